@@ -0,0 +1,89 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+	"github.com/aitmiloud/ngxtui/internal/ui"
+)
+
+// observeAnomalies feeds tailed entries to the anomaly detector and announces
+// the anomalies that started. Ticks call it without entries so sites that
+// stopped logging are noticed too.
+func observeAnomalies(m *model.Model, entries []nginx.LogEntry, now time.Time) tea.Cmd {
+	detector, _ := m.Anomalies.(*nginx.AnomalyDetector)
+	started := detector.Observe(entries, now)
+	if len(started) == 0 {
+		return nil
+	}
+	m.NotificationsUnread += len(started)
+
+	first := started[0]
+	m.StatusMsg = fmt.Sprintf("%s on %s: %s", first.Kind, ui.AnomalySite(first.Site), first.Detail())
+	if len(started) > 1 {
+		m.StatusMsg += fmt.Sprintf(" (and %d more, ! to list)", len(started)-1)
+	}
+	m.IsError = true
+	m.ShowStatus = true
+	return clearStatusAfter(5 * time.Second)
+}
+
+// openNotifications opens the notifications panel on the newest anomaly
+func openNotifications(m model.Model) (model.Model, tea.Cmd) {
+	m.NotificationsOpen = true
+	m.NotificationCursor = 0
+	m.NotificationsUnread = 0
+	return m, nil
+}
+
+// handleNotificationsKey moves through the notifications panel. Enter opens
+// the Logs tab on the selected anomaly's site and time.
+func handleNotificationsKey(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	detector, _ := m.Anomalies.(*nginx.AnomalyDetector)
+	events := detector.Events()
+	switch {
+	case key.Matches(msg, model.Keys.Back), key.Matches(msg, model.Keys.Alerts):
+		m.NotificationsOpen = false
+	case key.Matches(msg, model.Keys.Up):
+		if m.NotificationCursor > 0 {
+			m.NotificationCursor--
+		}
+	case key.Matches(msg, model.Keys.Down):
+		if m.NotificationCursor < len(events)-1 {
+			m.NotificationCursor++
+		}
+	case key.Matches(msg, model.Keys.Enter):
+		if m.NotificationCursor >= len(events) {
+			return m, nil
+		}
+		m.NotificationsOpen = false
+		return showAnomalyLogs(m, events[m.NotificationCursor])
+	}
+	return m, nil
+}
+
+// showAnomalyLogs opens the Logs tab filtered to the requests of an anomaly
+func showAnomalyLogs(m model.Model, anomaly nginx.Anomaly) (model.Model, tea.Cmd) {
+	expr := "since:" + anomaly.Since.Format(time.RFC3339)
+	if !anomaly.Ongoing() {
+		expr += " until:" + anomaly.Until.Format(time.RFC3339)
+	}
+	if anomaly.Kind == nginx.ErrorSpike {
+		expr = "status:5xx " + expr
+	}
+
+	m.ActiveTab = model.LogsTab
+	sources, _ := m.LogSources.([]nginx.LogSource)
+	m.LogSource = 0
+	if anomaly.Site != "" {
+		if i, ok := nginx.SiteLogSource(sources, anomaly.Site, nginx.AccessLogKind); ok {
+			m.LogSource = i
+		}
+	}
+	return runLogQuery(m, expr)
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+)
+
+// observeBans counts tailed entries against the ban rules and applies any
+// bans they trigger
+func observeBans(m *model.Model, entries []nginx.LogEntry, errors []nginx.ErrorLogEntry) tea.Cmd {
+	banner, _ := m.Banner.(*nginx.Banner)
+	banned := banner.Observe(entries, errors, time.Now())
+	if len(banned) == 0 {
+		return nil
+	}
+
+	var names []string
+	for _, ban := range banned {
+		names = append(names, ban.IP)
+	}
+	m.StatusMsg = fmt.Sprintf("Banned %s (%s)", strings.Join(names, ", "), banned[0].Rule)
+	m.IsError = true
+	m.ShowStatus = true
+	return tea.Batch(applyBans(m), clearStatusAfter(3*time.Second))
+}
+
+// expireBans lifts expired bans and applies the change
+func expireBans(m *model.Model, now time.Time) tea.Cmd {
+	banner, _ := m.Banner.(*nginx.Banner)
+	if len(banner.Expire(now)) == 0 {
+		return nil
+	}
+	return applyBans(m)
+}
+
+// applyBans writes the active bans out and reloads NGINX in the background.
+// Only one run goes at a time; changes made meanwhile are applied after it.
+func applyBans(m *model.Model) tea.Cmd {
+	if m.BansApplying {
+		m.BansPending = true
+		return nil
+	}
+	banner, _ := m.Banner.(*nginx.Banner)
+	if banner == nil {
+		return nil
+	}
+	m.BansApplying = true
+	bans := banner.Active()
+	cfg := m.Config.Bans
+	return func() tea.Msg {
+		return model.BansAppliedMsg{Bans: bans, Err: nginx.New().ApplyBans(cfg, bans)}
+	}
+}
+
+// handleBansAppliedMsg records the outcome of applying the bans
+func handleBansAppliedMsg(m model.Model, msg model.BansAppliedMsg) (model.Model, tea.Cmd) {
+	m.BansApplying = false
+	m.BansErr = msg.Err
+	var cmds []tea.Cmd
+	if bans, ok := msg.Bans.([]nginx.Ban); ok && msg.Err == nil {
+		banner, _ := m.Banner.(*nginx.Banner)
+		banner.MarkApplied(bans)
+	}
+	if msg.Err != nil {
+		m.StatusMsg = msg.Err.Error()
+		m.IsError = true
+		m.ShowStatus = true
+		cmds = append(cmds, clearStatusAfter(3*time.Second))
+	}
+	if m.BansPending {
+		m.BansPending = false
+		cmds = append(cmds, applyBans(&m))
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// handleBansTab handles key events in the Bans tab
+func handleBansTab(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	banner, _ := m.Banner.(*nginx.Banner)
+	bans := banner.Active()
+
+	switch {
+	case key.Matches(msg, model.Keys.Up):
+		if m.BanCursor > 0 {
+			m.BanCursor--
+		}
+	case key.Matches(msg, model.Keys.Down):
+		if m.BanCursor < len(bans)-1 {
+			m.BanCursor++
+		}
+	case key.Matches(msg, model.Keys.Unban):
+		if m.BanCursor >= len(bans) {
+			return m, nil
+		}
+		ip := bans[m.BanCursor].IP
+		banner.Unban(ip)
+		if m.BanCursor > 0 && m.BanCursor >= len(bans)-1 {
+			m.BanCursor--
+		}
+		m.StatusMsg = "Unbanned " + ip
+		m.IsError = false
+		m.ShowStatus = true
+		return m, tea.Batch(applyBans(&m), clearStatusAfter(2*time.Second))
+	}
+	return m, nil
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/aitmiloud/ngxtui/internal/config"
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+)
+
+// loadCertificates loads the certificate inventory in the background
+func loadCertificates() tea.Cmd {
+	return func() tea.Msg {
+		nginxService := nginx.New()
+		certs, err := nginxService.GetCertificates()
+		return model.CertificatesMsg{
+			Certificates: certs,
+			Err:          err,
+		}
+	}
+}
+
+// handleCertificatesTab handles key events in the certificates tab
+func handleCertificatesTab(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	certs, _ := m.Certificates.([]nginx.Certificate)
+
+	if key.Matches(msg, model.Keys.Up) {
+		if m.CertCursor > 0 {
+			m.CertCursor--
+		}
+	} else if key.Matches(msg, model.Keys.Down) {
+		if m.CertCursor < len(certs)-1 {
+			m.CertCursor++
+		}
+	} else if key.Matches(msg, model.Keys.Renew) {
+		return m, tea.Sequence(renewCertificates(m.Config, false), loadCertificates())
+	}
+	return m, nil
+}
+
+// acmeTimeout bounds a single ACME issuance or renewal run
+const acmeTimeout = 5 * time.Minute
+
+// renewCertificates renews managed certificates that are due. When quiet is
+// set, nothing is reported unless a certificate was renewed or renewal failed.
+func renewCertificates(cfg *config.Config, quiet bool) tea.Cmd {
+	return func() tea.Msg {
+		issuer := nginx.NewACMEIssuer(nginx.New(), cfg.ACME)
+		ctx, cancel := context.WithTimeout(context.Background(), acmeTimeout)
+		defer cancel()
+
+		renewed, err := issuer.RenewDue(ctx)
+		if err != nil {
+			return model.StatusMsg{Message: err.Error(), IsError: true}
+		}
+		if len(renewed) == 0 {
+			if quiet {
+				return nil
+			}
+			return model.StatusMsg{Message: "No managed certificates are due for renewal"}
+		}
+
+		var sites []string
+		for _, mc := range renewed {
+			sites = append(sites, mc.Site)
+		}
+		return model.StatusMsg{Message: "Renewed certificates for " + strings.Join(sites, ", ")}
+	}
+}
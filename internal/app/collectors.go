@@ -0,0 +1,132 @@
+package app
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/aitmiloud/ngxtui/internal/config"
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+)
+
+// statsInterval is how often the Stats tab data is collected while visible.
+// Collection runs the configuration test, so it is kept well above the tick rate.
+const statsInterval = 5 * time.Second
+
+// collectMetrics samples process, network and connection metrics in the background
+func collectMetrics(cfg *config.Config) tea.Cmd {
+	return func() tea.Msg {
+		nginxService := nginx.New().WithMetricsConfig(cfg.Metrics)
+		metrics, err := nginxService.GetMetrics()
+		return model.MetricsMsg{Metrics: metrics, Err: err}
+	}
+}
+
+// collectStats gathers the Stats tab data in the background
+func collectStats(cfg *config.Config) tea.Cmd {
+	return func() tea.Msg {
+		nginxService := nginx.New().WithMetricsConfig(cfg.Metrics)
+		stats, err := nginxService.GetStats()
+		return model.StatsMsg{
+			Stats:  stats,
+			Err:    err,
+			Health: nginxService.GetHealth(),
+		}
+	}
+}
+
+// scheduleCollectors starts the collectors the active tab needs, unless
+// one is already running or its data is still fresh
+func scheduleCollectors(m *model.Model) tea.Cmd {
+	switch m.ActiveTab {
+	case model.MetricsTab:
+		if !m.MetricsPending {
+			m.MetricsPending = true
+			return collectMetrics(m.Config)
+		}
+	case model.StatsTab:
+		if !m.StatsPending && time.Since(m.StatsUpdated) >= statsInterval {
+			m.StatsPending = true
+			return collectStats(m.Config)
+		}
+	}
+	return nil
+}
+
+// handleMetricsMsg adds a metrics sample to the chart histories
+func handleMetricsMsg(m model.Model, msg model.MetricsMsg) model.Model {
+	m.MetricsPending = false
+	metrics, ok := msg.Metrics.(*nginx.Metrics)
+	if msg.Err != nil || !ok {
+		return m
+	}
+
+	// Calculate network rate (MB/s) from change in total bytes
+	var networkRate float64
+	if m.LastNetworkIn > 0 && m.LastNetworkOut > 0 {
+		// Calculate change since last measurement
+		deltaIn := metrics.NetworkIn - m.LastNetworkIn
+		deltaOut := metrics.NetworkOut - m.LastNetworkOut
+		networkRate = (deltaIn + deltaOut) / 1024 // Convert to MB/s (measured per second)
+	}
+
+	// Store current values for next calculation
+	m.LastNetworkIn = metrics.NetworkIn
+	m.LastNetworkOut = metrics.NetworkOut
+
+	// Request rate from the request counter's change since the last
+	// sample, or from the tailed access logs without one
+	now := time.Now()
+	var prev *nginx.StubStatus
+	if last, _ := m.LastMetrics.(*nginx.Metrics); last != nil {
+		prev = last.Connections
+	}
+	rate, ok := metrics.Connections.RequestRate(prev)
+	if !ok {
+		live, _ := m.LiveTraffic.(*nginx.LiveTraffic)
+		rate = live.Rate(now)
+	}
+	metrics.RequestRate = rate
+	m.PrevMetrics = m.LastMetrics
+	m.LastMetrics = metrics
+
+	// Latency of the shortest window, in milliseconds
+	refreshLatency(&m, now)
+	var p50, p90, p99 float64
+	if reports, _ := m.Latency.([]*nginx.LatencyReport); len(reports) > 0 {
+		request := reports[0].Overall.Request
+		p50 = float64(request.P50) / float64(time.Millisecond)
+		p90 = float64(request.P90) / float64(time.Millisecond)
+		p99 = float64(request.P99) / float64(time.Millisecond)
+	}
+
+	// Add new data point (will grow from 0 to 50 points)
+	if len(m.CPUHistory) < 50 {
+		// Still filling up - just append
+		m.CPUHistory = append(m.CPUHistory, metrics.CPU)
+		m.MemHistory = append(m.MemHistory, metrics.Memory)
+		m.NetHistory = append(m.NetHistory, networkRate)
+		m.RequestHistory = append(m.RequestHistory, metrics.RequestRate)
+		m.LatencyP50History = append(m.LatencyP50History, p50)
+		m.LatencyP90History = append(m.LatencyP90History, p90)
+		m.LatencyP99History = append(m.LatencyP99History, p99)
+	} else {
+		// Full - shift and add new data
+		m.CPUHistory = append(m.CPUHistory[1:], metrics.CPU)
+		m.MemHistory = append(m.MemHistory[1:], metrics.Memory)
+		m.NetHistory = append(m.NetHistory[1:], networkRate)
+		m.RequestHistory = append(m.RequestHistory[1:], metrics.RequestRate)
+		m.LatencyP50History = append(m.LatencyP50History[1:], p50)
+		m.LatencyP90History = append(m.LatencyP90History[1:], p90)
+		m.LatencyP99History = append(m.LatencyP99History[1:], p99)
+	}
+	return m
+}
+
+// refreshLatency recomputes live latency over the tailed timings. Windows
+// slide with the clock, so this runs on every sample rather than per batch.
+func refreshLatency(m *model.Model, now time.Time) {
+	live, _ := m.LiveLatency.(*nginx.LiveLatency)
+	m.Latency = live.Windows(now)
+}
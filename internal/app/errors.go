@@ -0,0 +1,78 @@
+package app
+
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+)
+
+// errorLogLevels are the minimum levels the level filter cycles through
+var errorLogLevels = []nginx.ErrorLevel{nginx.LevelDebug, nginx.LevelWarn, nginx.LevelError, nginx.LevelCrit}
+
+// handleErrorLogTab handles key events in the Error Log tab
+func handleErrorLogTab(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	entries, _ := m.ErrorLogEntries.([]nginx.ErrorLogEntry)
+	visible := nginx.FilterErrorLogEntries(entries, nginx.ErrorLevel(m.ErrorLogLevel))
+	rows := len(visible)
+	if m.ErrorLogGrouped {
+		rows = len(nginx.GroupErrorLogEntries(visible))
+	}
+
+	switch {
+	case key.Matches(msg, model.Keys.Up):
+		if m.ErrorLogCursor > 0 {
+			m.ErrorLogCursor--
+		}
+	case key.Matches(msg, model.Keys.Down):
+		if m.ErrorLogCursor < rows-1 {
+			m.ErrorLogCursor++
+		}
+	case key.Matches(msg, model.Keys.Level):
+		next := 0
+		for i, level := range errorLogLevels {
+			if int(level) == m.ErrorLogLevel {
+				next = (i + 1) % len(errorLogLevels)
+			}
+		}
+		m.ErrorLogLevel = int(errorLogLevels[next])
+		m.ErrorLogCursor = 0
+	case key.Matches(msg, model.Keys.Group):
+		m.ErrorLogGrouped = !m.ErrorLogGrouped
+		m.ErrorLogCursor = 0
+	case key.Matches(msg, model.Keys.Enter):
+		if m.ErrorLogCursor >= rows {
+			return m, nil
+		}
+		entry := visible[m.ErrorLogCursor]
+		if m.ErrorLogGrouped {
+			entry = nginx.GroupErrorLogEntries(visible)[m.ErrorLogCursor].Latest
+		}
+		return jumpToAccessLog(m, entry)
+	}
+	return m, nil
+}
+
+// jumpToAccessLog opens the Logs tab filtered to the request an error happened in
+func jumpToAccessLog(m model.Model, entry nginx.ErrorLogEntry) (model.Model, tea.Cmd) {
+	expr := entry.AccessQuery()
+	if expr == "" {
+		m.StatusMsg = "This error has no client to match against the access log"
+		m.IsError = true
+		m.ShowStatus = true
+		return m, clearStatusAfter(2 * time.Second)
+	}
+
+	m.ActiveTab = model.LogsTab
+	sources, _ := m.LogSources.([]nginx.LogSource)
+	m.LogSource = 0
+	if entry.Site != "" {
+		if i, ok := nginx.SiteLogSource(sources, entry.Site, nginx.AccessLogKind); ok {
+			m.LogSource = i
+		}
+	}
+	return runLogQuery(m, expr)
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"io"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+	"github.com/aitmiloud/ngxtui/internal/ui"
+)
+
+// openExportPicker opens the export format picker. Error logs have no
+// entries to export.
+func openExportPicker(m model.Model) (model.Model, tea.Cmd) {
+	if m.ActiveTab == model.LogsTab && currentLogSource(m).Kind == nginx.ErrorLogKind {
+		m.StatusMsg = "Only access logs can be exported"
+		m.IsError = true
+		m.ShowStatus = true
+		return m, clearStatusAfter(2 * time.Second)
+	}
+	m.ExportPicking = true
+	return m, nil
+}
+
+// handleExportKey moves through the export format picker
+func handleExportKey(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, model.Keys.Back), key.Matches(msg, model.Keys.Export):
+		m.ExportPicking = false
+	case key.Matches(msg, model.Keys.Up):
+		if m.ExportCursor > 0 {
+			m.ExportCursor--
+		}
+	case key.Matches(msg, model.Keys.Down):
+		if m.ExportCursor < len(nginx.ExportFormats)-1 {
+			m.ExportCursor++
+		}
+	case key.Matches(msg, model.Keys.Enter):
+		m.ExportPicking = false
+		return export(m, nginx.ExportFormats[m.ExportCursor])
+	}
+	return m, nil
+}
+
+// export writes what the active tab shows to a file in the background: the
+// Logs tab's filtered entries or the Stats tab's statistics
+func export(m model.Model, f nginx.ExportFormat) (model.Model, tea.Cmd) {
+	dir := m.Config.Logs.ExportDir
+
+	if m.ActiveTab == model.StatsTab {
+		stats := currentLogStats(m)
+		if stats == nil {
+			m.StatusMsg = "No statistics to export yet"
+			m.IsError = true
+			m.ShowStatus = true
+			return m, clearStatusAfter(2 * time.Second)
+		}
+		return m, func() tea.Msg {
+			path, err := nginx.ExportFile(dir, "stats", f, func(w io.Writer) error {
+				return nginx.ExportLogStats(w, stats, f)
+			})
+			return model.ExportedMsg{Path: path, Err: err}
+		}
+	}
+
+	entries, _ := ui.ShownLogEntries(&m)
+	return m, func() tea.Msg {
+		path, err := nginx.ExportFile(dir, "logs", f, func(w io.Writer) error {
+			return nginx.ExportLogEntries(w, entries, f)
+		})
+		return model.ExportedMsg{Path: path, Err: err}
+	}
+}
+
+// currentLogStats returns the statistics over the selected time range, or
+// nil while they are still being read
+func currentLogStats(m model.Model) *nginx.LogStats {
+	if currentTimeRange(m).Live() {
+		stats, _ := m.LogStats.(*nginx.LogStats)
+		return stats
+	}
+	if m.RangeStatsPending {
+		return nil
+	}
+	stats, _ := m.RangeStats.(*nginx.LogStats)
+	return stats
+}
+
+// handleExportedMsg reports where an export was written
+func handleExportedMsg(m model.Model, msg model.ExportedMsg) (model.Model, tea.Cmd) {
+	if msg.Err != nil {
+		m.StatusMsg = msg.Err.Error()
+		m.IsError = true
+	} else {
+		m.StatusMsg = "Exported to " + msg.Path
+		m.IsError = false
+	}
+	m.ShowStatus = true
+	return m, clearStatusAfter(3 * time.Second)
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+)
+
+// currentTimeRange returns the selected time range, evaluated now so
+// relative ranges keep sliding
+func currentTimeRange(m model.Model) nginx.TimeRange {
+	return nginx.SelectedTimeRange(m.LogRange, time.Now())
+}
+
+// openLogRangePicker opens the time-range picker on the selected range
+func openLogRangePicker(m model.Model) (model.Model, tea.Cmd) {
+	m.LogRangePicking = true
+	m.LogRangeCursor = m.LogRange
+	return m, nil
+}
+
+// handleLogRangeKey moves through the time-range picker. Choosing a range
+// searches the logs and recomputes the Stats tab's traffic over it.
+func handleLogRangeKey(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, model.Keys.Back), key.Matches(msg, model.Keys.Range):
+		m.LogRangePicking = false
+	case key.Matches(msg, model.Keys.Up):
+		if m.LogRangeCursor > 0 {
+			m.LogRangeCursor--
+		}
+	case key.Matches(msg, model.Keys.Down):
+		if m.LogRangeCursor < len(nginx.TimeRanges(time.Now()))-1 {
+			m.LogRangeCursor++
+		}
+	case key.Matches(msg, model.Keys.Enter):
+		m.LogRangePicking = false
+		m.LogRange = m.LogRangeCursor
+		var searchCmd, statsCmd tea.Cmd
+		m, searchCmd = searchLogs(m)
+		m, statsCmd = loadRangeStats(m)
+		return m, tea.Batch(searchCmd, statsCmd)
+	}
+	return m, nil
+}
+
+// loadRangeStats computes access log statistics over the selected range in
+// the background. The live window's statistics come from the tailer instead.
+func loadRangeStats(m model.Model) (model.Model, tea.Cmd) {
+	m.RangeStatsID++
+	m.RangeStats = nil
+	m.RangeStatsErr = nil
+	m.RangeStatsPending = false
+
+	tr := currentTimeRange(m)
+	if tr.Live() {
+		return m, nil
+	}
+	m.RangeStatsPending = true
+	id, cfg := m.RangeStatsID, m.Config
+	return m, func() tea.Msg {
+		stats, err := nginx.New().WithLogConfig(cfg.Logs).GetLogStats(tr)
+		return model.LogRangeStatsMsg{ID: id, Stats: stats, Err: err}
+	}
+}
+
+// handleLogRangeStatsMsg stores statistics computed over the selected range
+func handleLogRangeStatsMsg(m model.Model, msg model.LogRangeStatsMsg) (model.Model, tea.Cmd) {
+	if msg.ID != m.RangeStatsID {
+		// The range changed while the logs were read
+		return m, nil
+	}
+	m.RangeStatsPending = false
+	m.RangeStats = msg.Stats
+	m.RangeStatsErr = msg.Err
+	return m, nil
+}
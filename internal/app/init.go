@@ -1,14 +1,16 @@
 package app
 
 import (
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
-	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/aitmiloud/ngxtui/internal/config"
 	"github.com/aitmiloud/ngxtui/internal/model"
 	"github.com/aitmiloud/ngxtui/internal/nginx"
 	"github.com/aitmiloud/ngxtui/internal/styles"
@@ -25,12 +27,14 @@ func InitialModel() model.Model {
 	// Initialize progress bar
 	prog := progress.New(progress.WithDefaultGradient())
 
-	// Initialize viewport for logs
-	vp := viewport.New(80, 20)
-	vp.Style = lipgloss.NewStyle().
-		BorderStyle(lipgloss.RoundedBorder()).
-		BorderForeground(styles.BorderSubtle).
-		Padding(1)
+	// Load user configuration (defaults if the file is missing),
+	// and each subsystem it configures on its own, so one bad setting
+	// doesn't keep the others off
+	cfg, cfgErr := config.Load()
+	cfgErr = errors.Join(cfgErr, nginx.LoadBotSignatures(cfg.Logs.BotSignatures))
+	cfgErr = errors.Join(cfgErr, nginx.LoadGeoIP(cfg.GeoIP.CountryDB, cfg.GeoIP.ASNDB))
+	banner, bansErr := nginx.NewBanner(cfg.Bans)
+	cfgErr = errors.Join(cfgErr, bansErr)
 
 	// Load initial sites
 	nginxService := nginx.New()
@@ -48,7 +52,7 @@ func InitialModel() model.Model {
 	netHistory := make([]float64, 0, 50)
 	requestHistory := make([]float64, 0, 50)
 
-	return model.Model{
+	m := model.Model{
 		Sites:          sites,
 		Table:          t,
 		Cursor:         0,
@@ -57,14 +61,29 @@ func InitialModel() model.Model {
 		ActiveTab:      model.SitesTab,
 		Spinner:        s,
 		Loading:        false,
-		Viewport:       vp,
 		CPUHistory:     cpuHistory,
 		MemHistory:     memHistory,
 		NetHistory:     netHistory,
 		RequestHistory: requestHistory,
 		Progress:       prog,
 		LastUpdate:     time.Now(),
+		Config:         cfg,
+		LogFilterInput: newLogFilterInput(),
+		LogJumpInput:   newLogJumpInput(),
+		LogFollow:      true,
+		Anomalies:      nginx.NewAnomalyDetector(time.Now()),
 	}
+	if banner != nil {
+		m.Banner = banner
+	}
+
+	if cfgErr != nil {
+		m.StatusMsg = strings.ReplaceAll(cfgErr.Error(), "\n", "; ")
+		m.IsError = true
+		m.ShowStatus = true
+	}
+
+	return m
 }
 
 // TickCmd returns a command that sends tick messages
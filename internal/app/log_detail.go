@@ -0,0 +1,57 @@
+package app
+
+import (
+	"os"
+	"time"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+	"github.com/aitmiloud/ngxtui/internal/ui"
+)
+
+// openLogDetail opens the detail pane of the entry under the cursor
+func openLogDetail(m model.Model, entries []nginx.LogEntry) model.Model {
+	if m.LogCursor < len(entries) {
+		// The pane keeps its own copy so it isn't moved by new entries
+		entry := entries[len(entries)-1-m.LogCursor]
+		m.LogDetail = &entry
+	}
+	return m
+}
+
+// handleLogDetailKey handles key events while the detail pane is open
+func handleLogDetailKey(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, model.Keys.Back), key.Matches(msg, model.Keys.Enter):
+		m.LogDetail = nil
+	case key.Matches(msg, model.Keys.Copy):
+		entry, _ := m.LogDetail.(*nginx.LogEntry)
+		if entry.ParseErr != nil {
+			return m, nil
+		}
+		m.StatusMsg = "Copied the curl command to the clipboard"
+		m.IsError = false
+		m.ShowStatus = true
+		return m, tea.Batch(copyToClipboard(entry.CurlCommand(ui.LogEntryScheme(&m, *entry))), clearStatusAfter(2*time.Second))
+	}
+	return m, nil
+}
+
+// copyToClipboard sets the terminal's clipboard with an OSC 52 sequence,
+// which also works over SSH when the terminal allows it
+func copyToClipboard(text string) tea.Cmd {
+	return func() tea.Msg {
+		seq := osc52.New(text)
+		if os.Getenv("TMUX") != "" {
+			seq = seq.Tmux()
+		} else if os.Getenv("STY") != "" {
+			seq = seq.Screen()
+		}
+		seq.WriteTo(os.Stderr)
+		return nil
+	}
+}
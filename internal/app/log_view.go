@@ -0,0 +1,173 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+	"github.com/aitmiloud/ngxtui/internal/ui"
+)
+
+// handleLogsTab scrolls through the shown access log entries and opens the
+// detail pane of the selected one
+func handleLogsTab(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	entries, _ := ui.ShownLogEntries(&m)
+	oldest := max(len(entries)-1, 0)
+	switch {
+	case key.Matches(msg, model.Keys.Up):
+		m = scrollLogs(m, 1, oldest)
+	case key.Matches(msg, model.Keys.Down):
+		m = scrollLogs(m, -1, oldest)
+	case key.Matches(msg, model.Keys.PageUp):
+		m = scrollLogs(m, logPageSize(m), oldest)
+	case key.Matches(msg, model.Keys.PageDown):
+		m = scrollLogs(m, -logPageSize(m), oldest)
+	case key.Matches(msg, model.Keys.Home):
+		m = scrollLogs(m, oldest, oldest)
+	case key.Matches(msg, model.Keys.End):
+		m = followLogTail(m)
+	case key.Matches(msg, model.Keys.Follow):
+		if m.LogFollow {
+			m.LogFollow = false
+		} else {
+			m = followLogTail(m)
+		}
+	case key.Matches(msg, model.Keys.Pause):
+		m = toggleLogPause(m)
+	case key.Matches(msg, model.Keys.Jump):
+		m.LogJumping = true
+		m.LogJumpInput.SetValue("")
+		return m, m.LogJumpInput.Focus()
+	case key.Matches(msg, model.Keys.Enter):
+		m = openLogDetail(m, entries)
+	}
+	return m, nil
+}
+
+// logPageSize is about how many entries the Logs tab lists at once
+func logPageSize(m model.Model) int {
+	return max(m.Height-20, 5)
+}
+
+// scrollLogs moves the cursor by delta entries, positive going back in time.
+// Scrolling back stops following the tail.
+func scrollLogs(m model.Model, delta, oldest int) model.Model {
+	m.LogCursor = min(max(m.LogCursor+delta, 0), oldest)
+	if delta > 0 {
+		m.LogFollow = false
+	}
+	return m
+}
+
+// followLogTail resumes the view and keeps the cursor on the newest entry
+func followLogTail(m model.Model) model.Model {
+	m.LogCursor = 0
+	m.LogFollow = true
+	m.LogPaused = false
+	m.LogFrozen = nil
+	m.LogPausedNew = 0
+	return m
+}
+
+// toggleLogPause freezes the shown entries, or resumes the live view. A
+// cursor that isn't following stays on its entry across the pause.
+func toggleLogPause(m model.Model) model.Model {
+	if m.LogPaused {
+		if !m.LogFollow {
+			m.LogCursor += m.LogPausedNew
+		}
+		m.LogPaused = false
+		m.LogFrozen = nil
+		m.LogPausedNew = 0
+		return m
+	}
+	entries, _ := ui.ShownLogEntries(&m)
+	// Copied, since new batches may be sorted into the live window in place
+	m.LogFrozen = slices.Clone(entries)
+	m.LogPaused = true
+	m.LogPausedNew = 0
+	return m
+}
+
+// newLogJumpInput creates the Logs tab jump-to-time input
+func newLogJumpInput() textinput.Model {
+	input := textinput.New()
+	input.Prompt = "jump to: "
+	input.Placeholder = "14:05, 14:05:30, 2025-01-31T14:05, or 10m for ten minutes ago"
+	input.CharLimit = 64
+	return input
+}
+
+// handleLogJumpKey edits the jump-to-time input
+func handleLogJumpKey(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, model.Keys.Back):
+		m.LogJumping = false
+		m.LogJumpInput.Blur()
+		return m, nil
+
+	case key.Matches(msg, model.Keys.Enter):
+		at, err := nginx.ParseLogTime(strings.TrimSpace(m.LogJumpInput.Value()))
+		if err != nil {
+			m.StatusMsg = err.Error()
+			m.IsError = true
+			m.ShowStatus = true
+			return m, clearStatusAfter(3 * time.Second)
+		}
+		m.LogJumping = false
+		m.LogJumpInput.Blur()
+		return jumpToLogTime(m, at)
+	}
+
+	var cmd tea.Cmd
+	m.LogJumpInput, cmd = m.LogJumpInput.Update(msg)
+	return m, cmd
+}
+
+// jumpToLogTime selects the first shown entry logged at or after a time
+func jumpToLogTime(m model.Model, at time.Time) (model.Model, tea.Cmd) {
+	entries, _ := ui.ShownLogEntries(&m)
+	if len(entries) == 0 {
+		return m, nil
+	}
+	i := sort.Search(len(entries), func(i int) bool { return !entries[i].Timestamp.Before(at) })
+	i = min(i, len(entries)-1)
+	m.LogCursor = len(entries) - 1 - i
+	m.LogFollow = false
+
+	m.StatusMsg = "Jumped to " + entries[i].Timestamp.Format("15:04:05")
+	m.IsError = false
+	if at.Before(entries[0].Timestamp) {
+		m.StatusMsg = fmt.Sprintf("The oldest entry kept is from %s; choose a time range (t) to go further back",
+			entries[0].Timestamp.Format("Jan 2 15:04:05"))
+	}
+	m.ShowStatus = true
+	return m, clearStatusAfter(3 * time.Second)
+}
+
+// newlyShownEntries counts the tailed entries the Logs tab will list, so a
+// cursor away from the tail stays on the entry it selected
+func newlyShownEntries(m model.Model, entries []nginx.LogEntry) int {
+	source := currentLogSource(m)
+	query, _ := m.LogQuery.(*nginx.LogQuery)
+	tr := currentTimeRange(m)
+	shown := 0
+	for _, entry := range entries {
+		if len(source.Paths) > 0 && !source.Includes(entry.Log) {
+			continue
+		}
+		if logMatchesShown(m) && !(tr.Contains(entry.Timestamp) && query.Match(entry)) {
+			continue
+		}
+		shown++
+	}
+	return shown
+}
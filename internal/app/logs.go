@@ -0,0 +1,364 @@
+package app
+
+import (
+	"slices"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/aitmiloud/ngxtui/internal/config"
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+)
+
+// logWindow is how many access log entries are kept in memory
+const logWindow = 2000
+
+// errorLogWindow is how many error log lines are kept in memory
+const errorLogWindow = 1000
+
+// logMatchLimit is how many matches of a filter query are kept
+const logMatchLimit = 5000
+
+// startLogTail starts following the configured access and error logs
+func startLogTail(cfg *config.Config) tea.Cmd {
+	return func() tea.Msg {
+		nginxService := nginx.New().WithLogConfig(cfg.Logs)
+		access, errorLogs := nginxService.AccessLogs(), nginxService.ErrorLogs()
+		tailer := nginx.NewLogTailer(access, errorLogs, logWindow)
+		tailer.Start()
+		return model.LogTailStartedMsg{Tailer: tailer, Sources: nginx.LogSources(access, errorLogs)}
+	}
+}
+
+// openSiteLogs resolves the logs again, since the site may have been enabled
+// or created since the tailer started, and selects the site's access log.
+// The tailer is only replaced when the set of files changed.
+func openSiteLogs(cfg *config.Config, current interface{}, site string) tea.Cmd {
+	return func() tea.Msg {
+		nginxService := nginx.New().WithLogConfig(cfg.Logs)
+		access, errorLogs := nginxService.AccessLogs(), nginxService.ErrorLogs()
+		sources := nginx.LogSources(access, errorLogs)
+
+		tailer, _ := current.(*nginx.LogTailer)
+		next := nginx.NewLogTailer(access, errorLogs, logWindow)
+		if tailer != nil && slices.Equal(tailer.Paths(), next.Paths()) {
+			return model.LogTailStartedMsg{Tailer: tailer, Sources: sources, Site: site}
+		}
+		if tailer != nil {
+			tailer.Stop()
+		}
+		next.Start()
+		return model.LogTailStartedMsg{Tailer: next, Sources: sources, Site: site}
+	}
+}
+
+// waitForLogBatch waits for the tailer's next batch of entries
+func waitForLogBatch(tailer *nginx.LogTailer) tea.Cmd {
+	return func() tea.Msg {
+		batch, ok := <-tailer.Batches()
+		if !ok {
+			// The tailer was stopped
+			return nil
+		}
+		return model.LogBatchMsg{Tailer: tailer, Batch: batch}
+	}
+}
+
+// handleLogTailMsg stores tailed entries and waits for the next batch
+func handleLogTailMsg(m model.Model, msg tea.Msg) (model.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case model.LogTailStartedMsg:
+		var cmd tea.Cmd
+		if msg.Tailer != m.LogTailer {
+			// A new tailer reads every log from its tail again
+			m.LogTailer = msg.Tailer
+			m.LogEntries = nil
+			m.LogStats = nil
+			m.LogErr = nil
+			m.ErrorLogEntries = nil
+			m.LogMatches = nil
+			m.LiveTraffic = nginx.NewLiveTraffic()
+			m.LiveLatency = nginx.NewLiveLatency()
+			if detector, ok := m.Anomalies.(*nginx.AnomalyDetector); ok {
+				detector.Restart(time.Now())
+			}
+			if tailer, ok := msg.Tailer.(*nginx.LogTailer); ok {
+				cmd = waitForLogBatch(tailer)
+			}
+		}
+		m, statusCmd := selectLogSource(m, msg)
+		return m, tea.Batch(cmd, statusCmd)
+
+	case model.LogBatchMsg:
+		if msg.Tailer != m.LogTailer {
+			// Left over from a tailer that was replaced
+			return m, nil
+		}
+		var banned, flagged tea.Cmd
+		if batch, ok := msg.Batch.(nginx.LogBatch); ok {
+			switch {
+			case batch.Err != nil && batch.Kind == nginx.AccessLogKind:
+				m.LogErr = batch.Err
+			case batch.Err != nil:
+				// A missing error log doesn't stop access logs from showing
+			case batch.Kind == nginx.ErrorLogKind:
+				banned = observeBans(&m, nil, batch.Errors)
+				errorEntries, _ := m.ErrorLogEntries.([]nginx.ErrorLogEntry)
+				errorEntries = append(errorEntries, batch.Errors...)
+				if len(errorEntries) > errorLogWindow {
+					errorEntries = append([]nginx.ErrorLogEntry(nil), errorEntries[len(errorEntries)-errorLogWindow:]...)
+				}
+				m.ErrorLogEntries = errorEntries
+			default:
+				switch {
+				case m.LogPaused:
+					m.LogPausedNew += newlyShownEntries(m, batch.Entries)
+				case !m.LogFollow:
+					m.LogCursor += newlyShownEntries(m, batch.Entries)
+				}
+				entries, _ := m.LogEntries.([]nginx.LogEntry)
+				entries = nginx.AppendLogEntries(entries, batch.Entries, logWindow)
+				m.LogEntries = entries
+				m.LogStats = nginx.ComputeLogStats(entries)
+				if live, ok := m.LiveTraffic.(*nginx.LiveTraffic); ok {
+					live.Add(batch.Entries, time.Now())
+				}
+				if live, ok := m.LiveLatency.(*nginx.LiveLatency); ok {
+					live.Add(batch.Entries, time.Now())
+				}
+				m.LogMatches = appendLogMatches(m, batch.Entries)
+				banned = observeBans(&m, batch.Entries, nil)
+				flagged = observeAnomalies(&m, batch.Entries, time.Now())
+			}
+		}
+		if tailer, ok := m.LogTailer.(*nginx.LogTailer); ok {
+			return m, tea.Batch(waitForLogBatch(tailer), banned, flagged)
+		}
+		return m, tea.Batch(banned, flagged)
+	}
+	return m, nil
+}
+
+// selectLogSource stores resolved sources and selects the requested site's access log
+func selectLogSource(m model.Model, msg model.LogTailStartedMsg) (model.Model, tea.Cmd) {
+	sources, _ := msg.Sources.([]nginx.LogSource)
+	current := currentLogSource(m)
+	m.LogSources = sources
+
+	// Keep showing the same source when the list changes under it
+	m.LogSource = 0
+	for i, src := range sources {
+		if src.Label == current.Label {
+			m.LogSource = i
+		}
+	}
+
+	if msg.Site != "" {
+		if i, ok := nginx.SiteLogSource(sources, msg.Site, nginx.AccessLogKind); ok {
+			m.LogSource = i
+		} else {
+			m.StatusMsg = "No access_log resolved for " + msg.Site + " (is the site enabled?), showing every log"
+			m.IsError = true
+			m.ShowStatus = true
+			m.LogSource = 0
+			return m, clearStatusAfter(3 * time.Second)
+		}
+	}
+	return m, nil
+}
+
+// currentLogSource returns the shown source, every access log by default
+func currentLogSource(m model.Model) nginx.LogSource {
+	sources, _ := m.LogSources.([]nginx.LogSource)
+	return nginx.SelectedLogSource(sources, m.LogSource)
+}
+
+// handleLogSourceKey moves through the source picker
+func handleLogSourceKey(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	sources, _ := m.LogSources.([]nginx.LogSource)
+	switch {
+	case key.Matches(msg, model.Keys.Back), key.Matches(msg, model.Keys.Source):
+		m.LogSourcePicking = false
+	case key.Matches(msg, model.Keys.Up):
+		if m.LogSourceCursor > 0 {
+			m.LogSourceCursor--
+		}
+	case key.Matches(msg, model.Keys.Down):
+		if m.LogSourceCursor < len(sources)-1 {
+			m.LogSourceCursor++
+		}
+	case key.Matches(msg, model.Keys.Enter):
+		m.LogSource = m.LogSourceCursor
+		m.LogSourcePicking = false
+		m = followLogTail(m)
+	}
+	return m, nil
+}
+
+// openLogSourcePicker opens the picker on the shown source
+func openLogSourcePicker(m model.Model) (model.Model, tea.Cmd) {
+	m.LogSourcePicking = true
+	m.LogSourceCursor = m.LogSource
+	return m, nil
+}
+
+// stopLogTail stops the background tailer, if one is running
+func stopLogTail(m model.Model) {
+	if tailer, ok := m.LogTailer.(*nginx.LogTailer); ok {
+		tailer.Stop()
+	}
+}
+
+// newLogFilterInput creates the Logs tab filter bar
+func newLogFilterInput() textinput.Model {
+	input := textinput.New()
+	input.Prompt = "/ "
+	input.Placeholder = "status:5xx method:POST path:/api/* ip:10.0.0.0/8 ua:~bot since:15m bytes>1M"
+	input.CharLimit = 256
+	return input
+}
+
+// openLogFilter gives the filter bar focus
+func openLogFilter(m model.Model) (model.Model, tea.Cmd) {
+	m.LogFiltering = true
+	return m, m.LogFilterInput.Focus()
+}
+
+// handleLogFilterKey edits the filter bar. The query is applied to the live
+// window on every keystroke; enter also searches the log files.
+func handleLogFilterKey(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, model.Keys.Back):
+		m.LogFiltering = false
+		m.LogFilterInput.Blur()
+		return clearLogFilter(m)
+
+	case key.Matches(msg, model.Keys.Enter):
+		return runLogQuery(m, m.LogFilterInput.Value())
+	}
+
+	var cmd tea.Cmd
+	m.LogFilterInput, cmd = m.LogFilterInput.Update(msg)
+	// Keep the last valid query while the expression is being typed
+	if query, err := nginx.ParseLogQuery(m.LogFilterInput.Value()); err != nil {
+		m.LogQueryErr = err
+	} else {
+		m = applyLogQuery(m, query)
+	}
+	return m, cmd
+}
+
+// runLogQuery applies a query to the live window and searches the log files with it
+func runLogQuery(m model.Model, expr string) (model.Model, tea.Cmd) {
+	query, err := nginx.ParseLogQuery(expr)
+	if err != nil {
+		m.LogQueryErr = err
+		return m, nil
+	}
+	m.LogFiltering = false
+	m.LogFilterInput.Blur()
+	m.LogFilterInput.SetValue(expr)
+	m = applyLogQuery(m, query)
+	return searchLogs(m)
+}
+
+// searchLogs refreshes the matches of the query and time range: from the
+// live window straight away, then from the files in the background
+func searchLogs(m model.Model) (model.Model, tea.Cmd) {
+	m.LogSearchID++
+	m.LogSearchErr = nil
+	m.LogSearchPending = false
+
+	query, _ := m.LogQuery.(*nginx.LogQuery)
+	tr := currentTimeRange(m)
+	entries, _ := m.LogEntries.([]nginx.LogEntry)
+	m.LogMatches = nil
+	m = followLogTail(m)
+	m.LogMatches = appendLogMatches(m, entries)
+	if query.Empty() && tr.Live() {
+		return m, nil
+	}
+	m.LogSearchPending = true
+	return m, searchAccessLogs(m.Config, query, tr, m.LogSearchID)
+}
+
+// applyLogQuery filters the live window with a query
+func applyLogQuery(m model.Model, query *nginx.LogQuery) model.Model {
+	m.LogQuery = nil
+	if !query.Empty() {
+		m.LogQuery = query
+	}
+	m.LogQueryErr = nil
+	m.LogMatches = nil
+	m = followLogTail(m)
+	entries, _ := m.LogEntries.([]nginx.LogEntry)
+	m.LogMatches = appendLogMatches(m, entries)
+	return m
+}
+
+// clearLogFilter removes the query; a historical time range stays applied
+func clearLogFilter(m model.Model) (model.Model, tea.Cmd) {
+	m.LogFilterInput.SetValue("")
+	m.LogQuery = nil
+	m.LogQueryErr = nil
+	return searchLogs(m)
+}
+
+// logMatchesShown reports whether the Logs tab shows matches rather than the live window
+func logMatchesShown(m model.Model) bool {
+	query, _ := m.LogQuery.(*nginx.LogQuery)
+	return !query.Empty() || !currentTimeRange(m).Live()
+}
+
+// appendLogMatches adds the entries matching the query and time range to the matches
+func appendLogMatches(m model.Model, entries []nginx.LogEntry) []nginx.LogEntry {
+	if !logMatchesShown(m) {
+		return nil
+	}
+	matches, _ := m.LogMatches.([]nginx.LogEntry)
+	query, _ := m.LogQuery.(*nginx.LogQuery)
+	tr := currentTimeRange(m)
+	var found []nginx.LogEntry
+	for _, entry := range entries {
+		if tr.Contains(entry.Timestamp) && query.Match(entry) {
+			found = append(found, entry)
+		}
+	}
+	return nginx.AppendLogEntries(matches, found, logMatchLimit)
+}
+
+// searchAccessLogs runs a query over the access log files within a time range
+func searchAccessLogs(cfg *config.Config, query *nginx.LogQuery, tr nginx.TimeRange, id int) tea.Cmd {
+	return func() tea.Msg {
+		entries, err := nginx.New().WithLogConfig(cfg.Logs).SearchAccessLogs(query, tr, logMatchLimit)
+		return model.LogSearchMsg{ID: id, Entries: entries, Err: err}
+	}
+}
+
+// handleLogSearchMsg replaces the live matches with the file search results
+func handleLogSearchMsg(m model.Model, msg model.LogSearchMsg) (model.Model, tea.Cmd) {
+	if msg.ID != m.LogSearchID {
+		// The query changed while the search ran
+		return m, nil
+	}
+	m.LogSearchPending = false
+	if msg.Err != nil {
+		m.LogSearchErr = msg.Err
+		return m, nil
+	}
+
+	// Entries the tailer delivered after the files were read are kept
+	found, _ := msg.Entries.([]nginx.LogEntry)
+	live, _ := m.LogMatches.([]nginx.LogEntry)
+	var newer []nginx.LogEntry
+	for _, entry := range live {
+		if len(found) == 0 || entry.Timestamp.After(found[len(found)-1].Timestamp) {
+			newer = append(newer, entry)
+		}
+	}
+	m.LogMatches = nginx.AppendLogEntries(found, newer, logMatchLimit)
+	return m, nil
+}
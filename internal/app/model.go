@@ -19,7 +19,11 @@ func New() *App {
 
 // Init implements tea.Model
 func (a *App) Init() tea.Cmd {
-	return a.Model.Init()
+	return tea.Batch(
+		a.Model.Init(),
+		renewCertificates(a.Model.Config, true),
+		startLogTail(a.Model.Config),
+	)
 }
 
 // Update implements tea.Model
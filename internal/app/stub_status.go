@@ -0,0 +1,44 @@
+package app
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+)
+
+// enableStubStatus sets up a local stub_status server in the background
+// when connection metrics can't be polled yet
+func enableStubStatus(m model.Model) (model.Model, tea.Cmd) {
+	if m.StubStatusBusy {
+		return m, nil
+	}
+	if metrics, _ := m.LastMetrics.(*nginx.Metrics); metrics != nil && metrics.Connections != nil {
+		m.StatusMsg = "Connections are already read from " + metrics.Source
+		m.IsError = false
+		m.ShowStatus = true
+		return m, clearStatusAfter(2 * time.Second)
+	}
+	m.StubStatusBusy = true
+	cfg := m.Config.Metrics
+	return m, func() tea.Msg {
+		endpoint, err := nginx.New().EnableStubStatus(cfg)
+		return model.StubStatusEnabledMsg{URL: endpoint.URL, Err: err}
+	}
+}
+
+// handleStubStatusEnabledMsg reports whether the stub_status server was set up
+func handleStubStatusEnabledMsg(m model.Model, msg model.StubStatusEnabledMsg) (model.Model, tea.Cmd) {
+	m.StubStatusBusy = false
+	if msg.Err != nil {
+		m.StatusMsg = msg.Err.Error()
+		m.IsError = true
+	} else {
+		m.StatusMsg = "stub_status is served at " + msg.URL
+		m.IsError = false
+	}
+	m.ShowStatus = true
+	return m, clearStatusAfter(3 * time.Second)
+}
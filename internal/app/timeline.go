@@ -0,0 +1,86 @@
+package app
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+	"github.com/aitmiloud/ngxtui/internal/ui"
+)
+
+// openTimeline shows the Logs tab timeline with the cursor on the newest bucket
+func openTimeline(m model.Model) (model.Model, tea.Cmd) {
+	if currentLogSource(m).Kind == nginx.ErrorLogKind {
+		m.StatusMsg = "The timeline charts access logs"
+		m.IsError = true
+		m.ShowStatus = true
+		return m, clearStatusAfter(2 * time.Second)
+	}
+	m.LogTimeline = true
+	m.TimelineCursor = 0
+	m.TimelineAnchor = -1
+	return m, nil
+}
+
+// handleTimelineKey moves the brush over the timeline's buckets. Enter
+// filters the logs to the brushed time range.
+func handleTimelineKey(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	oldest := max(len(ui.ShownTimeline(&m, m.Width))-1, 0)
+	switch {
+	case key.Matches(msg, model.Keys.Back), key.Matches(msg, model.Keys.Timeline):
+		m.LogTimeline = false
+	case key.Matches(msg, model.Keys.Left):
+		m.TimelineCursor = min(m.TimelineCursor+1, oldest)
+	case key.Matches(msg, model.Keys.Right):
+		m.TimelineCursor = max(m.TimelineCursor-1, 0)
+	case key.Matches(msg, model.Keys.Home):
+		m.TimelineCursor = oldest
+	case key.Matches(msg, model.Keys.End):
+		m.TimelineCursor = 0
+	case key.Matches(msg, model.Keys.Mark):
+		if m.TimelineAnchor >= 0 {
+			m.TimelineAnchor = -1
+		} else {
+			m.TimelineAnchor = m.TimelineCursor
+		}
+	case key.Matches(msg, model.Keys.Zoom):
+		m.TimelineWindow = (m.TimelineWindow + 1) % len(ui.TimelineWindows)
+		m.TimelineCursor = 0
+		m.TimelineAnchor = -1
+	case key.Matches(msg, model.Keys.Enter):
+		return brushLogs(m)
+	}
+	return m, nil
+}
+
+// brushLogs filters the Logs tab to the brushed buckets, replacing any time
+// terms of the current filter
+func brushLogs(m model.Model) (model.Model, tea.Cmd) {
+	buckets := ui.ShownTimeline(&m, m.Width)
+	if len(buckets) == 0 {
+		return m, nil
+	}
+	newest, oldest := m.TimelineCursor, m.TimelineCursor
+	if m.TimelineAnchor >= 0 {
+		newest, oldest = min(newest, m.TimelineAnchor), max(oldest, m.TimelineAnchor)
+	}
+	from := buckets[len(buckets)-1-min(oldest, len(buckets)-1)]
+	to := buckets[len(buckets)-1-min(newest, len(buckets)-1)]
+
+	var terms []string
+	if query, _ := m.LogQuery.(*nginx.LogQuery); !query.Empty() {
+		for _, term := range strings.Fields(query.Expr) {
+			if !strings.HasPrefix(term, "since:") && !strings.HasPrefix(term, "until:") {
+				terms = append(terms, term)
+			}
+		}
+	}
+	terms = append(terms, "since:"+from.Start.Format(time.RFC3339), "until:"+to.End.Format(time.RFC3339))
+
+	m.LogTimeline = false
+	return runLogQuery(m, strings.Join(terms, " "))
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+)
+
+// loadTLSReports grades the SSL sites in the background
+func loadTLSReports() tea.Cmd {
+	return func() tea.Msg {
+		nginxService := nginx.New()
+		reports, err := nginxService.GetTLSReports()
+		return model.TLSReportsMsg{
+			Reports: reports,
+			Err:     err,
+		}
+	}
+}
+
+// handleTLSTab handles key events in the TLS tab
+func handleTLSTab(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	reports, _ := m.TLSReports.([]nginx.TLSReport)
+
+	if key.Matches(msg, model.Keys.Up) {
+		if m.TLSCursor > 0 {
+			m.TLSCursor--
+		}
+	} else if key.Matches(msg, model.Keys.Down) {
+		if m.TLSCursor < len(reports)-1 {
+			m.TLSCursor++
+		}
+	}
+	return m, nil
+}
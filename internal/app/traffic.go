@@ -0,0 +1,77 @@
+package app
+
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+)
+
+// trafficRows returns the Traffic tab's rows in display order: the last
+// minute of tailed requests, or those read over the selected range
+func trafficRows(m model.Model) []nginx.TrafficRow {
+	dimension := nginx.TrafficDimensions[m.TrafficDimension]
+	order := nginx.TrafficSorts[m.TrafficSort]
+	if currentTimeRange(m).Live() {
+		live, _ := m.LiveTraffic.(*nginx.LiveTraffic)
+		var rows []nginx.TrafficRow
+		for _, row := range live.Top(dimension, order, 0, time.Now()) {
+			rows = append(rows, row.TrafficRow)
+		}
+		return rows
+	}
+	stats, _ := m.RangeStats.(*nginx.LogStats)
+	if stats == nil {
+		return nil
+	}
+	return stats.Top(dimension, order, 0)
+}
+
+// handleTrafficTab handles key events in the Traffic tab
+func handleTrafficTab(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, model.Keys.Up):
+		if m.TrafficCursor > 0 {
+			m.TrafficCursor--
+		}
+	case key.Matches(msg, model.Keys.Down):
+		if m.TrafficCursor < len(trafficRows(m))-1 {
+			m.TrafficCursor++
+		}
+	case key.Matches(msg, model.Keys.Breakdown):
+		m.TrafficDimension = (m.TrafficDimension + 1) % len(nginx.TrafficDimensions)
+		m.TrafficCursor = 0
+	case key.Matches(msg, model.Keys.Sort):
+		m.TrafficSort = (m.TrafficSort + 1) % len(nginx.TrafficSorts)
+		m.TrafficCursor = 0
+	case key.Matches(msg, model.Keys.Range):
+		return openLogRangePicker(m)
+	case key.Matches(msg, model.Keys.Enter):
+		return drillIntoTraffic(m)
+	}
+	return m, nil
+}
+
+// drillIntoTraffic shows the requests counted under the selected row in the
+// Logs tab, over the same time range
+func drillIntoTraffic(m model.Model) (model.Model, tea.Cmd) {
+	rows := trafficRows(m)
+	if m.TrafficCursor >= len(rows) {
+		return m, nil
+	}
+	dimension := nginx.TrafficDimensions[m.TrafficDimension]
+
+	m.ActiveTab = model.LogsTab
+	m.LogSource = 0 // Every access log, as the breakdown covers them all
+	m, cmd := runLogQuery(m, dimension.Query(rows[m.TrafficCursor].Key))
+	if m.LogQueryErr != nil {
+		m.StatusMsg = "Unable to filter on this row: " + m.LogQueryErr.Error()
+		m.IsError = true
+		m.ShowStatus = true
+		return m, tea.Batch(cmd, clearStatusAfter(2*time.Second))
+	}
+	return m, cmd
+}
@@ -1,7 +1,9 @@
 package app
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -20,6 +22,16 @@ func Update(m model.Model, msg tea.Msg) (model.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 
+	// Log tailing carries on whatever else is showing
+	switch msg.(type) {
+	case model.LogTailStartedMsg, model.LogBatchMsg:
+		return handleLogTailMsg(m, msg)
+	case model.LogSearchMsg:
+		return handleLogSearchMsg(m, msg.(model.LogSearchMsg))
+	case model.LogRangeStatsMsg:
+		return handleLogRangeStatsMsg(m, msg.(model.LogRangeStatsMsg))
+	}
+
 	// If form is showing, handle form input first (for all message types)
 	if m.ShowAddSiteForm {
 		return handleAddSiteForm(&m, msg)
@@ -29,16 +41,43 @@ func Update(m model.Model, msg tea.Msg) (model.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.Width = msg.Width
 		m.Height = msg.Height
-		m.Viewport.Width = msg.Width - 4
-		m.Viewport.Height = msg.Height - 10
 
 	case tea.KeyMsg:
+		// The filter bar takes every key while it has focus
+		if m.LogFiltering && msg.String() != "ctrl+c" {
+			return handleLogFilterKey(m, msg)
+		}
+		if m.LogJumping && msg.String() != "ctrl+c" {
+			return handleLogJumpKey(m, msg)
+		}
+
 		// Global keys
 		if key.Matches(msg, model.Keys.Quit) {
 			m.Quitting = true
+			stopLogTail(m)
 			return m, tea.Quit
 		}
 
+		// The log source picker takes navigation keys while open
+		if m.LogSourcePicking {
+			return handleLogSourceKey(m, msg)
+		}
+		if m.LogRangePicking {
+			return handleLogRangeKey(m, msg)
+		}
+		if m.ExportPicking {
+			return handleExportKey(m, msg)
+		}
+		if m.NotificationsOpen {
+			return handleNotificationsKey(m, msg)
+		}
+		if m.LogTimeline && m.ActiveTab == model.LogsTab && m.LogDetail == nil {
+			return handleTimelineKey(m, msg)
+		}
+		if key.Matches(msg, model.Keys.Alerts) && !m.MenuMode {
+			return openNotifications(m)
+		}
+
 		// Handle menu mode
 		if m.MenuMode {
 			return handleMenuMode(m, msg)
@@ -48,15 +87,38 @@ func Update(m model.Model, msg tea.Msg) (model.Model, tea.Cmd) {
 		if key.Matches(msg, model.Keys.Left) {
 			if m.ActiveTab > 0 {
 				m.ActiveTab--
+				cmd = onTabActivated(&m)
+				return m, cmd
 			}
 		} else if key.Matches(msg, model.Keys.Right) {
-			if m.ActiveTab < model.MetricsTab {
+			if m.ActiveTab < model.TabCount-1 {
 				m.ActiveTab++
+				cmd = onTabActivated(&m)
+				return m, cmd
 			}
 		}
 
 		// Handle refresh
 		if key.Matches(msg, model.Keys.Refresh) {
+			if m.ActiveTab == model.CertificatesTab {
+				return m, loadCertificates()
+			}
+			if m.ActiveTab == model.StatsTab {
+				// Collect now rather than waiting for the interval
+				m.StatsUpdated = time.Time{}
+				var rangeCmd tea.Cmd
+				m, rangeCmd = loadRangeStats(m)
+				return m, tea.Batch(scheduleCollectors(&m), refreshSites(&m), rangeCmd)
+			}
+			if m.ActiveTab == model.TrafficTab {
+				return loadRangeStats(m)
+			}
+			if m.ActiveTab == model.TLSTab {
+				// Clear the old grading so the view shows it is running again
+				m.TLSReports = nil
+				m.TLSReportsErr = nil
+				return m, loadTLSReports()
+			}
 			return m, refreshSites(&m)
 		}
 
@@ -65,45 +127,68 @@ func Update(m model.Model, msg tea.Msg) (model.Model, tea.Cmd) {
 		case model.SitesTab:
 			return handleSitesTab(m, msg)
 		case model.LogsTab:
-			m.Viewport, cmd = m.Viewport.Update(msg)
-			cmds = append(cmds, cmd)
+			if m.LogDetail != nil {
+				return handleLogDetailKey(m, msg)
+			}
+			if key.Matches(msg, model.Keys.Filter) {
+				return openLogFilter(m)
+			}
+			if key.Matches(msg, model.Keys.Source) {
+				return openLogSourcePicker(m)
+			}
+			if key.Matches(msg, model.Keys.Back) && m.LogQuery != nil {
+				return clearLogFilter(m)
+			}
+			if key.Matches(msg, model.Keys.Range) {
+				return openLogRangePicker(m)
+			}
+			if key.Matches(msg, model.Keys.Export) {
+				return openExportPicker(m)
+			}
+			if key.Matches(msg, model.Keys.Timeline) {
+				return openTimeline(m)
+			}
+			return handleLogsTab(m, msg)
+		case model.StatsTab:
+			if key.Matches(msg, model.Keys.Range) {
+				return openLogRangePicker(m)
+			}
+			if key.Matches(msg, model.Keys.Export) {
+				return openExportPicker(m)
+			}
+		case model.ErrorLogTab:
+			return handleErrorLogTab(m, msg)
+		case model.TrafficTab:
+			return handleTrafficTab(m, msg)
+		case model.BansTab:
+			return handleBansTab(m, msg)
+		case model.MetricsTab:
+			if key.Matches(msg, model.Keys.Status) {
+				return enableStubStatus(m)
+			}
+			if key.Matches(msg, model.Keys.Breakdown) {
+				m.MetricsBreakdown = !m.MetricsBreakdown
+				return m, nil
+			}
+		case model.CertificatesTab:
+			return handleCertificatesTab(m, msg)
+		case model.TLSTab:
+			return handleTLSTab(m, msg)
 		}
 
 	case model.TickMsg:
-		// Update REAL metrics when on Metrics tab
-		if m.ActiveTab == model.MetricsTab {
-			nginxService := nginx.New()
-			metrics, err := nginxService.GetMetrics()
-
-			if err == nil {
-				// Calculate network rate (MB/s) from change in total bytes
-				var networkRate float64
-				if m.LastNetworkIn > 0 && m.LastNetworkOut > 0 {
-					// Calculate change since last measurement
-					deltaIn := metrics.NetworkIn - m.LastNetworkIn
-					deltaOut := metrics.NetworkOut - m.LastNetworkOut
-					networkRate = (deltaIn + deltaOut) / 1024 // Convert to MB/s (measured per second)
-				}
-
-				// Store current values for next calculation
-				m.LastNetworkIn = metrics.NetworkIn
-				m.LastNetworkOut = metrics.NetworkOut
-
-				// Add new data point (will grow from 0 to 50 points)
-				if len(m.CPUHistory) < 50 {
-					// Still filling up - just append
-					m.CPUHistory = append(m.CPUHistory, metrics.CPU)
-					m.MemHistory = append(m.MemHistory, metrics.Memory)
-					m.NetHistory = append(m.NetHistory, networkRate)
-					m.RequestHistory = append(m.RequestHistory, metrics.RequestRate)
-				} else {
-					// Full - shift and add new data
-					m.CPUHistory = append(m.CPUHistory[1:], metrics.CPU)
-					m.MemHistory = append(m.MemHistory[1:], metrics.Memory)
-					m.NetHistory = append(m.NetHistory[1:], networkRate)
-					m.RequestHistory = append(m.RequestHistory[1:], metrics.RequestRate)
-				}
-			}
+		// Collectors run as commands so ticks never block on I/O
+		if cmd := scheduleCollectors(&m); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+		if m.ActiveTab == model.StatsTab {
+			refreshLatency(&m, time.Now())
+		}
+		if bansCmd := expireBans(&m, time.Now()); bansCmd != nil {
+			cmds = append(cmds, bansCmd)
+		}
+		if anomalyCmd := observeAnomalies(&m, nil, time.Now()); anomalyCmd != nil {
+			cmds = append(cmds, anomalyCmd)
 		}
 		m.LastUpdate = time.Now()
 		cmds = append(cmds, tea.Tick(time.Second, func(t time.Time) tea.Msg {
@@ -133,9 +218,49 @@ func Update(m model.Model, msg tea.Msg) (model.Model, tea.Cmd) {
 		// Show success message
 		m.StatusMsg = "Site created successfully: " + msg.SiteName
 		m.IsError = false
+		if msg.Detail != "" {
+			m.StatusMsg += " (" + msg.Detail + ")"
+		}
+		if msg.Warning != "" {
+			m.StatusMsg += " - " + msg.Warning
+			m.IsError = true
+		}
 		m.ShowStatus = true
 		cmds = append(cmds, clearStatusAfter(3*time.Second))
 
+	case model.MetricsMsg:
+		m = handleMetricsMsg(m, msg)
+
+	case model.StatsMsg:
+		m.StatsPending = false
+		m.StatsUpdated = time.Now()
+		m.Stats = msg.Stats
+		m.StatsErr = msg.Err
+		m.Health = msg.Health
+
+	case model.CertificatesMsg:
+		m.Certificates = msg.Certificates
+		m.CertificatesErr = msg.Err
+		if certs, ok := msg.Certificates.([]nginx.Certificate); ok && m.CertCursor >= len(certs) {
+			m.CertCursor = 0
+		}
+
+	case model.BansAppliedMsg:
+		return handleBansAppliedMsg(m, msg)
+
+	case model.ExportedMsg:
+		return handleExportedMsg(m, msg)
+
+	case model.StubStatusEnabledMsg:
+		return handleStubStatusEnabledMsg(m, msg)
+
+	case model.TLSReportsMsg:
+		m.TLSReports = msg.Reports
+		m.TLSReportsErr = msg.Err
+		if reports, ok := msg.Reports.([]nginx.TLSReport); ok && m.TLSCursor >= len(reports) {
+			m.TLSCursor = 0
+		}
+
 	case spinner.TickMsg:
 		m.Spinner, cmd = m.Spinner.Update(msg)
 		cmds = append(cmds, cmd)
@@ -184,6 +309,29 @@ func handleSitesTab(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
 	return m, nil
 }
 
+// onTabActivated returns the command that loads data for a newly selected tab
+func onTabActivated(m *model.Model) tea.Cmd {
+	switch m.ActiveTab {
+	case model.MetricsTab, model.StatsTab:
+		return scheduleCollectors(m)
+	case model.TrafficTab:
+		if m.RangeStats == nil && !m.RangeStatsPending && !currentTimeRange(*m).Live() {
+			var cmd tea.Cmd
+			*m, cmd = loadRangeStats(*m)
+			return cmd
+		}
+	case model.CertificatesTab:
+		if m.Certificates == nil && m.CertificatesErr == nil {
+			return loadCertificates()
+		}
+	case model.TLSTab:
+		if m.TLSReports == nil && m.TLSReportsErr == nil {
+			return loadTLSReports()
+		}
+	}
+	return nil
+}
+
 // handleMenuMode handles key events in menu mode
 func handleMenuMode(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
 	if key.Matches(msg, model.Keys.Back) {
@@ -197,10 +345,17 @@ func handleMenuMode(m model.Model, msg tea.KeyMsg) (model.Model, tea.Cmd) {
 			m.Cursor--
 		}
 	} else if key.Matches(msg, model.Keys.Down) {
-		if m.Cursor < 5 { // 6 menu items (0-5)
+		if m.Cursor < ui.SiteActionCount-1 {
 			m.Cursor++
 		}
 	} else if key.Matches(msg, model.Keys.Enter) {
+		if m.Cursor == 4 && m.Selected >= 0 && m.Selected < len(m.Sites) { // View Logs
+			site := m.Sites[m.Selected].Name
+			m.ActiveTab = model.LogsTab
+			m.MenuMode = false
+			m.Selected = -1
+			return m, openSiteLogs(m.Config, m.LogTailer, site)
+		}
 		return m, executeAction(&m)
 	}
 
@@ -258,16 +413,17 @@ func executeAction(m *model.Model) tea.Cmd {
 		case 3: // Reload NGINX
 			err = nginxService.Reload()
 			message = "NGINX reloaded successfully"
-		case 4: // View Logs
-			// Switch to logs tab
-			m.ActiveTab = model.LogsTab
-			m.MenuMode = false
-			m.Selected = -1
-			return model.StatusMsg{
-				Message: "Switched to logs view",
-				IsError: false,
+		case 5: // Issue Certificate
+			issuer := nginx.NewACMEIssuer(nginxService, m.Config.ACME)
+			ctx, cancel := context.WithTimeout(context.Background(), acmeTimeout)
+			defer cancel()
+			var managed *nginx.ManagedCertificate
+			managed, err = issuer.IssueForSite(ctx, site.Name)
+			if err == nil {
+				message = fmt.Sprintf("Certificate issued for %s (expires %s)",
+					strings.Join(managed.Domains, ", "), managed.NotAfter.Format("2006-01-02"))
 			}
-		case 5: // Back
+		case 6: // Back
 			m.MenuMode = false
 			m.Selected = -1
 			return model.StatusMsg{
@@ -374,16 +530,51 @@ func createSiteFromForm(m *model.Model) tea.Cmd {
 			}
 		}
 
-		// Generate NGINX configuration
-		nginxConfig := config.GenerateNginxConfig()
 		filename := config.GetFileName() + ".conf"
+		nginxService := nginx.New()
 
-		// Debug: Show what we're creating
-		_ = nginxConfig // Keep for debugging
-		_ = filename    // Keep for debugging
+		// Generated certificates are written first and their paths go
+		// straight into the site config so the config test passes
+		var detail string
+		if config.GeneratesCertificate() {
+			generator := nginx.NewCertificateGenerator(m.Config.LocalCerts)
+			var certPath, keyPath string
+			var err error
+			if config.CertSource == forms.CertSourceLocalCA {
+				certPath, keyPath, err = generator.SignWithCA(config.GetFileName(), config.Domains())
+				detail = "certificate signed by local CA " + generator.CACertPath()
+			} else {
+				certPath, keyPath, err = generator.SelfSigned(config.GetFileName(), config.Domains())
+				detail = "self-signed certificate " + certPath
+			}
+			if err != nil {
+				return model.StatusMsg{
+					Message: "Failed to generate certificate: " + err.Error(),
+					IsError: true,
+				}
+			}
+			config.SSLCertPath = certPath
+			config.SSLKeyPath = keyPath
+		}
 
-		// Create the site
-		nginxService := nginx.New()
+		// With ACME the certificate doesn't exist yet; install the challenge
+		// snippet and a placeholder so the config test passes
+		var issuer *nginx.ACMEIssuer
+		if config.UsesACME() {
+			issuer = nginx.NewACMEIssuer(nginxService, m.Config.ACME)
+			if err := issuer.EnsureChallengeSnippet(); err != nil {
+				return model.StatusMsg{Message: err.Error(), IsError: true}
+			}
+			if err := nginx.EnsurePlaceholderCertificate(config.SSLCertPath, config.SSLKeyPath, config.Domains()); err != nil {
+				return model.StatusMsg{
+					Message: "Failed to create placeholder certificate: " + err.Error(),
+					IsError: true,
+				}
+			}
+		}
+
+		// Generate NGINX configuration and create the site
+		nginxConfig := config.GenerateNginxConfig()
 		if err := nginxService.CreateSiteConfig(filename, nginxConfig); err != nil {
 			return model.StatusMsg{
 				Message: "Failed to create site '" + filename + "': " + err.Error(),
@@ -391,6 +582,16 @@ func createSiteFromForm(m *model.Model) tea.Cmd {
 			}
 		}
 
+		// Replace the placeholder with a real certificate
+		var warning string
+		if issuer != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), acmeTimeout)
+			defer cancel()
+			if _, err := issuer.IssueForSite(ctx, filename); err != nil {
+				warning = "site is using a placeholder certificate; ACME issuance failed: " + err.Error()
+			}
+		}
+
 		// Refresh sites list
 		sites, err := nginxService.ListSites()
 		if err != nil {
@@ -404,6 +605,8 @@ func createSiteFromForm(m *model.Model) tea.Cmd {
 		return model.SiteCreatedMsg{
 			SiteName: config.ServerName,
 			Sites:    sites,
+			Detail:   detail,
+			Warning:  warning,
 		}
 	}
 }
@@ -91,18 +91,30 @@ func View(m model.Model) string {
 
 	// Content based on active tab (fills remaining space)
 	var content string
-	if m.MenuMode {
+	if m.NotificationsOpen {
+		content = renderer.RenderNotifications(&m, width, contentHeight)
+	} else if m.MenuMode {
 		content = renderer.RenderSitesWithMenu(&m, width, contentHeight)
 	} else {
 		switch m.ActiveTab {
 		case model.SitesTab:
 			content = renderer.RenderSitesTable(&m, width, contentHeight)
 		case model.LogsTab:
-			content = renderer.RenderLogsView(&m)
+			content = renderer.RenderLogsView(&m, width, contentHeight)
+		case model.ErrorLogTab:
+			content = renderer.RenderErrorLogView(&m, width, contentHeight)
 		case model.StatsTab:
 			content = renderer.RenderStatsView(&m, width)
+		case model.TrafficTab:
+			content = renderer.RenderTrafficView(&m, width, contentHeight)
+		case model.BansTab:
+			content = renderer.RenderBansView(&m, width, contentHeight)
 		case model.MetricsTab:
 			content = renderer.RenderMetricsView(&m, width, contentHeight)
+		case model.CertificatesTab:
+			content = renderer.RenderCertificatesView(&m, width, contentHeight)
+		case model.TLSTab:
+			content = renderer.RenderTLSView(&m, width, contentHeight)
 		}
 	}
 
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// DefaultPath is where NgxTUI looks for its configuration file
+const DefaultPath = "/etc/ngxtui/config.json"
+
+// Config holds user-tunable NgxTUI settings
+type Config struct {
+	// CertExpiryWarnDays highlights certificates expiring within this many days
+	CertExpiryWarnDays int `json:"cert_expiry_warn_days"`
+
+	// ACME holds the certificate issuance settings
+	ACME ACMEConfig `json:"acme"`
+
+	// LocalCerts holds the self-signed and local CA settings
+	LocalCerts LocalCertConfig `json:"local_certs"`
+
+	// Logs holds the access log parsing settings
+	Logs LogConfig `json:"logs"`
+
+	// GeoIP holds the offline country and ASN databases
+	GeoIP GeoIPConfig `json:"geoip"`
+
+	// Bans holds the automatic IP banning rules
+	Bans BanConfig `json:"bans"`
+
+	// Metrics holds where connection and traffic metrics are read from
+	Metrics MetricsConfig `json:"metrics"`
+}
+
+// MetricsConfig configures the NGINX status pages polled for metrics
+type MetricsConfig struct {
+	// StubStatusURL is the stub_status page to poll, e.g.
+	// "http://127.0.0.1:8089/nginx_status"; found in the configuration when empty
+	StubStatusURL string `json:"stub_status_url"`
+	// StubStatusInclude is the managed file the Metrics tab writes a
+	// stub_status server to, included in the http block
+	StubStatusInclude string `json:"stub_status_include"`
+	// StubStatusListen is the address that server listens on
+	StubStatusListen string `json:"stub_status_listen"`
+	// VTSURL is the JSON status of nginx-module-vts to poll, e.g.
+	// "http://127.0.0.1:8089/status/format/json"; found in the configuration when empty
+	VTSURL string `json:"vts_url"`
+	// PlusAPIURL is the NGINX Plus API to poll, e.g. "http://127.0.0.1:8080/api";
+	// found in the configuration when empty
+	PlusAPIURL string `json:"plus_api_url"`
+}
+
+// BanConfig configures fail2ban-style blocking of clients that trip a rule.
+// Banned addresses are written as deny rules to a managed include file.
+type BanConfig struct {
+	// Enabled turns on automatic banning; active bans can be lifted either way
+	Enabled bool `json:"enabled"`
+	// IncludePath is the managed file of deny rules, included in the http block
+	IncludePath string `json:"include_path"`
+	// StateFile keeps the active bans across restarts
+	StateFile string `json:"state_file"`
+	// BanMinutes is how long a ban lasts unless its rule says otherwise
+	BanMinutes int `json:"ban_minutes"`
+	// Ignore lists addresses and networks that are never banned
+	Ignore []string `json:"ignore"`
+	// Rules are checked against every tailed log entry
+	Rules []BanRule `json:"rules"`
+}
+
+// BanRule bans a client with more than MaxHits matching entries within WindowSeconds
+type BanRule struct {
+	Name string `json:"name"`
+	// Log is "access" (the default) or "error"
+	Log string `json:"log"`
+	// Filter selects the entries that count: a log filter query for access
+	// logs (e.g. "status:4xx"), a regular expression on the message for error logs
+	Filter        string `json:"filter"`
+	MaxHits       int    `json:"max_hits"`
+	WindowSeconds int    `json:"window_seconds"`
+	// BanMinutes overrides the default ban length for this rule
+	BanMinutes int `json:"ban_minutes"`
+}
+
+// GeoIPConfig points at MaxMind-format (.mmdb) databases, such as GeoLite2
+// or DB-IP, used to annotate client IPs. Either may be left empty.
+type GeoIPConfig struct {
+	// CountryDB is a Country or City database
+	CountryDB string `json:"country_db"`
+	// ASNDB is an ASN database
+	ASNDB string `json:"asn_db"`
+}
+
+// LogConfig configures access log parsing
+type LogConfig struct {
+	// JSONFields maps keys of JSON log lines to the NGINX variable they hold,
+	// e.g. {"ip": "remote_addr"}. Nested keys are dotted ("request.method").
+	// Entries override the mapping inferred from a JSON log_format.
+	JSONFields map[string]string `json:"json_fields"`
+	// BotSignatures is a file of "<kind> <user agent substring>" lines checked
+	// before the bundled crawler list, e.g. for bots it doesn't know yet
+	BotSignatures string `json:"bot_signatures"`
+	// ExportDir is where the Logs and Stats tabs export to; the working
+	// directory when empty
+	ExportDir string `json:"export_dir"`
+}
+
+// LocalCertConfig configures self-signed and local CA certificate generation
+type LocalCertConfig struct {
+	// CertsDir is where generated certificates are written, one directory per site
+	CertsDir string `json:"certs_dir"`
+	// CADir holds the local CA certificate and key
+	CADir string `json:"ca_dir"`
+	// ValidDays is the lifetime of generated certificates
+	ValidDays int `json:"valid_days"`
+}
+
+// ACMEConfig configures the built-in ACME client
+type ACMEConfig struct {
+	// DirectoryURL is the ACME directory, e.g. Let's Encrypt or a local Pebble
+	DirectoryURL string `json:"directory_url"`
+	// Email is registered as the account contact
+	Email string `json:"email"`
+	// CAFile is an extra CA bundle trusted for the directory's HTTPS endpoint (Pebble's minica)
+	CAFile string `json:"ca_file"`
+	// StateDir holds the account key, challenge files and managed certificate list
+	StateDir string `json:"state_dir"`
+	// RenewBeforeDays renews managed certificates expiring within this many days
+	RenewBeforeDays int `json:"renew_before_days"`
+}
+
+// Default returns the built-in configuration
+func Default() *Config {
+	return &Config{
+		CertExpiryWarnDays: 30,
+		ACME: ACMEConfig{
+			DirectoryURL:    "https://acme-v02.api.letsencrypt.org/directory",
+			StateDir:        "/var/lib/ngxtui/acme",
+			RenewBeforeDays: 30,
+		},
+		LocalCerts: LocalCertConfig{
+			CertsDir:  "/etc/ngxtui/certs",
+			CADir:     "/etc/ngxtui/ca",
+			ValidDays: 365,
+		},
+		Bans: BanConfig{
+			IncludePath: "/etc/nginx/conf.d/ngxtui-bans.conf",
+			StateFile:   "/var/lib/ngxtui/bans.json",
+			BanMinutes:  60,
+			Ignore:      []string{"127.0.0.0/8", "::1/128"},
+		},
+		Metrics: MetricsConfig{
+			StubStatusInclude: "/etc/nginx/conf.d/ngxtui-status.conf",
+			StubStatusListen:  "127.0.0.1:8089",
+		},
+	}
+}
+
+// Load reads the configuration file, falling back to defaults when it is missing.
+// The NGXTUI_CONFIG environment variable overrides the default location.
+func Load() (*Config, error) {
+	path := DefaultPath
+	if env := os.Getenv("NGXTUI_CONFIG"); env != "" {
+		path = env
+	}
+
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	// Unmarshal over the defaults so omitted keys keep their default values
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return Default(), fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
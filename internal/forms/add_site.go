@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/huh"
+
+	"github.com/aitmiloud/ngxtui/internal/nginx"
 )
 
 // SiteConfig holds all configuration for a new NGINX site
@@ -23,6 +25,8 @@ type SiteConfig struct {
 	SSLCertPath  string
 	SSLKeyPath   string
 	ForceHTTPS   bool
+	EnableHSTS   bool   // Send Strict-Transport-Security; opt-in as browsers remember it
+	CertSource   string // One of the CertSource* constants
 	
 	// Proxy Configuration
 	IsProxy      bool
@@ -46,6 +50,24 @@ type SiteConfig struct {
 	Confirmed bool
 }
 
+// Certificate sources for SSL sites
+const (
+	CertSourceFiles      = "files"       // Existing files at SSLCertPath/SSLKeyPath
+	CertSourceACME       = "acme"        // Issued over ACME after the site is created
+	CertSourceSelfSigned = "self-signed" // Generated self-signed certificate
+	CertSourceLocalCA    = "local-ca"    // Generated and signed by the local NgxTUI CA
+)
+
+// UsesACME reports whether the certificate is obtained over ACME
+func (c *SiteConfig) UsesACME() bool {
+	return c.EnableSSL && c.CertSource == CertSourceACME
+}
+
+// GeneratesCertificate reports whether NgxTUI generates the certificate locally
+func (c *SiteConfig) GeneratesCertificate() bool {
+	return c.EnableSSL && (c.CertSource == CertSourceSelfSigned || c.CertSource == CertSourceLocalCA)
+}
+
 // NewAddSiteForm creates a new form for adding an NGINX site
 func NewAddSiteForm() (*huh.Form, *SiteConfig) {
 	config := &SiteConfig{
@@ -56,6 +78,7 @@ func NewAddSiteForm() (*huh.Form, *SiteConfig) {
 		AccessLog:         "/var/log/nginx/access.log",
 		ErrorLog:          "/var/log/nginx/error.log",
 		PHPSocket:         "/var/run/php/php-fpm.sock",
+		CertSource:        CertSourceFiles,
 		ProxyHeaders:      true,
 		EnableGzip:        true,
 	}
@@ -93,6 +116,7 @@ func NewAddSiteForm() (*huh.Form, *SiteConfig) {
 						config.SSLCertPath = template.Config.SSLCertPath
 						config.SSLKeyPath = template.Config.SSLKeyPath
 						config.ForceHTTPS = template.Config.ForceHTTPS
+						config.EnableHSTS = template.Config.EnableHSTS
 						config.IsProxy = template.Config.IsProxy
 						config.ProxyPass = template.Config.ProxyPass
 						config.ProxyHeaders = template.Config.ProxyHeaders
@@ -154,6 +178,30 @@ func NewAddSiteForm() (*huh.Form, *SiteConfig) {
 				Description("Enable HTTPS for this site").
 				Value(&config.EnableSSL),
 
+			huh.NewSelect[string]().
+				Title("Certificate Source").
+				Description("Where the site's certificate comes from").
+				Options(
+					huh.NewOption("Use existing certificate files", CertSourceFiles),
+					huh.NewOption("Obtain via ACME (HTTP-01)", CertSourceACME),
+					huh.NewOption("Generate self-signed certificate", CertSourceSelfSigned),
+					huh.NewOption("Sign with local NgxTUI CA", CertSourceLocalCA),
+				).
+				Value(&config.CertSource),
+
+			huh.NewConfirm().
+				Title("Force HTTPS Redirect").
+				Description("Redirect HTTP to HTTPS automatically").
+				Value(&config.ForceHTTPS),
+
+			huh.NewConfirm().
+				Title("Send HSTS Header").
+				Description("Browsers will refuse plain HTTP for this name for two years").
+				Value(&config.EnableHSTS),
+		).Title("SSL/TLS Configuration").Description("Configure HTTPS settings"),
+
+		// Page 2b: Certificate files (generated certificates set these automatically)
+		huh.NewGroup(
 			huh.NewInput().
 				Title("SSL Certificate Path").
 				Description("Path to SSL certificate file").
@@ -177,12 +225,10 @@ func NewAddSiteForm() (*huh.Form, *SiteConfig) {
 					}
 					return nil
 				}),
-
-			huh.NewConfirm().
-				Title("Force HTTPS Redirect").
-				Description("Redirect HTTP to HTTPS automatically").
-				Value(&config.ForceHTTPS),
-		).Title("SSL/TLS Configuration").Description("Configure HTTPS settings"),
+		).Title("Certificate Files").Description("Certificate and key locations").
+			WithHideFunc(func() bool {
+				return !config.EnableSSL || config.GeneratesCertificate()
+			}),
 
 		// Page 3: Proxy Configuration
 		huh.NewGroup(
@@ -288,7 +334,22 @@ func (c *SiteConfig) GenerateNginxConfig() string {
 
 	// HTTP to HTTPS redirect server block (if SSL and force HTTPS)
 	if c.EnableSSL && c.ForceHTTPS {
-		sb.WriteString(fmt.Sprintf(`# HTTP to HTTPS redirect
+		if c.UsesACME() {
+			// The redirect lives in a location so ACME challenges are still served
+			sb.WriteString(fmt.Sprintf(`# HTTP to HTTPS redirect
+server {
+    listen 80;
+    server_name %s;
+    include %s;
+
+    location / {
+        return 301 https://$server_name$request_uri;
+    }
+}
+
+`, c.ServerName, nginx.ACMESnippetPath))
+		} else {
+			sb.WriteString(fmt.Sprintf(`# HTTP to HTTPS redirect
 server {
     listen 80;
     server_name %s;
@@ -296,6 +357,7 @@ server {
 }
 
 `, c.ServerName))
+		}
 	}
 
 	// Main server block
@@ -305,6 +367,11 @@ server {
 	if c.EnableSSL {
 		sb.WriteString(fmt.Sprintf("    listen 443 ssl http2;\n"))
 		sb.WriteString(fmt.Sprintf("    listen [::]:443 ssl http2;\n"))
+		if c.UsesACME() && !c.ForceHTTPS {
+			// HTTP-01 validation needs the site reachable on port 80
+			sb.WriteString("    listen 80;\n")
+			sb.WriteString("    listen [::]:80;\n")
+		}
 	} else {
 		sb.WriteString(fmt.Sprintf("    listen %s;\n", c.Port))
 		sb.WriteString(fmt.Sprintf("    listen [::]:%s;\n", c.Port))
@@ -318,9 +385,24 @@ server {
 		sb.WriteString(fmt.Sprintf("    # SSL Configuration\n"))
 		sb.WriteString(fmt.Sprintf("    ssl_certificate %s;\n", c.SSLCertPath))
 		sb.WriteString(fmt.Sprintf("    ssl_certificate_key %s;\n", c.SSLKeyPath))
-		sb.WriteString("    ssl_protocols TLSv1.2 TLSv1.3;\n")
-		sb.WriteString("    ssl_ciphers HIGH:!aNULL:!MD5;\n")
-		sb.WriteString("    ssl_prefer_server_ciphers on;\n\n")
+		sb.WriteString(fmt.Sprintf("    ssl_protocols %s;\n", strings.Join(nginx.MozillaIntermediate.Protocols, " ")))
+		sb.WriteString(fmt.Sprintf("    ssl_ciphers %s;\n", nginx.MozillaIntermediate.CipherString()))
+		sb.WriteString("    ssl_prefer_server_ciphers off;\n")
+		sb.WriteString("    ssl_session_timeout 1d;\n")
+		sb.WriteString("    ssl_session_tickets off;\n")
+		// Only certificates naming an OCSP responder have anything to staple;
+		// generated certificates and the ACME placeholder name none
+		if nginx.HasOCSPResponder(c.SSLCertPath) {
+			sb.WriteString("    ssl_stapling on;\n")
+			sb.WriteString("    ssl_stapling_verify on;\n")
+		}
+		if c.EnableHSTS {
+			sb.WriteString(fmt.Sprintf("    add_header Strict-Transport-Security \"max-age=%d\" always;\n", nginx.MozillaIntermediate.HSTSMaxAge))
+		}
+		sb.WriteString("\n")
+		if c.UsesACME() && !c.ForceHTTPS {
+			sb.WriteString(fmt.Sprintf("    include %s;\n\n", nginx.ACMESnippetPath))
+		}
 	}
 
 	// Logging
@@ -404,6 +486,11 @@ server {
 	return sb.String()
 }
 
+// Domains returns the server names as a list
+func (c *SiteConfig) Domains() []string {
+	return strings.Fields(c.ServerName)
+}
+
 // GetFileName returns the suggested filename for the site configuration
 func (c *SiteConfig) GetFileName() string {
 	// Remove protocol and special characters from server name
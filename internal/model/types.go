@@ -7,7 +7,9 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
-	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/bubbles/textinput"
+
+	"github.com/aitmiloud/ngxtui/internal/config"
 )
 
 // TabType represents the different tabs in the application
@@ -16,8 +18,16 @@ type TabType int
 const (
 	SitesTab TabType = iota
 	LogsTab
+	ErrorLogTab
 	StatsTab
+	TrafficTab
+	BansTab
 	MetricsTab
+	CertificatesTab
+	TLSTab
+
+	// TabCount is the number of tabs; keep it last
+	TabCount
 )
 
 // Site represents an NGINX site configuration
@@ -45,6 +55,79 @@ type ClearStatusMsg struct{}
 type SiteCreatedMsg struct {
 	SiteName string
 	Sites    []Site
+	Detail   string // Extra information about the created site
+	Warning  string // Set when the site was created but a follow-up step failed
+}
+
+// CertificatesMsg is sent when the certificate inventory has been loaded
+type CertificatesMsg struct {
+	Certificates interface{} // Will store []nginx.Certificate
+	Err          error
+}
+
+// TLSReportsMsg is sent when the TLS grading of SSL sites has finished
+type TLSReportsMsg struct {
+	Reports interface{} // Will store []nginx.TLSReport
+	Err     error
+}
+
+// BansAppliedMsg is sent once the active bans were written out and NGINX reloaded
+type BansAppliedMsg struct {
+	Bans interface{} // Will store the []nginx.Ban written out
+	Err  error
+}
+
+// ExportedMsg is sent once a log view or statistics export was written
+type ExportedMsg struct {
+	Path string
+	Err  error
+}
+
+// StubStatusEnabledMsg is sent once a stub_status server was set up
+type StubStatusEnabledMsg struct {
+	URL string
+	Err error
+}
+
+// MetricsMsg carries a metrics sample collected in the background
+type MetricsMsg struct {
+	Metrics interface{} // Will store *nginx.Metrics
+	Err     error
+}
+
+// StatsMsg carries the Stats tab data collected in the background
+type StatsMsg struct {
+	Stats  interface{} // Will store *nginx.Stats
+	Err    error
+	Health interface{} // Will store *nginx.Health
+}
+
+// LogTailStartedMsg is sent once the log tailer is running, or when the logs
+// were resolved again and the running tailer still covers them
+type LogTailStartedMsg struct {
+	Tailer  interface{} // Will store *nginx.LogTailer
+	Sources interface{} // Will store []nginx.LogSource
+	Site    string      // Site whose logs to select, if any
+}
+
+// LogBatchMsg carries entries the tailer read from a log
+type LogBatchMsg struct {
+	Tailer interface{} // Will store the *nginx.LogTailer that read the batch
+	Batch  interface{} // Will store nginx.LogBatch
+}
+
+// LogSearchMsg carries the matches of a filter query over the access log files
+type LogSearchMsg struct {
+	ID      int         // Matches Model.LogSearchID when the result is current
+	Entries interface{} // Will store []nginx.LogEntry, oldest first
+	Err     error
+}
+
+// LogRangeStatsMsg carries access log statistics over a historical time range
+type LogRangeStatsMsg struct {
+	ID    int         // Matches Model.RangeStatsID when the result is current
+	Stats interface{} // Will store *nginx.LogStats
+	Err   error
 }
 
 // Model represents the application state
@@ -64,7 +147,6 @@ type Model struct {
 	Loading        bool
 	Width          int
 	Height         int
-	Viewport       viewport.Model
 	CPUHistory     []float64
 	MemHistory     []float64
 	NetHistory     []float64
@@ -79,20 +161,163 @@ type Model struct {
 	ShowAddSiteForm bool
 	AddSiteForm     interface{} // Will store *huh.Form
 	AddSiteConfig   interface{} // Will store *forms.SiteConfig
+
+	// User configuration
+	Config *config.Config
+
+	// Certificates tab state
+	Certificates    interface{} // Will store []nginx.Certificate
+	CertificatesErr error
+	CertCursor      int
+
+	// Access log state, fed by the background tailer
+	LogTailer  interface{} // Will store *nginx.LogTailer
+	LogEntries interface{} // Will store []nginx.LogEntry, oldest first
+	LogStats   interface{} // Will store *nginx.LogStats over LogEntries
+	LogErr     error
+
+	// Logs tab cursor and request detail pane
+	LogCursor int         // Entries between the selected one and the newest shown
+	LogDetail interface{} // Will store *nginx.LogEntry shown in the detail pane, nil when closed
+
+	// Logs tab scrolling. Following keeps the cursor on the newest entry;
+	// otherwise it stays on the entry it selected as new ones arrive. Pausing
+	// freezes the shown entries while the tailer keeps reading.
+	LogFollow    bool
+	LogPaused    bool
+	LogFrozen    interface{} // Will store []nginx.LogEntry shown when the view was paused
+	LogPausedNew int         // Entries that arrived while paused
+	LogJumping   bool        // The jump-to-time input has focus
+	LogJumpInput textinput.Model
+
+	// Logs tab timeline of requests by status class. While shown it takes
+	// the arrow keys to brush a range of buckets; offsets count from the newest.
+	LogTimeline    bool
+	TimelineWindow int // Index into ui.TimelineWindows
+	TimelineCursor int
+	TimelineAnchor int // Where the brush started, -1 when nothing is marked
+
+	// Log source selection; sources are host-wide or per site
+	LogSources       interface{} // Will store []nginx.LogSource
+	LogSource        int         // Index of the shown source
+	LogSourcePicking bool        // The source picker is open
+	LogSourceCursor  int
+	ErrorLogEntries  interface{} // Will store []nginx.ErrorLogEntry, oldest first
+
+	// Error Log tab state
+	ErrorLogCursor  int
+	ErrorLogLevel   int  // Lowest nginx.ErrorLevel shown
+	ErrorLogGrouped bool // Repeated messages are grouped
+
+	// Access log filter state
+	LogFilterInput   textinput.Model
+	LogFiltering     bool        // The filter bar has focus
+	LogQuery         interface{} // Will store *nginx.LogQuery
+	LogQueryErr      error
+	LogMatches       interface{} // Will store []nginx.LogEntry matching LogQuery, oldest first
+	LogSearchID      int
+	LogSearchPending bool
+	LogSearchErr     error
+
+	// Time range shared by the Logs and Stats tabs
+	LogRange          int // Index into nginx.TimeRanges; 0 is the live window
+	LogRangePicking   bool
+	LogRangeCursor    int
+	RangeStats        interface{} // Will store *nginx.LogStats over LogRange
+	RangeStatsErr     error
+	RangeStatsID      int
+	RangeStatsPending bool
+
+	// Live latency over nginx.LatencyWindows, and the Metrics tab's latency
+	// chart of the shortest window in milliseconds
+	Latency           interface{} // Will store []*nginx.LatencyReport
+	LiveLatency       interface{} // Will store *nginx.LiveLatency
+	LatencyP50History []float64
+	LatencyP90History []float64
+	LatencyP99History []float64
+
+	// MetricsBreakdown shows the Metrics tab's server zones, upstreams and
+	// caches instead of its charts
+	MetricsBreakdown bool
+
+	// Traffic tab state; the breakdown is over the shared time range, and
+	// the live range counts tailed requests per second
+	TrafficDimension int // Index into nginx.TrafficDimensions
+	TrafficSort      int // Index into nginx.TrafficSorts
+	TrafficCursor    int
+	LiveTraffic      interface{} // Will store *nginx.LiveTraffic
+
+	// Collector state; View renders from these and never does I/O itself
+	MetricsPending bool
+	LastMetrics    interface{} // Will store *nginx.Metrics of the last sample
+	PrevMetrics    interface{} // Will store *nginx.Metrics of the sample before it
+	StubStatusBusy bool        // A stub_status server is being set up
+	Stats          interface{} // Will store *nginx.Stats
+	StatsErr       error
+	Health         interface{} // Will store *nginx.Health
+	StatsPending   bool
+	StatsUpdated   time.Time
+
+	// TLS tab state
+	TLSReports    interface{} // Will store []nginx.TLSReport
+	TLSReportsErr error
+	TLSCursor     int
+
+	// Automatic IP bans; changes made while the bans are being applied are
+	// applied again once that finishes
+	Banner       interface{} // Will store *nginx.Banner
+	BanCursor    int
+	BansApplying bool
+	BansPending  bool
+	BansErr      error // From the last time the bans were applied
+
+	// Export format picker of the Logs and Stats tabs
+	ExportPicking bool
+	ExportCursor  int // Index into nginx.ExportFormats
+
+	// Anomalies flagged against per-site baselines of the tailed logs, and
+	// the notifications panel listing them
+	Anomalies           interface{} // Will store *nginx.AnomalyDetector
+	NotificationsOpen   bool
+	NotificationCursor  int
+	NotificationsUnread int // Anomalies flagged since the panel was last open
 }
 
 // KeyMap defines the keybindings for the application
 type KeyMap struct {
-	Up      key.Binding
-	Down    key.Binding
-	Left    key.Binding
-	Right   key.Binding
-	Enter   key.Binding
-	Back    key.Binding
-	Quit    key.Binding
-	Tab     key.Binding
-	Refresh key.Binding
-	AddSite key.Binding
+	Up        key.Binding
+	Down      key.Binding
+	Left      key.Binding
+	Right     key.Binding
+	Enter     key.Binding
+	Back      key.Binding
+	Quit      key.Binding
+	Tab       key.Binding
+	Refresh   key.Binding
+	AddSite   key.Binding
+	Renew     key.Binding
+	Filter    key.Binding
+	Source    key.Binding
+	Level     key.Binding
+	Group     key.Binding
+	Range     key.Binding
+	Breakdown key.Binding
+	Sort      key.Binding
+	Unban     key.Binding
+	Export    key.Binding
+	Copy      key.Binding
+	Pause     key.Binding
+	Follow    key.Binding
+	PageUp    key.Binding
+	PageDown  key.Binding
+	Home      key.Binding
+	End       key.Binding
+	Jump      key.Binding
+	Timeline  key.Binding
+	Mark      key.Binding
+	Zoom      key.Binding
+	Alerts    key.Binding
+	Status    key.Binding
 }
 
 // Keys is the default keymap
@@ -137,6 +362,98 @@ var Keys = KeyMap{
 		key.WithKeys("a"),
 		key.WithHelp("a", "add site"),
 	),
+	Renew: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "renew certificates"),
+	),
+	Filter: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "filter logs"),
+	),
+	Source: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "select logs"),
+	),
+	Level: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "filter level"),
+	),
+	Group: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "group repeats"),
+	),
+	Range: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "time range"),
+	),
+	Breakdown: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "breakdown"),
+	),
+	Sort: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "sort"),
+	),
+	Unban: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "unban"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "export"),
+	),
+	Copy: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy"),
+	),
+	Pause: key.NewBinding(
+		key.WithKeys("p", " "),
+		key.WithHelp("p", "pause"),
+	),
+	Follow: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "follow"),
+	),
+	PageUp: key.NewBinding(
+		key.WithKeys("pgup"),
+		key.WithHelp("pgup", "page up"),
+	),
+	PageDown: key.NewBinding(
+		key.WithKeys("pgdown"),
+		key.WithHelp("pgdn", "page down"),
+	),
+	Home: key.NewBinding(
+		key.WithKeys("home"),
+		key.WithHelp("home", "oldest"),
+	),
+	End: key.NewBinding(
+		key.WithKeys("end"),
+		key.WithHelp("end", "newest"),
+	),
+	Jump: key.NewBinding(
+		key.WithKeys(":"),
+		key.WithHelp(":", "jump to time"),
+	),
+	Timeline: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "timeline"),
+	),
+	Mark: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "mark"),
+	),
+	Zoom: key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "window"),
+	),
+	Alerts: key.NewBinding(
+		key.WithKeys("!"),
+		key.WithHelp("!", "alerts"),
+	),
+	Status: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "set up stub_status"),
+	),
 }
 
 // ShortHelp returns a short help text
@@ -0,0 +1,705 @@
+package nginx
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	crossplane "github.com/nginxinc/nginx-go-crossplane"
+	"golang.org/x/crypto/acme"
+
+	"github.com/aitmiloud/ngxtui/internal/config"
+)
+
+// ACMESnippetPath is the include file that serves HTTP-01 challenge responses
+const ACMESnippetPath = "/etc/nginx/snippets/ngxtui-acme.conf"
+
+// acmeChallengePrefix is the location HTTP-01 validation requests are sent to
+const acmeChallengePrefix = "/.well-known/acme-challenge/"
+
+// big128 bounds random certificate serial numbers
+var big128 = new(big.Int).Lsh(big.NewInt(1), 128)
+
+// ManagedCertificate is a certificate issued and renewed by NgxTUI
+type ManagedCertificate struct {
+	Site     string    `json:"site"`
+	Domains  []string  `json:"domains"`
+	CertPath string    `json:"cert_path"`
+	KeyPath  string    `json:"key_path"`
+	Issued   time.Time `json:"issued"`
+	NotAfter time.Time `json:"not_after"`
+}
+
+// ACMEIssuer obtains and renews certificates using ACME HTTP-01 challenges
+type ACMEIssuer struct {
+	service *Service
+	cfg     config.ACMEConfig
+}
+
+// NewACMEIssuer creates an ACME issuer for the given service
+func NewACMEIssuer(s *Service, cfg config.ACMEConfig) *ACMEIssuer {
+	return &ACMEIssuer{service: s, cfg: cfg}
+}
+
+// challengeDir is where challenge response files are written
+func (a *ACMEIssuer) challengeDir() string {
+	return filepath.Join(a.cfg.StateDir, "challenges")
+}
+
+// EnsureChallengeSnippet writes the shared acme-challenge location snippet
+func (a *ACMEIssuer) EnsureChallengeSnippet() error {
+	if err := requireNativeNginx("ACME issuance"); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(a.challengeDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create challenge directory: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(ACMESnippetPath), 0755); err != nil {
+		return fmt.Errorf("failed to create snippets directory: %w", err)
+	}
+
+	content := fmt.Sprintf(`# Managed by ngxtui - serves ACME HTTP-01 challenge responses
+location ^~ %s {
+    default_type "text/plain";
+    alias %s/;
+}
+`, acmeChallengePrefix, a.challengeDir())
+
+	if existing, err := os.ReadFile(ACMESnippetPath); err == nil && string(existing) == content {
+		return nil
+	}
+	if err := os.WriteFile(ACMESnippetPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write ACME snippet: %w", err)
+	}
+	return nil
+}
+
+// IssueForSite obtains a certificate covering every server_name of a site. The
+// certificate is written to the paths the site's ssl_certificate directives
+// point at, or to the state directory if the site has none, and NGINX is reloaded.
+func (a *ACMEIssuer) IssueForSite(ctx context.Context, site string) (*ManagedCertificate, error) {
+	if err := a.EnsureChallengeSnippet(); err != nil {
+		return nil, err
+	}
+
+	blocks, err := a.service.ServerBlocks()
+	if err != nil {
+		return nil, err
+	}
+
+	managed := &ManagedCertificate{Site: site}
+	var siteBlocks []ServerBlock
+	seen := make(map[string]bool)
+	for _, block := range blocks {
+		if block.Site != site {
+			continue
+		}
+		siteBlocks = append(siteBlocks, block)
+		for _, name := range block.ServerNames {
+			if isIssuableName(name) && !seen[name] {
+				seen[name] = true
+				managed.Domains = append(managed.Domains, name)
+			}
+		}
+		for _, d := range block.Directives {
+			if d.Directive == "ssl_certificate" && managed.CertPath == "" && len(d.Args) > 0 {
+				managed.CertPath = resolveConfigPath(d.Args[0])
+			}
+			if d.Directive == "ssl_certificate_key" && managed.KeyPath == "" && len(d.Args) > 0 {
+				managed.KeyPath = resolveConfigPath(d.Args[0])
+			}
+		}
+	}
+
+	if len(siteBlocks) == 0 {
+		return nil, fmt.Errorf("site %s is not part of the loaded configuration (is it enabled?)", site)
+	}
+	if len(managed.Domains) == 0 {
+		return nil, fmt.Errorf("site %s has no server_name that a certificate can be issued for", site)
+	}
+	if managed.CertPath == "" || managed.KeyPath == "" {
+		dir := filepath.Join(a.cfg.StateDir, "certs", managed.Domains[0])
+		managed.CertPath = filepath.Join(dir, "fullchain.pem")
+		managed.KeyPath = filepath.Join(dir, "privkey.pem")
+	}
+
+	if err := a.ensureChallengeRoute(siteBlocks); err != nil {
+		return nil, err
+	}
+
+	if err := a.Issue(ctx, managed); err != nil {
+		return nil, err
+	}
+	if err := a.saveManaged(*managed); err != nil {
+		return managed, err
+	}
+	return managed, nil
+}
+
+// Issue obtains a certificate for the domains of mc, writes it to mc.CertPath
+// and mc.KeyPath, then tests and reloads NGINX
+func (a *ACMEIssuer) Issue(ctx context.Context, mc *ManagedCertificate) error {
+	if err := requireNativeNginx("ACME issuance"); err != nil {
+		return err
+	}
+
+	client, err := a.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	order, err := client.AuthorizeOrder(ctx, acme.DomainIDs(mc.Domains...))
+	if err != nil {
+		return fmt.Errorf("failed to create order: %w", err)
+	}
+
+	for _, authzURL := range order.AuthzURLs {
+		if err := a.authorize(ctx, client, authzURL); err != nil {
+			return err
+		}
+	}
+
+	order, err = client.WaitOrder(ctx, order.URI)
+	if err != nil {
+		return fmt.Errorf("order did not become ready: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: mc.Domains[0]},
+		DNSNames: mc.Domains,
+	}, key)
+	if err != nil {
+		return fmt.Errorf("failed to create CSR: %w", err)
+	}
+
+	chain, _, err := client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return fmt.Errorf("failed to finalize order: %w", err)
+	}
+
+	// The old pair is put back if NGINX can't load the new one
+	restores := []func(){snapshotFile(mc.CertPath), snapshotFile(mc.KeyPath)}
+	err = writeKeyPair(mc.CertPath, mc.KeyPath, chain, key)
+	if err == nil {
+		err = a.service.TestConfig()
+	}
+	if err == nil {
+		err = a.service.Reload()
+	}
+	if err != nil {
+		for _, restore := range restores {
+			restore()
+		}
+		return err
+	}
+
+	if leaf, err := x509.ParseCertificate(chain[0]); err == nil {
+		mc.NotAfter = leaf.NotAfter
+	}
+	mc.Issued = time.Now()
+	return nil
+}
+
+// authorize completes the HTTP-01 challenge for a single authorization
+func (a *ACMEIssuer) authorize(ctx context.Context, client *acme.Client, authzURL string) error {
+	authz, err := client.GetAuthorization(ctx, authzURL)
+	if err != nil {
+		return fmt.Errorf("failed to fetch authorization: %w", err)
+	}
+	if authz.Status == acme.StatusValid {
+		return nil
+	}
+
+	var challenge *acme.Challenge
+	for _, c := range authz.Challenges {
+		if c.Type == "http-01" {
+			challenge = c
+			break
+		}
+	}
+	if challenge == nil {
+		return fmt.Errorf("no http-01 challenge offered for %s", authz.Identifier.Value)
+	}
+
+	response, err := client.HTTP01ChallengeResponse(challenge.Token)
+	if err != nil {
+		return err
+	}
+	responsePath := filepath.Join(a.challengeDir(), challenge.Token)
+	if err := os.WriteFile(responsePath, []byte(response), 0644); err != nil {
+		return fmt.Errorf("failed to write challenge response: %w", err)
+	}
+	defer os.Remove(responsePath)
+
+	if _, err := client.Accept(ctx, challenge); err != nil {
+		return fmt.Errorf("failed to accept challenge: %w", err)
+	}
+	if _, err := client.WaitAuthorization(ctx, authz.URI); err != nil {
+		return fmt.Errorf("validation failed for %s: %w", authz.Identifier.Value, err)
+	}
+	return nil
+}
+
+// RenewDue renews every managed certificate that expires within RenewBeforeDays
+func (a *ACMEIssuer) RenewDue(ctx context.Context) ([]ManagedCertificate, error) {
+	managed, err := a.loadManaged()
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := time.Duration(a.cfg.RenewBeforeDays) * 24 * time.Hour
+	var renewed []ManagedCertificate
+	var snippetErr error
+	snippetChecked := false
+	var failures []string
+	for _, mc := range managed {
+		notAfter := mc.NotAfter
+		if data, err := os.ReadFile(mc.CertPath); err == nil {
+			if chain, err := decodeCertificateChain(data); err == nil {
+				notAfter = chain[0].NotAfter
+			}
+		}
+		if time.Until(notAfter) > threshold {
+			continue
+		}
+
+		// HTTP-01 needs the challenge snippet the sites include
+		if !snippetChecked {
+			snippetErr = a.EnsureChallengeSnippet()
+			snippetChecked = true
+		}
+		if snippetErr != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", mc.Site, snippetErr))
+			continue
+		}
+		if err := a.Issue(ctx, &mc); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", mc.Site, err))
+			continue
+		}
+		if err := a.saveManaged(mc); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", mc.Site, err))
+		}
+		renewed = append(renewed, mc)
+	}
+
+	if len(failures) > 0 {
+		return renewed, fmt.Errorf("renewal failed: %s", strings.Join(failures, "; "))
+	}
+	return renewed, nil
+}
+
+// client returns an ACME client with a registered account
+func (a *ACMEIssuer) client(ctx context.Context) (*acme.Client, error) {
+	key, err := a.accountKey()
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	if a.cfg.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		caPEM, err := os.ReadFile(a.cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ACME CA file: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in %s", a.cfg.CAFile)
+		}
+		httpClient.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+	}
+
+	client := &acme.Client{
+		Key:          key,
+		DirectoryURL: a.cfg.DirectoryURL,
+		HTTPClient:   httpClient,
+		UserAgent:    "ngxtui",
+	}
+
+	account := &acme.Account{}
+	if a.cfg.Email != "" {
+		account.Contact = []string{"mailto:" + a.cfg.Email}
+	}
+	if _, err := client.Register(ctx, account, acme.AcceptTOS); err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+		return nil, fmt.Errorf("failed to register ACME account: %w", err)
+	}
+
+	return client, nil
+}
+
+// accountKey loads the ACME account key, creating it on first use
+func (a *ACMEIssuer) accountKey() (crypto.Signer, error) {
+	path := filepath.Join(a.cfg.StateDir, "account.key")
+	if data, err := os.ReadFile(path); err == nil {
+		return parsePrivateKey(data)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate account key: %w", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(a.cfg.StateDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create ACME state directory: %w", err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write account key: %w", err)
+	}
+	return key, nil
+}
+
+// ensureChallengeRoute makes every plain-HTTP server block of a site include the
+// challenge snippet, moving server-level redirects into location / so they
+// don't shadow it
+func (a *ACMEIssuer) ensureChallengeRoute(blocks []ServerBlock) error {
+	type edit struct {
+		line   int
+		insert string // Statement added after the line, inside it if it opens a block
+		move   int    // Line of a return moved into the location / opened on the line
+		wrap   bool   // Wrap the return on the line in location /
+		remove bool   // Drop the return on the line, moved into location /
+	}
+	edits := make(map[string][]edit)
+
+	httpBlocks := 0
+	for _, block := range blocks {
+		if !listensOnPlainHTTP(block) {
+			continue
+		}
+		httpBlocks++
+		if hasChallengeLocation(block) {
+			continue
+		}
+
+		anchor := block.Line
+		for _, d := range block.Directives {
+			if d.Directive == "server_name" && d.File == block.File {
+				anchor = d.Line
+				break
+			}
+		}
+		edits[block.File] = append(edits[block.File], edit{line: anchor, insert: "include " + ACMESnippetPath + ";"})
+
+		// A location / already there would clash with a wrapped return
+		var root *crossplane.Directive
+		for _, d := range block.Directives {
+			if d.Directive == "location" && len(d.Args) == 1 && d.Args[0] == "/" && d.File == block.File {
+				root = d
+			}
+		}
+		for _, d := range block.Directives {
+			if d.Directive != "return" || d.File != block.File {
+				continue
+			}
+			if root == nil {
+				edits[block.File] = append(edits[block.File], edit{line: d.Line, wrap: true})
+				continue
+			}
+			edits[block.File] = append(edits[block.File], edit{line: d.Line, remove: true}, edit{line: root.Line, move: d.Line})
+		}
+	}
+
+	if httpBlocks == 0 {
+		return fmt.Errorf("site has no server block listening on port 80; HTTP-01 validation needs one")
+	}
+	if len(edits) == 0 {
+		return nil
+	}
+
+	// Apply edits bottom-up so earlier line numbers stay valid
+	var restores []func()
+	undo := func() {
+		for _, restore := range restores {
+			restore()
+		}
+	}
+	for file, fileEdits := range edits {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			undo()
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			undo()
+			return err
+		}
+
+		lines := strings.Split(string(data), "\n")
+		original := slices.Clone(lines)
+		sort.SliceStable(fileEdits, func(i, j int) bool { return fileEdits[i].line > fileEdits[j].line })
+		for _, e := range fileEdits {
+			idx := e.line - 1
+			if idx < 0 || idx >= len(lines) {
+				continue
+			}
+			indent := lines[idx][:len(lines[idx])-len(strings.TrimLeft(lines[idx], " \t"))]
+			statement := strings.TrimSpace(lines[idx])
+			if e.move > 0 && e.move <= len(original) {
+				e.insert = strings.TrimSpace(original[e.move-1])
+			}
+			switch {
+			case e.move > 0 && !strings.HasSuffix(statement, "{"):
+				undo()
+				return fmt.Errorf("move the return on line %d of %s into location /", e.move, file)
+			case e.insert != "":
+				if strings.HasSuffix(statement, "{") {
+					indent += "    "
+				}
+				lines = append(lines[:idx+1], append([]string{indent + e.insert}, lines[idx+1:]...)...)
+			case !strings.HasPrefix(statement, "return") || !strings.HasSuffix(statement, ";"):
+				undo()
+				return fmt.Errorf("move the return on line %d of %s into location /", e.line, file)
+			case e.wrap:
+				lines[idx] = indent + "location / { " + statement + " }"
+			case e.remove:
+				lines = append(lines[:idx], lines[idx+1:]...)
+			}
+		}
+
+		restores = append(restores, snapshotFile(file))
+		if err := writeFileAtomic(file, []byte(strings.Join(lines, "\n")), info.Mode().Perm()); err != nil {
+			undo()
+			return fmt.Errorf("failed to update %s: %w", file, err)
+		}
+	}
+
+	err := a.service.TestConfig()
+	if err == nil {
+		err = a.service.Reload()
+	}
+	if err != nil {
+		undo()
+		return fmt.Errorf("failed to add challenge location: %w", err)
+	}
+	return nil
+}
+
+// listensOnPlainHTTP reports whether a server block accepts plain HTTP on port 80
+func listensOnPlainHTTP(block ServerBlock) bool {
+	hasListen := false
+	for _, d := range block.Directives {
+		if d.Directive != "listen" || len(d.Args) == 0 {
+			continue
+		}
+		hasListen = true
+		addr := d.Args[0]
+		port := addr[strings.LastIndex(addr, ":")+1:]
+		if port != "80" {
+			continue
+		}
+		ssl := false
+		for _, arg := range d.Args[1:] {
+			if arg == "ssl" {
+				ssl = true
+			}
+		}
+		if !ssl {
+			return true
+		}
+	}
+	// A server without listen directives listens on *:80
+	return !hasListen
+}
+
+// hasChallengeLocation reports whether the block already serves ACME challenges
+func hasChallengeLocation(block ServerBlock) bool {
+	for _, d := range block.Directives {
+		if d.Directive != "location" {
+			continue
+		}
+		for _, arg := range d.Args {
+			if strings.HasPrefix(arg, strings.TrimSuffix(acmeChallengePrefix, "/")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isIssuableName reports whether a server_name can appear on a public certificate
+func isIssuableName(name string) bool {
+	if name == "" || name == "_" || name == "localhost" {
+		return false
+	}
+	if strings.HasPrefix(name, "~") || strings.HasPrefix(name, ".") || strings.Contains(name, "*") || strings.Contains(name, "$") {
+		return false
+	}
+	return strings.Contains(name, ".")
+}
+
+// managedPath is the file listing certificates NgxTUI renews
+func (a *ACMEIssuer) managedPath() string {
+	return filepath.Join(a.cfg.StateDir, "certificates.json")
+}
+
+// loadManaged reads the managed certificate list
+func (a *ACMEIssuer) loadManaged() ([]ManagedCertificate, error) {
+	data, err := os.ReadFile(a.managedPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var managed []ManagedCertificate
+	if err := json.Unmarshal(data, &managed); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", a.managedPath(), err)
+	}
+	return managed, nil
+}
+
+// saveManaged adds or replaces a managed certificate entry
+func (a *ACMEIssuer) saveManaged(mc ManagedCertificate) error {
+	managed, err := a.loadManaged()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i := range managed {
+		if managed[i].CertPath == mc.CertPath {
+			managed[i] = mc
+			replaced = true
+		}
+	}
+	if !replaced {
+		managed = append(managed, mc)
+	}
+
+	data, err := json.MarshalIndent(managed, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(a.cfg.StateDir, 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(a.managedPath(), data, 0600)
+}
+
+// writeKeyPair writes a PEM certificate chain and private key, key first so
+// NGINX never sees a certificate without its key
+func writeKeyPair(certPath, keyPath string, chain [][]byte, key crypto.Signer) error {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode private key: %w", err)
+	}
+
+	var certPEM []byte
+	for _, der := range chain {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+
+	if err := writeFileAtomic(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return fmt.Errorf("failed to write private key: %w", err)
+	}
+	if err := writeFileAtomic(certPath, certPEM, 0644); err != nil {
+		return fmt.Errorf("failed to write certificate: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file and renames it into place
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// parsePrivateKey decodes a PEM private key in PKCS#8, PKCS#1 or SEC 1 form
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in key file")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported private key format")
+}
+
+// EnsurePlaceholderCertificate writes a short-lived self-signed certificate if
+// certPath does not exist yet, so a new SSL site passes nginx -t before the
+// real certificate has been issued
+func EnsurePlaceholderCertificate(certPath, keyPath string, domains []string) error {
+	if _, err := os.Stat(certPath); err == nil {
+		return nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	template, err := leafTemplate(domains, 7*24*time.Hour)
+	if err != nil {
+		return err
+	}
+	template.Subject.Organization = []string{"ngxtui placeholder"}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return fmt.Errorf("failed to create placeholder certificate: %w", err)
+	}
+	return writeKeyPair(certPath, keyPath, [][]byte{der}, key)
+}
+
+// requireNativeNginx returns an error when NGINX runs in Docker, where NgxTUI
+// cannot write files the feature relies on
+func requireNativeNginx(feature string) error {
+	if IsDockerAvailable() {
+		if _, err := getCachedContainerID(); err == nil {
+			return fmt.Errorf("%s is only supported for native NGINX", feature)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,442 @@
+package nginx
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aitmiloud/ngxtui/internal/config"
+)
+
+// fakeNginx replaces PATH with nginx and systemctl commands that log their
+// arguments and exit with the given codes, so TestConfig and Reload run
+// without NGINX. It returns a function listing the commands run so far.
+func fakeNginx(t *testing.T, testExit, reloadExit int) func() []string {
+	t.Helper()
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	for name, exit := range map[string]int{"nginx": testExit, "systemctl": reloadExit} {
+		script := fmt.Sprintf("#!/bin/sh\necho \"%s $*\" >> %s\nexit %d\n", name, calls, exit)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+	return func() []string {
+		data, _ := os.ReadFile(calls)
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+}
+
+func TestEnsureChallengeRoute(t *testing.T) {
+	snippet := "include " + ACMESnippetPath + ";"
+	tests := []struct {
+		name    string
+		site    string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "plain server",
+			site: "server {\n" +
+				"    listen 80;\n" +
+				"    server_name example.com;\n" +
+				"    root /var/www;\n" +
+				"}\n",
+			want: "server {\n" +
+				"    listen 80;\n" +
+				"    server_name example.com;\n" +
+				"    " + snippet + "\n" +
+				"    root /var/www;\n" +
+				"}\n",
+		},
+		{
+			name: "server-level redirect",
+			site: "server {\n" +
+				"    listen 80;\n" +
+				"    server_name example.com;\n" +
+				"    return 301 https://$host$request_uri;\n" +
+				"}\n",
+			want: "server {\n" +
+				"    listen 80;\n" +
+				"    server_name example.com;\n" +
+				"    " + snippet + "\n" +
+				"    location / { return 301 https://$host$request_uri; }\n" +
+				"}\n",
+		},
+		{
+			// A second location / would fail nginx -t
+			name: "redirect beside location /",
+			site: "server {\n" +
+				"    listen 80;\n" +
+				"    server_name example.com;\n" +
+				"    return 301 \"https://example.com$request_uri\";\n" +
+				"    location / {\n" +
+				"        root /var/www;\n" +
+				"    }\n" +
+				"}\n",
+			want: "server {\n" +
+				"    listen 80;\n" +
+				"    server_name example.com;\n" +
+				"    " + snippet + "\n" +
+				"    location / {\n" +
+				"        return 301 \"https://example.com$request_uri\";\n" +
+				"        root /var/www;\n" +
+				"    }\n" +
+				"}\n",
+		},
+		{
+			name: "redirect after location /",
+			site: "server {\n" +
+				"    listen 80;\n" +
+				"    location / {\n" +
+				"        root /var/www;\n" +
+				"    }\n" +
+				"    return 302 https://example.com/;\n" +
+				"}\n",
+			want: "server {\n" +
+				"    " + snippet + "\n" +
+				"    listen 80;\n" +
+				"    location / {\n" +
+				"        return 302 https://example.com/;\n" +
+				"        root /var/www;\n" +
+				"    }\n" +
+				"}\n",
+		},
+		{
+			name: "one-line location /",
+			site: "server {\n" +
+				"    listen 80;\n" +
+				"    return 301 https://example.com/;\n" +
+				"    location / { root /var/www; }\n" +
+				"}\n",
+			wantErr: "into location /",
+		},
+		{
+			name: "challenge already served",
+			site: "server {\n" +
+				"    listen 80;\n" +
+				"    location /.well-known/acme-challenge/ { root /srv/acme; }\n" +
+				"    return 301 https://example.com/;\n" +
+				"}\n",
+			want: "server {\n" +
+				"    listen 80;\n" +
+				"    location /.well-known/acme-challenge/ { root /srv/acme; }\n" +
+				"    return 301 https://example.com/;\n" +
+				"}\n",
+		},
+		{
+			name:    "HTTPS only",
+			site:    "server {\n    listen 443 ssl;\n}\n",
+			wantErr: "port 80",
+		},
+	}
+	for _, tt := range tests {
+		fakeNginx(t, 0, 0)
+		dir := t.TempDir()
+		site := filepath.Join(dir, "example")
+		if err := os.WriteFile(site, []byte(tt.site), 0o640); err != nil {
+			t.Fatal(err)
+		}
+		blocks := testServerBlocks(t, dir)
+
+		issuer := NewACMEIssuer(New(), config.ACMEConfig{StateDir: dir})
+		err := issuer.ensureChallengeRoute(blocks)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error %v, want %q", tt.name, err, tt.wantErr)
+			}
+			if data, _ := os.ReadFile(site); string(data) != tt.site {
+				t.Errorf("%s: site changed on error:\n%s", tt.name, data)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if data, _ := os.ReadFile(site); string(data) != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, data, tt.want)
+		}
+		if info, err := os.Stat(site); err != nil || info.Mode().Perm() != 0o640 {
+			t.Errorf("%s: mode not kept", tt.name)
+		}
+	}
+}
+
+func TestEnsureChallengeRouteRestores(t *testing.T) {
+	site := "server {\n    listen 80;\n    return 301 https://example.com/;\n}\n"
+	for name, exits := range map[string][2]int{"config test fails": {1, 0}, "reload fails": {0, 1}} {
+		calls := fakeNginx(t, exits[0], exits[1])
+		dir := t.TempDir()
+		path := filepath.Join(dir, "example")
+		if err := os.WriteFile(path, []byte(site), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		issuer := NewACMEIssuer(New(), config.ACMEConfig{StateDir: dir})
+		if err := issuer.ensureChallengeRoute(testServerBlocks(t, dir)); err == nil {
+			t.Errorf("%s: succeeded (ran %q)", name, calls())
+		}
+		if data, _ := os.ReadFile(path); string(data) != site {
+			t.Errorf("%s: site not restored:\n%s", name, data)
+		}
+	}
+}
+
+// testServerBlocks parses a configuration whose http block includes every
+// file in dir
+func testServerBlocks(t *testing.T, dir string) []ServerBlock {
+	t.Helper()
+	conf := filepath.Join(t.TempDir(), "nginx.conf")
+	if err := os.WriteFile(conf, []byte("events {}\nhttp {\n    include "+dir+"/*;\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(path string) { nginxConfPath = path }(nginxConfPath)
+	nginxConfPath = conf
+	blocks, err := New().ServerBlocks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blocks
+}
+
+// acmeStub is a minimal RFC 8555 CA. It checks that the HTTP-01 response
+// is in place when a challenge is accepted instead of fetching it, and
+// doesn't verify request signatures.
+type acmeStub struct {
+	*httptest.Server
+	challengeDir string
+	ca           *x509.Certificate
+	caKey        *ecdsa.PrivateKey
+
+	mu      sync.Mutex
+	domains []string
+	valid   map[int]bool // Authorizations by index
+	leaf    []byte
+}
+
+func newACMEStub(t *testing.T, challengeDir string) *acmeStub {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "stub CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(der)
+
+	stub := &acmeStub{challengeDir: challengeDir, ca: ca, caKey: key, valid: map[int]bool{}}
+	stub.Server = httptest.NewServer(http.HandlerFunc(stub.serve))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+func (s *acmeStub) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.Header().Set("Replay-Nonce", fmt.Sprintf("nonce-%d", time.Now().UnixNano()))
+
+	var payload []byte
+	if r.Method == http.MethodPost {
+		var jws struct{ Payload string }
+		json.NewDecoder(r.Body).Decode(&jws)
+		payload, _ = base64.RawURLEncoding.DecodeString(jws.Payload)
+	}
+	reply := func(status int, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+	order := func() map[string]any {
+		status := "ready"
+		var authzs []string
+		for i := range s.domains {
+			authzs = append(authzs, fmt.Sprintf("%s/authz/%d", s.URL, i))
+			if !s.valid[i] {
+				status = "pending"
+			}
+		}
+		return map[string]any{"status": status, "authorizations": authzs, "finalize": s.URL + "/finalize"}
+	}
+
+	var i int
+	switch path := r.URL.Path; {
+	case path == "/directory":
+		reply(http.StatusOK, map[string]string{
+			"newNonce":   s.URL + "/nonce",
+			"newAccount": s.URL + "/account",
+			"newOrder":   s.URL + "/order",
+		})
+	case path == "/nonce":
+		w.WriteHeader(http.StatusOK)
+	case path == "/account":
+		w.Header().Set("Location", s.URL+"/account/1")
+		reply(http.StatusCreated, map[string]string{"status": "valid"})
+	case path == "/order":
+		var req struct{ Identifiers []struct{ Value string } }
+		json.Unmarshal(payload, &req)
+		s.domains = nil
+		for _, id := range req.Identifiers {
+			s.domains = append(s.domains, id.Value)
+		}
+		w.Header().Set("Location", s.URL+"/order/1")
+		reply(http.StatusCreated, order())
+	case path == "/order/1":
+		reply(http.StatusOK, order())
+	case scanIndex(path, "/authz/%d", &i):
+		status := "pending"
+		if s.valid[i] {
+			status = "valid"
+		}
+		reply(http.StatusOK, map[string]any{
+			"status":     status,
+			"identifier": map[string]string{"type": "dns", "value": s.domains[i]},
+			"challenges": []map[string]string{{"type": "http-01", "url": fmt.Sprintf("%s/challenge/%d", s.URL, i), "token": fmt.Sprintf("token%d", i), "status": status}},
+		})
+	case scanIndex(path, "/challenge/%d", &i):
+		token := fmt.Sprintf("token%d", i)
+		response, err := os.ReadFile(filepath.Join(s.challengeDir, token))
+		status := "invalid"
+		if err == nil && strings.HasPrefix(string(response), token+".") {
+			status = "valid"
+			s.valid[i] = true
+		}
+		reply(http.StatusOK, map[string]string{"type": "http-01", "url": s.URL + path, "token": token, "status": status})
+	case path == "/finalize":
+		var req struct{ CSR string }
+		json.Unmarshal(payload, &req)
+		der, _ := base64.RawURLEncoding.DecodeString(req.CSR)
+		csr, err := x509.ParseCertificateRequest(der)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		template, _ := leafTemplate(csr.DNSNames, 90*24*time.Hour)
+		s.leaf, err = x509.CreateCertificate(rand.Reader, template, s.ca, csr.PublicKey, s.caKey)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		reply(http.StatusOK, map[string]string{"status": "valid", "certificate": s.URL + "/cert"})
+	case path == "/cert":
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: s.leaf})
+		pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: s.ca.Raw})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// scanIndex reads the number in a path such as "/authz/1"
+func scanIndex(path, format string, i *int) bool {
+	_, err := fmt.Sscanf(path, format, i)
+	return err == nil
+}
+
+func TestIssue(t *testing.T) {
+	domains := []string{"example.com", "www.example.com"}
+	tests := []struct {
+		name                 string
+		testExit, reloadExit int
+		oldPair              bool
+		wantErr              bool
+	}{
+		{name: "issued", oldPair: true},
+		{name: "first certificate"},
+		{name: "config test fails", testExit: 1, oldPair: true, wantErr: true},
+		{name: "reload fails", reloadExit: 1, oldPair: true, wantErr: true},
+		{name: "reload fails without a pair before", reloadExit: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		calls := fakeNginx(t, tt.testExit, tt.reloadExit)
+		dir := t.TempDir()
+		issuer := NewACMEIssuer(New(), config.ACMEConfig{StateDir: dir})
+		if err := os.MkdirAll(issuer.challengeDir(), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		stub := newACMEStub(t, issuer.challengeDir())
+		issuer.cfg.DirectoryURL = stub.URL + "/directory"
+
+		mc := &ManagedCertificate{
+			Domains:  domains,
+			CertPath: filepath.Join(dir, "certs", "fullchain.pem"),
+			KeyPath:  filepath.Join(dir, "certs", "privkey.pem"),
+		}
+		var oldCert, oldKey []byte
+		if tt.oldPair {
+			if err := EnsurePlaceholderCertificate(mc.CertPath, mc.KeyPath, domains); err != nil {
+				t.Fatal(err)
+			}
+			oldCert, _ = os.ReadFile(mc.CertPath)
+			oldKey, _ = os.ReadFile(mc.KeyPath)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := issuer.Issue(ctx, mc)
+		cancel()
+		if entries, _ := os.ReadDir(issuer.challengeDir()); len(entries) != 0 {
+			t.Errorf("%s: challenge responses left behind", tt.name)
+		}
+
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: issued, want an error", tt.name)
+			}
+			cert, certErr := os.ReadFile(mc.CertPath)
+			key, _ := os.ReadFile(mc.KeyPath)
+			if tt.oldPair && (string(cert) != string(oldCert) || string(key) != string(oldKey)) {
+				t.Errorf("%s: old key pair not restored", tt.name)
+			}
+			if !tt.oldPair && !os.IsNotExist(certErr) {
+				t.Errorf("%s: new certificate left in place", tt.name)
+			}
+			if !mc.NotAfter.IsZero() {
+				t.Errorf("%s: expiry recorded for a certificate NGINX doesn't use", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		cert := &Certificate{CertPath: mc.CertPath, KeyPath: mc.KeyPath}
+		inspectCertificate(cert)
+		if cert.Err != nil || !cert.KeyMatch || cert.Issuer != "stub CA" || strings.Join(cert.SANs, " ") != strings.Join(domains, " ") {
+			t.Errorf("%s: written certificate %+v", tt.name, cert)
+		}
+		if !mc.NotAfter.Equal(cert.NotAfter) || mc.Issued.IsZero() {
+			t.Errorf("%s: not after %v issued %v", tt.name, mc.NotAfter, mc.Issued)
+		}
+		if got := strings.Join(calls(), "; "); got != "nginx -t; systemctl reload nginx" {
+			t.Errorf("%s: ran %q, want a config test and a reload", tt.name, got)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package nginx
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// AnomalyKind is a kind of deviation from a site's baseline
+type AnomalyKind int
+
+const (
+	ErrorSpike AnomalyKind = iota
+	TrafficDrop
+	LatencyRegression
+
+	anomalyKinds // Number of kinds; keep it last
+)
+
+// String returns the kind as shown in notifications
+func (k AnomalyKind) String() string {
+	switch k {
+	case ErrorSpike:
+		return "5xx spike"
+	case TrafficDrop:
+		return "Traffic drop"
+	case LatencyRegression:
+		return "Latency regression"
+	}
+	return "Unknown"
+}
+
+// Anomaly is a stretch of time a site's traffic deviated from its baseline
+type Anomaly struct {
+	Site     string // Site whose access_log the requests came from; empty for shared logs
+	Kind     AnomalyKind
+	Since    time.Time // Start of the first deviating interval
+	Until    time.Time // End of the last deviating interval; zero while ongoing
+	Value    float64   // The deviating value: 5xx share, requests/s or seconds
+	Baseline float64   // The baseline it deviated from, in the same unit
+}
+
+// Ongoing reports whether the site still deviates
+func (a Anomaly) Ongoing() bool {
+	return a.Until.IsZero()
+}
+
+// Detail describes the deviation, e.g. "38% 5xx, baseline 0.4%"
+func (a Anomaly) Detail() string {
+	switch a.Kind {
+	case ErrorSpike:
+		return fmt.Sprintf("%.0f%% 5xx, baseline %.1f%%", a.Value*100, a.Baseline*100)
+	case TrafficDrop:
+		return fmt.Sprintf("no requests, baseline %.1f req/s", a.Baseline)
+	case LatencyRegression:
+		return fmt.Sprintf("%s mean, baseline %s", anomalySeconds(a.Value), anomalySeconds(a.Baseline))
+	}
+	return ""
+}
+
+// anomalySeconds formats seconds to the millisecond, e.g. "350ms"
+func anomalySeconds(s float64) string {
+	return time.Duration(s * float64(time.Second)).Round(time.Millisecond).String()
+}
+
+const (
+	// anomalyInterval is how long a window is compared against the baseline
+	anomalyInterval = 10 * time.Second
+	// anomalyGrace is how long after its end an interval is closed, so lines
+	// still being written count towards it
+	anomalyGrace = 5 * time.Second
+	// anomalyAlpha weighs each interval in the baselines; about the last ten
+	// minutes count
+	anomalyAlpha = 2.0 / 61
+	// anomalyWarmup is how many intervals a baseline learns before it flags anything
+	anomalyWarmup = 30
+	// anomalyMaxGap is how far behind a baseline may fall before it is
+	// forgotten rather than caught up interval by interval
+	anomalyMaxGap = 24 * time.Hour
+	// anomalyMaxEvents bounds the kept findings
+	anomalyMaxEvents = 100
+)
+
+// Thresholds for flagging an interval
+const (
+	anomalyZ            = 4.0   // Standard deviations from the baseline
+	anomalyMinDrop      = 7.0   // Baseline requests per interval before an empty one counts; P(0) < 0.1%
+	anomalyMinErrors    = 5     // 5xx responses in the interval
+	anomalyMinShare     = 0.05  // 5xx share in the interval
+	anomalyMinTimed     = 5     // Requests with $request_time in the interval
+	anomalyLatencyRatio = 1.5   // Times the baseline mean
+	anomalyLatencyFloor = 0.050 // Seconds above the baseline mean
+)
+
+// ewma is an exponentially weighted mean and variance
+type ewma struct {
+	mean, variance float64
+	n              int
+}
+
+// add weighs in a value
+func (e *ewma) add(x float64) {
+	if e.n == 0 {
+		e.mean = x
+	}
+	diff := x - e.mean
+	incr := anomalyAlpha * diff
+	e.mean += incr
+	e.variance = (1 - anomalyAlpha) * (e.variance + diff*incr)
+	e.n++
+}
+
+// anomalyCounts is the traffic of one interval
+type anomalyCounts struct {
+	requests, errors int
+	timed            int
+	requestTime      time.Duration
+}
+
+// siteBaseline is a site's open interval and what its closed ones were like
+type siteBaseline struct {
+	interval int64 // Open interval, counted since the epoch
+	open     anomalyCounts
+	rate     ewma // Requests per interval
+	errors   ewma // 5xx share
+	latency  ewma // Mean $request_time, in seconds
+	active   [anomalyKinds]*Anomaly
+}
+
+// AnomalyDetector learns per-site baselines of request rate, 5xx share and
+// latency from the tailed access logs and flags intervals that deviate from
+// them. It holds state only; the UI decides how findings are shown.
+type AnomalyDetector struct {
+	started time.Time // Intervals closing before this only teach the baselines
+	sites   map[string]*siteBaseline
+	events  []*Anomaly // Oldest first
+}
+
+// NewAnomalyDetector creates a detector that flags intervals from now on
+func NewAnomalyDetector(now time.Time) *AnomalyDetector {
+	return &AnomalyDetector{started: now, sites: make(map[string]*siteBaseline)}
+}
+
+// Restart forgets the baselines, for when the logs are read again from their
+// tails, and keeps the findings
+func (d *AnomalyDetector) Restart(now time.Time) {
+	d.started = now
+	d.sites = make(map[string]*siteBaseline)
+	for _, event := range d.events {
+		if event.Ongoing() {
+			event.Until = now
+		}
+	}
+}
+
+// Observe counts tailed entries, closes the intervals that ended before now
+// and returns the anomalies that started as a result. It is also called with
+// no entries so sites that stopped logging are noticed.
+func (d *AnomalyDetector) Observe(entries []LogEntry, now time.Time) []Anomaly {
+	if d == nil {
+		return nil
+	}
+	var started []Anomaly
+	for _, entry := range entries {
+		if entry.ParseErr != nil || entry.Timestamp.IsZero() {
+			continue
+		}
+		interval := entry.Timestamp.Unix() / int64(anomalyInterval/time.Second)
+		site, ok := d.sites[entry.Site]
+		if !ok {
+			site = &siteBaseline{interval: interval}
+			d.sites[entry.Site] = site
+		}
+		started = d.advance(entry.Site, site, interval, started)
+		if interval < site.interval {
+			// Logged in an interval that was already closed
+			continue
+		}
+		site.open.requests++
+		if entry.StatusClass == "5xx" {
+			site.open.errors++
+		}
+		if entry.RequestTime > 0 {
+			site.open.timed++
+			site.open.requestTime += entry.RequestTime
+		}
+	}
+
+	current := now.Add(-anomalyGrace).Unix() / int64(anomalyInterval/time.Second)
+	for name, site := range d.sites {
+		started = d.advance(name, site, current, started)
+	}
+	return started
+}
+
+// advance closes a site's intervals before the given one
+func (d *AnomalyDetector) advance(name string, site *siteBaseline, interval int64, started []Anomaly) []Anomaly {
+	if time.Duration(interval-site.interval)*anomalyInterval > anomalyMaxGap {
+		// Too stale to catch up; learn again from here
+		*site = siteBaseline{interval: interval}
+		return started
+	}
+	for site.interval < interval {
+		started = d.close(name, site, started)
+		site.interval++
+		site.open = anomalyCounts{}
+	}
+	return started
+}
+
+// close compares a site's open interval with its baselines, records the
+// anomalies starting or ending with it and then learns from it
+func (d *AnomalyDetector) close(name string, site *siteBaseline, started []Anomaly) []Anomaly {
+	counts := site.open
+	start := time.Unix(site.interval*int64(anomalyInterval/time.Second), 0)
+	end := start.Add(anomalyInterval)
+
+	var flagged [anomalyKinds]bool
+	var values, baselines [anomalyKinds]float64
+
+	// Traffic drops to zero where the baseline makes an empty interval unlikely
+	if site.rate.n >= anomalyWarmup && site.rate.mean >= anomalyMinDrop {
+		flagged[TrafficDrop] = counts.requests == 0
+		values[TrafficDrop] = 0
+		baselines[TrafficDrop] = site.rate.mean / anomalyInterval.Seconds()
+	}
+
+	// 5xx spikes, tested as a binomial share against the baseline share
+	if site.errors.n >= anomalyWarmup && counts.requests > 0 {
+		p := math.Max(site.errors.mean, 0.01)
+		n := float64(counts.requests)
+		share := float64(counts.errors) / n
+		z := (float64(counts.errors) - n*p) / math.Sqrt(n*p*(1-p))
+		flagged[ErrorSpike] = counts.errors >= anomalyMinErrors && share >= anomalyMinShare && z >= anomalyZ
+		values[ErrorSpike], baselines[ErrorSpike] = share, site.errors.mean
+	}
+
+	// Latency regressions of the interval's mean request time
+	var latency float64
+	if counts.timed > 0 {
+		latency = (counts.requestTime / time.Duration(counts.timed)).Seconds()
+	}
+	if site.latency.n >= anomalyWarmup && counts.timed >= anomalyMinTimed {
+		base := site.latency.mean
+		sd := math.Max(math.Sqrt(site.latency.variance), base*0.1)
+		flagged[LatencyRegression] = (latency-base)/sd >= anomalyZ &&
+			latency >= base*anomalyLatencyRatio && latency-base >= anomalyLatencyFloor
+		values[LatencyRegression], baselines[LatencyRegression] = latency, base
+	}
+
+	for kind := range anomalyKinds {
+		active := site.active[kind]
+		switch {
+		case flagged[kind] && active == nil && !end.Before(d.started):
+			event := &Anomaly{Site: name, Kind: kind, Since: start, Value: values[kind], Baseline: baselines[kind]}
+			site.active[kind] = event
+			d.record(event)
+			started = append(started, *event)
+		case flagged[kind] && active != nil:
+			// Report the worst interval of an ongoing anomaly
+			if kind == TrafficDrop || values[kind] > active.Value {
+				active.Value = values[kind]
+			}
+		case !flagged[kind] && active != nil:
+			active.Until = start
+			site.active[kind] = nil
+		}
+	}
+
+	// Deviating intervals don't teach the baseline they deviate from
+	if !flagged[TrafficDrop] {
+		site.rate.add(float64(counts.requests))
+	}
+	if !flagged[ErrorSpike] && counts.requests > 0 {
+		site.errors.add(float64(counts.errors) / float64(counts.requests))
+	}
+	if !flagged[LatencyRegression] && counts.timed > 0 {
+		site.latency.add(latency)
+	}
+	return started
+}
+
+// record keeps a finding, forgetting the oldest ones that are over
+func (d *AnomalyDetector) record(event *Anomaly) {
+	d.events = append(d.events, event)
+	for i := 0; len(d.events) > anomalyMaxEvents && i < len(d.events); {
+		if d.events[i].Ongoing() {
+			i++
+			continue
+		}
+		d.events = append(d.events[:i], d.events[i+1:]...)
+	}
+}
+
+// Events returns the findings, newest first
+func (d *AnomalyDetector) Events() []Anomaly {
+	if d == nil {
+		return nil
+	}
+	events := make([]Anomaly, len(d.events))
+	for i, event := range d.events {
+		events[len(d.events)-1-i] = *event
+	}
+	return events
+}
+
+// Ongoing returns the anomalies still going on, newest first
+func (d *AnomalyDetector) Ongoing() []Anomaly {
+	var ongoing []Anomaly
+	for _, event := range d.Events() {
+		if event.Ongoing() {
+			ongoing = append(ongoing, event)
+		}
+	}
+	return ongoing
+}
+
+// Sites returns how many sites have baselines, and how many of them have
+// learned enough to flag anomalies
+func (d *AnomalyDetector) Sites() (watched, ready int) {
+	if d == nil {
+		return 0, 0
+	}
+	for _, site := range d.sites {
+		watched++
+		if site.rate.n >= anomalyWarmup {
+			ready++
+		}
+	}
+	return watched, ready
+}
@@ -0,0 +1,191 @@
+package nginx
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEWMA(t *testing.T) {
+	var e ewma
+	for range 100 {
+		e.add(10)
+	}
+	if e.mean != 10 || e.variance != 0 || e.n != 100 {
+		t.Errorf("steady values: mean %v variance %v n %d", e.mean, e.variance, e.n)
+	}
+
+	// One step weighs in alpha of the difference
+	e.add(20)
+	if want := 10 + 10*anomalyAlpha; math.Abs(e.mean-want) > 1e-9 {
+		t.Errorf("mean = %v, want %v", e.mean, want)
+	}
+	if want := (1 - anomalyAlpha) * 100 * anomalyAlpha; math.Abs(e.variance-want) > 1e-9 {
+		t.Errorf("variance = %v, want %v", e.variance, want)
+	}
+
+	// About ten minutes of intervals move the mean most of the way
+	for range 60 {
+		e.add(20)
+	}
+	if e.mean < 18 || e.mean >= 20 {
+		t.Errorf("mean after a minute of the new level = %v", e.mean)
+	}
+}
+
+// anomalyTraffic is one interval's requests for a site
+type anomalyTraffic struct {
+	requests, errors int
+	requestTime      time.Duration
+}
+
+// observeInterval feeds an interval's requests and closes it
+func observeInterval(d *AnomalyDetector, start time.Time, traffic anomalyTraffic) []Anomaly {
+	var entries []LogEntry
+	for i := range traffic.requests {
+		class := "2xx"
+		if i < traffic.errors {
+			class = "5xx"
+		}
+		entries = append(entries, LogEntry{
+			Timestamp:   start.Add(time.Duration(i) * anomalyInterval / time.Duration(traffic.requests)),
+			Site:        "shop",
+			StatusClass: class,
+			RequestTime: traffic.requestTime,
+		})
+	}
+	return d.Observe(entries, start.Add(anomalyInterval+anomalyGrace))
+}
+
+// warmAnomalyDetector returns a detector that started at base after
+// learning intervals of steady traffic before it
+func warmAnomalyDetector(base time.Time, intervals int) *AnomalyDetector {
+	d := NewAnomalyDetector(base)
+	for i := intervals; i > 0; i-- {
+		observeInterval(d, base.Add(-time.Duration(i)*anomalyInterval), anomalyTraffic{20, 0, 100 * time.Millisecond})
+	}
+	return d
+}
+
+func TestAnomalyDetectorObserve(t *testing.T) {
+	base := time.Unix(1760097600, 0)
+	tests := []struct {
+		name       string
+		warmup     int
+		traffic    anomalyTraffic
+		want       []AnomalyKind
+		wantDetail string
+	}{
+		{"steady", 60, anomalyTraffic{20, 0, 100 * time.Millisecond}, nil, ""},
+		{"error spike", 60, anomalyTraffic{20, 10, 100 * time.Millisecond}, []AnomalyKind{ErrorSpike}, "50% 5xx, baseline 0.0%"},
+		{"too few errors", 60, anomalyTraffic{20, 2, 100 * time.Millisecond}, nil, ""},
+		{"traffic drop", 60, anomalyTraffic{}, []AnomalyKind{TrafficDrop}, "no requests, baseline 2.0 req/s"},
+		{"fewer requests", 60, anomalyTraffic{5, 0, 100 * time.Millisecond}, nil, ""},
+		{"latency regression", 60, anomalyTraffic{20, 0, 500 * time.Millisecond}, []AnomalyKind{LatencyRegression}, "500ms mean, baseline 100ms"},
+		{"slower within the floor", 60, anomalyTraffic{20, 0, 140 * time.Millisecond}, nil, ""},
+		{"all at once", 60, anomalyTraffic{20, 20, 2 * time.Second}, []AnomalyKind{ErrorSpike, LatencyRegression}, ""},
+		// Baselines learn before they flag anything
+		{"still warming up", anomalyWarmup - 1, anomalyTraffic{20, 10, 500 * time.Millisecond}, nil, ""},
+	}
+	for _, tt := range tests {
+		d := warmAnomalyDetector(base, tt.warmup)
+		started := observeInterval(d, base, tt.traffic)
+		if len(started) != len(tt.want) {
+			t.Errorf("%s: got %+v, want %v", tt.name, started, tt.want)
+			continue
+		}
+		for i, a := range started {
+			if a.Kind != tt.want[i] || a.Site != "shop" || !a.Since.Equal(base) || !a.Ongoing() {
+				t.Errorf("%s: anomaly %d = %+v, want an ongoing %s from %v", tt.name, i, a, tt.want[i], base)
+			}
+		}
+		if tt.wantDetail != "" && started[0].Detail() != tt.wantDetail {
+			t.Errorf("%s: detail %q, want %q", tt.name, started[0].Detail(), tt.wantDetail)
+		}
+	}
+}
+
+func TestAnomalyDetectorLifecycle(t *testing.T) {
+	base := time.Unix(1760097600, 0)
+	d := warmAnomalyDetector(base, 60)
+	if watched, ready := d.Sites(); watched != 1 || ready != 1 {
+		t.Errorf("sites = %d watched, %d ready; want 1, 1", watched, ready)
+	}
+
+	spike := anomalyTraffic{20, 10, 100 * time.Millisecond}
+	steady := anomalyTraffic{20, 0, 100 * time.Millisecond}
+	at := func(i int) time.Time { return base.Add(time.Duration(i) * anomalyInterval) }
+
+	if started := observeInterval(d, at(0), spike); len(started) != 1 {
+		t.Fatalf("spike not flagged: %+v", started)
+	}
+	// A worse interval raises the ongoing anomaly instead of starting another
+	if started := observeInterval(d, at(1), anomalyTraffic{20, 15, 100 * time.Millisecond}); len(started) != 0 {
+		t.Errorf("ongoing spike flagged again: %+v", started)
+	}
+	ongoing := d.Ongoing()
+	if len(ongoing) != 1 || ongoing[0].Value != 0.75 {
+		t.Fatalf("ongoing = %+v, want the spike at its worst", ongoing)
+	}
+	// The spike didn't teach the baseline, so it is still flagged as one
+	if ongoing[0].Baseline != 0 {
+		t.Errorf("baseline moved to %v during the spike", ongoing[0].Baseline)
+	}
+
+	observeInterval(d, at(2), steady)
+	if len(d.Ongoing()) != 0 {
+		t.Errorf("spike still ongoing: %+v", d.Ongoing())
+	}
+	events := d.Events()
+	if len(events) != 1 || !events[0].Until.Equal(at(2)) {
+		t.Fatalf("events = %+v, want the spike ending at %v", events, at(2))
+	}
+
+	// Entries logged for an interval that was already closed are dropped
+	late := LogEntry{Timestamp: at(0), Site: "shop", StatusClass: "5xx"}
+	d.Observe([]LogEntry{late, late, late, late, late, late}, at(3).Add(anomalyGrace))
+	if started := observeInterval(d, at(3), steady); len(started) != 0 {
+		t.Errorf("late entries flagged: %+v", started)
+	}
+
+	// A second spike is listed first
+	observeInterval(d, at(4), spike)
+	if events := d.Events(); len(events) != 2 || !events[0].Since.Equal(at(4)) {
+		t.Errorf("events = %+v, want the newest first", events)
+	}
+
+	// Restart ends what is ongoing and forgets the baselines
+	d.Restart(at(5))
+	if len(d.Ongoing()) != 0 || len(d.Events()) != 2 || !d.Events()[0].Until.Equal(at(5)) {
+		t.Errorf("after restart: events %+v", d.Events())
+	}
+	if watched, _ := d.Sites(); watched != 0 {
+		t.Errorf("%d sites kept after restart", watched)
+	}
+}
+
+func TestAnomalyDetectorHistory(t *testing.T) {
+	base := time.Unix(1760097600, 0)
+
+	// Intervals that closed before the detector started only teach it
+	d := warmAnomalyDetector(base, 60)
+	d.started = base.Add(time.Hour)
+	if started := observeInterval(d, base, anomalyTraffic{20, 10, 100 * time.Millisecond}); len(started) != 0 {
+		t.Errorf("interval from before the start flagged: %+v", started)
+	}
+
+	// A baseline left too far behind is learned again rather than caught up
+	d = warmAnomalyDetector(base, 60)
+	later := base.Add(anomalyMaxGap + time.Hour)
+	if started := observeInterval(d, later, anomalyTraffic{20, 10, 100 * time.Millisecond}); len(started) != 0 {
+		t.Errorf("stale baseline flagged: %+v", started)
+	}
+	if watched, ready := d.Sites(); watched != 1 || ready != 0 {
+		t.Errorf("sites = %d watched, %d ready; want the baseline learning again", watched, ready)
+	}
+
+	var none *AnomalyDetector
+	if none.Observe([]LogEntry{{Timestamp: base}}, base) != nil || none.Events() != nil {
+		t.Error("nil detector reports anomalies")
+	}
+}
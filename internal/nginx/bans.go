@@ -0,0 +1,435 @@
+package nginx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aitmiloud/ngxtui/internal/config"
+)
+
+// Ban is a client denied access for tripping a rule
+type Ban struct {
+	IP      string    `json:"ip"`
+	Rule    string    `json:"rule"`
+	Reason  string    `json:"reason"` // e.g. "31 hits in 1m"
+	Since   time.Time `json:"since"`
+	Expires time.Time `json:"expires"`
+}
+
+// banRule is a compiled config.BanRule
+type banRule struct {
+	name    string
+	query   *LogQuery      // Access log rules
+	message *regexp.Regexp // Error log rules
+	maxHits int
+	window  time.Duration
+	ttl     time.Duration
+}
+
+// Banner counts rule hits per client in the tailed logs and keeps the list of
+// active bans. It holds state only; ApplyBans writes the bans out to NGINX.
+type Banner struct {
+	enabled bool
+	rules   []banRule
+	ignore  []*net.IPNet
+	hits    []map[string][]time.Time // Per rule, recent hit times by client
+	bans    map[string]Ban           // By client address
+	applied map[string]bool          // Clients NGINX denies, by address
+}
+
+// NewBanner compiles the rules and loads the bans still active from the state file
+func NewBanner(cfg config.BanConfig) (*Banner, error) {
+	b := &Banner{enabled: cfg.Enabled, bans: make(map[string]Ban), applied: make(map[string]bool)}
+
+	defaultTTL := time.Duration(cfg.BanMinutes) * time.Minute
+	if defaultTTL <= 0 {
+		defaultTTL = time.Hour
+	}
+	for i, rc := range cfg.Rules {
+		rule := banRule{
+			name:    rc.Name,
+			maxHits: rc.MaxHits,
+			window:  time.Duration(rc.WindowSeconds) * time.Second,
+			ttl:     time.Duration(rc.BanMinutes) * time.Minute,
+		}
+		if rule.name == "" {
+			rule.name = fmt.Sprintf("rule %d", i+1)
+		}
+		if rule.window <= 0 {
+			rule.window = time.Minute
+		}
+		if rule.ttl <= 0 {
+			rule.ttl = defaultTTL
+		}
+		if rc.Filter == "" {
+			return nil, fmt.Errorf("ban rule %q has no filter", rule.name)
+		}
+
+		var err error
+		switch rc.Log {
+		case "", "access":
+			rule.query, err = ParseLogQuery(rc.Filter)
+		case "error":
+			rule.message, err = regexp.Compile("(?i)" + rc.Filter)
+		default:
+			err = fmt.Errorf("unknown log %q (use access or error)", rc.Log)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ban rule %q: %w", rule.name, err)
+		}
+		b.rules = append(b.rules, rule)
+		b.hits = append(b.hits, make(map[string][]time.Time))
+	}
+
+	for _, entry := range cfg.Ignore {
+		network, err := parseNetwork(entry)
+		if err != nil {
+			return nil, fmt.Errorf("ban ignore list: %w", err)
+		}
+		b.ignore = append(b.ignore, network)
+	}
+
+	bans, err := loadBans(cfg.StateFile)
+	if err != nil {
+		return nil, err
+	}
+	// Only bans NGINX accepted are saved
+	for _, ban := range bans {
+		b.bans[ban.IP] = ban
+		b.applied[ban.IP] = true
+	}
+	return b, nil
+}
+
+// parseNetwork parses a CIDR range, or a single address as a range of one
+func parseNetwork(s string) (*net.IPNet, error) {
+	if strings.Contains(s, "/") {
+		_, network, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q", s)
+		}
+		return network, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid address %q", s)
+	}
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// Enabled reports whether rules ban clients automatically
+func (b *Banner) Enabled() bool {
+	return b != nil && b.enabled && len(b.rules) > 0
+}
+
+// Observe counts tailed entries against the rules and returns the clients
+// banned as a result. Entries logged before a rule's window are ignored, so
+// the backlog read when tailing starts doesn't ban anyone for old requests.
+func (b *Banner) Observe(entries []LogEntry, errors []ErrorLogEntry, now time.Time) []Ban {
+	if !b.Enabled() {
+		return nil
+	}
+	var banned []Ban
+	for i, rule := range b.rules {
+		if rule.message != nil {
+			for _, e := range errors {
+				if rule.message.MatchString(e.Message) {
+					banned = b.hit(i, e.Client, e.Timestamp, now, banned)
+				}
+			}
+			continue
+		}
+		for _, e := range entries {
+			if e.ParseErr == nil && rule.query.Match(e) {
+				banned = b.hit(i, e.IP, e.Timestamp, now, banned)
+			}
+		}
+	}
+	return banned
+}
+
+// hit records a rule hit by a client, banning it once it has too many
+func (b *Banner) hit(i int, addr string, at, now time.Time, banned []Ban) []Ban {
+	rule := b.rules[i]
+	ip := net.ParseIP(addr)
+	if ip == nil || at.Before(now.Add(-rule.window)) || b.ignored(ip) {
+		return banned
+	}
+	addr = ip.String()
+	if _, ok := b.bans[addr]; ok {
+		return banned
+	}
+
+	times := append(recentHits(b.hits[i][addr], now.Add(-rule.window)), at)
+	if len(times) <= rule.maxHits {
+		b.hits[i][addr] = times
+		return banned
+	}
+	delete(b.hits[i], addr)
+
+	reason := fmt.Sprintf("%d hits in %s", len(times), banWindow(rule.window))
+	if len(times) == 1 {
+		reason = "matched once"
+	}
+	ban := Ban{
+		IP:      addr,
+		Rule:    rule.name,
+		Reason:  reason,
+		Since:   now,
+		Expires: now.Add(rule.ttl),
+	}
+	b.bans[addr] = ban
+	return append(banned, ban)
+}
+
+// banWindow formats a rule window as "90s", "5m" or "1h"
+func banWindow(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return fmt.Sprintf("%ds", d/time.Second)
+}
+
+// recentHits drops the hit times before since
+func recentHits(times []time.Time, since time.Time) []time.Time {
+	kept := times[:0]
+	for _, t := range times {
+		if !t.Before(since) {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// ignored reports whether an address is on the ignore list
+func (b *Banner) ignored(ip net.IP) bool {
+	for _, network := range b.ignore {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Expire lifts the bans past their expiry, forgets stale hits and returns
+// the lifted bans
+func (b *Banner) Expire(now time.Time) []Ban {
+	if b == nil {
+		return nil
+	}
+	var expired []Ban
+	for addr, ban := range b.bans {
+		if !now.Before(ban.Expires) {
+			expired = append(expired, ban)
+			delete(b.bans, addr)
+		}
+	}
+	for i, rule := range b.rules {
+		for addr, times := range b.hits[i] {
+			if times = recentHits(times, now.Add(-rule.window)); len(times) == 0 {
+				delete(b.hits[i], addr)
+			} else {
+				b.hits[i][addr] = times
+			}
+		}
+	}
+	return expired
+}
+
+// Unban lifts a client's ban and reports whether it was banned
+func (b *Banner) Unban(addr string) bool {
+	if b == nil {
+		return false
+	}
+	if _, ok := b.bans[addr]; !ok {
+		return false
+	}
+	delete(b.bans, addr)
+	return true
+}
+
+// MarkApplied records the bans NGINX now denies, after ApplyBans succeeded
+func (b *Banner) MarkApplied(bans []Ban) {
+	if b == nil {
+		return
+	}
+	b.applied = make(map[string]bool, len(bans))
+	for _, ban := range bans {
+		b.applied[ban.IP] = true
+	}
+}
+
+// Applied reports whether NGINX denies a banned client yet
+func (b *Banner) Applied(addr string) bool {
+	return b != nil && b.applied[addr]
+}
+
+// Active returns the active bans, soonest to expire first
+func (b *Banner) Active() []Ban {
+	if b == nil {
+		return nil
+	}
+	bans := make([]Ban, 0, len(b.bans))
+	for _, ban := range b.bans {
+		bans = append(bans, ban)
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		if !bans[i].Expires.Equal(bans[j].Expires) {
+			return bans[i].Expires.Before(bans[j].Expires)
+		}
+		return bans[i].IP < bans[j].IP
+	})
+	return bans
+}
+
+// ApplyBans writes the bans as deny rules to the managed include file, then
+// tests and reloads NGINX, and saves them once NGINX took them
+func (s *Service) ApplyBans(cfg config.BanConfig, bans []Ban) error {
+	if err := requireNativeNginx("IP banning"); err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Managed by ngxtui - clients banned by its ban rules.\n")
+	sb.WriteString("# Changes are overwritten; lift bans from the Bans tab.\n")
+	for _, ban := range bans {
+		fmt.Fprintf(&sb, "deny %s; # %s, until %s\n", ban.IP, ban.Rule, ban.Expires.Format(time.RFC3339))
+	}
+	if err := s.applyManagedInclude(cfg.IncludePath, sb.String()); err != nil {
+		return fmt.Errorf("bans were not applied: %w", err)
+	}
+	return saveBans(cfg.StateFile, bans)
+}
+
+// applyManagedInclude writes a file NgxTUI manages, adds an include of it to
+// the http block unless the configuration already loads it, then tests and
+// reloads NGINX. Every file is put back if the test or the reload fails, so
+// what is on disk is what NGINX runs.
+func (s *Service) applyManagedInclude(path, content string) error {
+	restores := []func(){snapshotFile(path)}
+	if err := writeFileAtomic(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	restore, err := s.includeInHTTP(path)
+	if restore != nil {
+		restores = append(restores, restore)
+	}
+	if err == nil {
+		err = s.TestConfig()
+	}
+	if err == nil {
+		err = s.Reload()
+	}
+	if err != nil {
+		for _, restore := range restores {
+			restore()
+		}
+		return err
+	}
+	return nil
+}
+
+// includeInHTTP adds an include of path to the http block unless the
+// configuration already loads it, and returns a function undoing the edit
+func (s *Service) includeInHTTP(path string) (func(), error) {
+	if err := s.parseConfig(); err != nil {
+		return nil, err
+	}
+	for _, file := range s.payload.Config {
+		if file.File == path {
+			return nil, nil
+		}
+	}
+	if len(s.payload.Config) == 0 {
+		return nil, fmt.Errorf("empty nginx configuration")
+	}
+
+	root := s.payload.Config[0]
+	for _, d := range s.expandIncludes(root.Parsed, root.File) {
+		if d.Directive != "http" {
+			continue
+		}
+		data, err := os.ReadFile(d.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", d.File, err)
+		}
+		lines := strings.Split(string(data), "\n")
+		idx := d.Line - 1
+		if idx < 0 || idx >= len(lines) || !strings.HasSuffix(strings.TrimSpace(lines[idx]), "{") {
+			return nil, fmt.Errorf("add \"include %s;\" to the http block of %s", path, d.File)
+		}
+		indent := lines[idx][:len(lines[idx])-len(strings.TrimLeft(lines[idx], " \t"))]
+		lines = append(lines[:idx+1], append([]string{indent + "    include " + path + ";"}, lines[idx+1:]...)...)
+
+		info, err := os.Stat(d.File)
+		if err != nil {
+			return nil, err
+		}
+		restore := snapshotFile(d.File)
+		if err := writeFileAtomic(d.File, []byte(strings.Join(lines, "\n")), info.Mode().Perm()); err != nil {
+			return nil, fmt.Errorf("failed to update %s: %w", d.File, err)
+		}
+		return restore, nil
+	}
+	return nil, fmt.Errorf("no http block found in %s", root.File)
+}
+
+// snapshotFile returns a function putting a file back as it is now, or
+// removing it if it doesn't exist yet
+func snapshotFile(path string) func() {
+	info, err := os.Stat(path)
+	if err != nil {
+		return func() { os.Remove(path) }
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return func() {}
+	}
+	return func() { writeFileAtomic(path, data, info.Mode().Perm()) }
+}
+
+// loadBans reads the saved bans; those that expired while NgxTUI wasn't
+// running are lifted by the next Expire
+func loadBans(path string) ([]Ban, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read bans: %w", err)
+	}
+	var bans []Ban
+	if err := json.Unmarshal(data, &bans); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return bans, nil
+}
+
+// saveBans writes the active bans to the state file
+func saveBans(path string, bans []Ban) error {
+	if bans == nil {
+		bans = []Ban{}
+	}
+	data, err := json.MarshalIndent(bans, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
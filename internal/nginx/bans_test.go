@@ -0,0 +1,386 @@
+package nginx
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aitmiloud/ngxtui/internal/config"
+)
+
+// newTestBanner creates a banner with one access log rule and no saved bans
+func newTestBanner(t *testing.T, rule config.BanRule, ignore ...string) *Banner {
+	t.Helper()
+	b, err := NewBanner(config.BanConfig{
+		Enabled:   true,
+		StateFile: filepath.Join(t.TempDir(), "bans.json"),
+		Ignore:    ignore,
+		Rules:     []config.BanRule{rule},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestBannerHit(t *testing.T) {
+	base := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+	type hit struct {
+		addr    string
+		at, now int // Seconds after base; now is at when zero
+	}
+	tests := []struct {
+		name       string
+		maxHits    int
+		ignore     []string
+		hits       []hit
+		want       []string // Banned addresses, in order
+		wantReason string
+	}{
+		{
+			name:    "over the threshold",
+			maxHits: 3,
+			hits:    []hit{{"10.0.0.1", 0, 0}, {"10.0.0.1", 10, 0}, {"10.0.0.1", 20, 0}, {"10.0.0.1", 30, 0}},
+			want:    []string{"10.0.0.1"}, wantReason: "4 hits in 1m",
+		},
+		{
+			name:    "at the threshold",
+			maxHits: 3,
+			hits:    []hit{{"10.0.0.1", 0, 0}, {"10.0.0.1", 10, 0}, {"10.0.0.1", 20, 0}},
+		},
+		{
+			name:    "clients counted apart",
+			maxHits: 2,
+			hits:    []hit{{"10.0.0.1", 0, 0}, {"10.0.0.2", 1, 0}, {"10.0.0.1", 2, 0}, {"10.0.0.2", 3, 0}, {"10.0.0.2", 4, 0}},
+			want:    []string{"10.0.0.2"},
+		},
+		{
+			// The first hit has left the window by the fourth
+			name:    "hits leave the window",
+			maxHits: 3,
+			hits:    []hit{{"10.0.0.1", 0, 0}, {"10.0.0.1", 30, 0}, {"10.0.0.1", 61, 0}, {"10.0.0.1", 90, 0}},
+		},
+		{
+			// Backlog lines read when tailing starts
+			name:    "hits from before the window",
+			maxHits: 0,
+			hits:    []hit{{"10.0.0.1", 0, 61}},
+		},
+		{
+			name:    "ignored network",
+			maxHits: 0,
+			ignore:  []string{"10.0.0.0/8", "2001:db8::1"},
+			hits:    []hit{{"10.1.2.3", 0, 0}, {"2001:db8::1", 0, 0}, {"192.168.1.1", 0, 0}},
+			want:    []string{"192.168.1.1"},
+		},
+		{
+			name:    "matched once",
+			maxHits: 0,
+			hits:    []hit{{"10.0.0.1", 0, 0}, {"10.0.0.1", 1, 0}},
+			want:    []string{"10.0.0.1"}, wantReason: "matched once",
+		},
+		{
+			// Spellings of one address count as one client
+			name:    "IPv6 normalised",
+			maxHits: 1,
+			hits:    []hit{{"2001:DB8:0::0:1", 0, 0}, {"2001:db8::1", 1, 0}, {"::ffff:10.0.0.9", 2, 0}, {"10.0.0.9", 3, 0}},
+			want:    []string{"2001:db8::1", "10.0.0.9"},
+		},
+		{
+			name:    "not an address",
+			maxHits: 0,
+			hits:    []hit{{"-", 0, 0}, {"", 0, 0}, {"example.com", 0, 0}},
+		},
+	}
+	for _, tt := range tests {
+		b := newTestBanner(t, config.BanRule{Name: "probe", Filter: "status:4xx", MaxHits: tt.maxHits, WindowSeconds: 60}, tt.ignore...)
+		var banned []Ban
+		for _, h := range tt.hits {
+			now := h.now
+			if now == 0 {
+				now = h.at
+			}
+			banned = b.hit(0, h.addr, base.Add(time.Duration(h.at)*time.Second), base.Add(time.Duration(now)*time.Second), banned)
+		}
+		var got []string
+		for _, ban := range banned {
+			got = append(got, ban.IP)
+		}
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("%s: banned %q, want %q", tt.name, got, tt.want)
+			continue
+		}
+		if tt.wantReason != "" && banned[0].Reason != tt.wantReason {
+			t.Errorf("%s: reason %q, want %q", tt.name, banned[0].Reason, tt.wantReason)
+		}
+		for _, ban := range banned {
+			if ban.Rule != "probe" || ban.Expires.Sub(ban.Since) != time.Hour {
+				t.Errorf("%s: ban %+v", tt.name, ban)
+			}
+		}
+	}
+}
+
+func TestBannerObserve(t *testing.T) {
+	now := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+	b, err := NewBanner(config.BanConfig{
+		Enabled:    true,
+		StateFile:  filepath.Join(t.TempDir(), "bans.json"),
+		BanMinutes: 30,
+		Rules: []config.BanRule{
+			{Filter: "status:404 path:/wp-*", MaxHits: 1},
+			{Name: "ssl", Log: "error", Filter: "ssl_do_handshake", BanMinutes: 5},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []LogEntry{
+		{IP: "10.0.0.1", Timestamp: now, StatusCode: 404, Path: "/wp-login.php"},
+		{IP: "10.0.0.1", Timestamp: now, StatusCode: 404, Path: "/wp-admin/"},
+		{IP: "10.0.0.2", Timestamp: now, StatusCode: 404, Path: "/favicon.ico"},
+		{IP: "10.0.0.2", Timestamp: now, StatusCode: 404, Path: "/favicon.ico"},
+		{IP: "10.0.0.3", Timestamp: now, Raw: "garbage", ParseErr: os.ErrInvalid},
+	}
+	errors := []ErrorLogEntry{{Timestamp: now, Client: "10.0.0.4", Message: "SSL_do_handshake() failed"}}
+	banned := b.Observe(entries, errors, now)
+	if len(banned) != 2 || banned[0].IP != "10.0.0.1" || banned[1].IP != "10.0.0.4" {
+		t.Fatalf("banned %+v, want 10.0.0.1 and 10.0.0.4", banned)
+	}
+	if banned[0].Rule != "rule 1" || banned[0].Expires != now.Add(30*time.Minute) || banned[1].Expires != now.Add(5*time.Minute) {
+		t.Errorf("rule names or lengths: %+v", banned)
+	}
+	if b.Applied("10.0.0.1") {
+		t.Error("ban applied before NGINX took it")
+	}
+	b.MarkApplied(banned)
+	if !b.Applied("10.0.0.1") || len(b.Active()) != 2 {
+		t.Errorf("active %+v", b.Active())
+	}
+
+	// The SSL ban runs out first
+	if expired := b.Expire(now.Add(10 * time.Minute)); len(expired) != 1 || expired[0].IP != "10.0.0.4" {
+		t.Errorf("expired %+v, want 10.0.0.4", expired)
+	}
+	if !b.Unban("10.0.0.1") || b.Unban("10.0.0.1") || len(b.Active()) != 0 {
+		t.Error("unban didn't lift the ban once")
+	}
+
+	disabled, err := NewBanner(config.BanConfig{Rules: []config.BanRule{{Filter: "status:404"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if disabled.Enabled() || disabled.Observe(entries, nil, now) != nil {
+		t.Error("disabled banner bans clients")
+	}
+}
+
+func TestBannerExpireForgetsHits(t *testing.T) {
+	now := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+	b := newTestBanner(t, config.BanRule{Filter: "status:4xx", MaxHits: 1, WindowSeconds: 60})
+	b.hit(0, "10.0.0.1", now, now, nil)
+	b.Expire(now.Add(2 * time.Minute))
+	if len(b.hits[0]) != 0 {
+		t.Errorf("stale hits kept: %v", b.hits[0])
+	}
+	// The old hit no longer counts towards a ban
+	if banned := b.hit(0, "10.0.0.1", now.Add(2*time.Minute), now.Add(2*time.Minute), nil); len(banned) != 0 {
+		t.Errorf("banned on a forgotten hit: %+v", banned)
+	}
+}
+
+func TestNewBanner(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "bans.json")
+	saved := []Ban{{IP: "10.0.0.1", Rule: "probe", Expires: time.Now().Add(time.Hour)}}
+	data, _ := json.Marshal(saved)
+	if err := os.WriteFile(state, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewBanner(config.BanConfig{StateFile: state})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only bans NGINX took are saved, so loaded ones are applied
+	if len(b.Active()) != 1 || !b.Applied("10.0.0.1") {
+		t.Errorf("saved bans not loaded: %+v", b.Active())
+	}
+
+	for name, cfg := range map[string]config.BanConfig{
+		"no filter":    {Rules: []config.BanRule{{Name: "x"}}},
+		"bad query":    {Rules: []config.BanRule{{Filter: "status:abc"}}},
+		"bad regexp":   {Rules: []config.BanRule{{Log: "error", Filter: "("}}},
+		"unknown log":  {Rules: []config.BanRule{{Log: "syslog", Filter: "x"}}},
+		"bad ignore":   {Ignore: []string{"10.0.0"}},
+		"bad network":  {Ignore: []string{"10.0.0.0/33"}},
+		"broken state": {StateFile: writeTempFile(t, "bans.json", "{")},
+	} {
+		if _, err := NewBanner(cfg); err == nil {
+			t.Errorf("%s: created a banner, want an error", name)
+		}
+	}
+}
+
+// writeTempFile writes a file in a temporary directory and returns its path
+func writeTempFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIncludeInHTTP(t *testing.T) {
+	if err := requireNativeNginx("Editing nginx.conf"); err != nil {
+		t.Skip(err)
+	}
+	defer func(path string) { nginxConfPath = path }(nginxConfPath)
+
+	tests := []struct {
+		name    string
+		files   map[string]string // By name in the configuration directory
+		edited  string            // File the include is added to
+		want    string            // Its content afterwards
+		wantErr string
+	}{
+		{
+			name: "http block",
+			files: map[string]string{"nginx.conf": "events {}\n" +
+				"http {\n" +
+				"    server { listen 80; }\n" +
+				"}\n"},
+			edited: "nginx.conf",
+			want: "events {}\n" +
+				"http {\n" +
+				"    include {dir}/managed.conf;\n" +
+				"    server { listen 80; }\n" +
+				"}\n",
+		},
+		{
+			name: "http block in an included file",
+			files: map[string]string{
+				"nginx.conf": "events {}\ninclude {dir}/http.conf;\n",
+				"http.conf":  "\thttp {\n\t}\n",
+			},
+			edited: "http.conf",
+			want:   "\thttp {\n\t    include {dir}/managed.conf;\n\t}\n",
+		},
+		{
+			name: "already included",
+			files: map[string]string{"nginx.conf": "events {}\n" +
+				"http {\n" +
+				"    include {dir}/managed.conf;\n" +
+				"}\n"},
+			edited: "nginx.conf",
+			want: "events {}\n" +
+				"http {\n" +
+				"    include {dir}/managed.conf;\n" +
+				"}\n",
+		},
+		{
+			name:    "no http block",
+			files:   map[string]string{"nginx.conf": "events {}\nstream {\n}\n"},
+			wantErr: "no http block",
+		},
+		{
+			// Only a brace on the http line is edited
+			name:    "brace on its own line",
+			files:   map[string]string{"nginx.conf": "events {}\nhttp\n{\n}\n"},
+			wantErr: "add \"include",
+		},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		expand := func(s string) string { return strings.ReplaceAll(s, "{dir}", dir) }
+		for name, content := range tt.files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(expand(content)), 0o640); err != nil {
+				t.Fatal(err)
+			}
+		}
+		managed := filepath.Join(dir, "managed.conf")
+		if err := os.WriteFile(managed, []byte("deny 10.0.0.1;\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		nginxConfPath = filepath.Join(dir, "nginx.conf")
+
+		restore, err := New().includeInHTTP(managed)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		edited := filepath.Join(dir, tt.edited)
+		data, _ := os.ReadFile(edited)
+		if string(data) != expand(tt.want) {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, data, expand(tt.want))
+		}
+		if info, err := os.Stat(edited); err != nil || info.Mode().Perm() != 0o640 {
+			t.Errorf("%s: mode not kept: %v", tt.name, info.Mode())
+		}
+
+		if restore == nil {
+			continue
+		}
+		// The edited configuration loads the include, so it isn't added twice
+		if again, err := New().includeInHTTP(managed); err != nil || again != nil {
+			t.Errorf("%s: second include: %v", tt.name, err)
+		}
+		restore()
+		if data, _ := os.ReadFile(edited); string(data) != expand(tt.files[tt.edited]) {
+			t.Errorf("%s: not restored:\n%s", tt.name, data)
+		}
+	}
+}
+
+func TestApplyBans(t *testing.T) {
+	dir := t.TempDir()
+	conf := writeTempFile(t, "nginx.conf", "events {}\nhttp {\n}\n")
+	defer func(path string) { nginxConfPath = path }(nginxConfPath)
+	nginxConfPath = conf
+	cfg := config.BanConfig{IncludePath: filepath.Join(dir, "bans.conf"), StateFile: filepath.Join(dir, "bans.json")}
+	bans := []Ban{{IP: "10.0.0.1", Rule: "probe", Expires: time.Now().Add(time.Hour)}}
+
+	// A failed reload leaves nothing behind that NGINX didn't load
+	fakeNginx(t, 0, 1)
+	if err := New().ApplyBans(cfg, bans); err == nil {
+		t.Fatal("applied with a failing reload")
+	}
+	if data, _ := os.ReadFile(conf); string(data) != "events {}\nhttp {\n}\n" {
+		t.Errorf("nginx.conf not restored:\n%s", data)
+	}
+	for _, path := range []string{cfg.IncludePath, cfg.StateFile} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s left behind", path)
+		}
+	}
+
+	// Applying the same bans again reloads rather than trusting the file
+	calls := fakeNginx(t, 0, 0)
+	for range 2 {
+		if err := New().ApplyBans(cfg, bans); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := strings.Join(calls(), "; "); got != "nginx -t; systemctl reload nginx; nginx -t; systemctl reload nginx" {
+		t.Errorf("ran %q, want a test and reload per apply", got)
+	}
+	if data, _ := os.ReadFile(cfg.IncludePath); !strings.Contains(string(data), "deny 10.0.0.1;") {
+		t.Errorf("include:\n%s", data)
+	}
+	if data, _ := os.ReadFile(conf); strings.Count(string(data), "include "+cfg.IncludePath+";") != 1 {
+		t.Errorf("nginx.conf:\n%s", data)
+	}
+	if saved, err := loadBans(cfg.StateFile); err != nil || len(saved) != 1 {
+		t.Errorf("saved bans %+v, %v", saved, err)
+	}
+}
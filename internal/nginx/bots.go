@@ -0,0 +1,173 @@
+package nginx
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ClientKind is what sent a request, judged from its user agent
+type ClientKind int
+
+const (
+	HumanClient   ClientKind = iota // A browser
+	SearchCrawler                   // Search engines, SEO tools and link previews
+	AICrawler                       // AI training crawlers and assistants
+	MonitorProbe                    // Uptime and health checks
+	ScriptClient                    // curl, HTTP libraries, scanners and headless browsers
+	OtherBot                        // Any other self-declared robot
+	UnknownClient                   // No user agent, or one that names no browser
+)
+
+// ClientKinds lists every kind in display order
+var ClientKinds = []ClientKind{HumanClient, SearchCrawler, AICrawler, MonitorProbe, ScriptClient, OtherBot, UnknownClient}
+
+// String returns the kind's name, as used in signature files and queries
+func (k ClientKind) String() string {
+	switch k {
+	case HumanClient:
+		return "human"
+	case SearchCrawler:
+		return "search"
+	case AICrawler:
+		return "ai"
+	case MonitorProbe:
+		return "monitor"
+	case ScriptClient:
+		return "script"
+	case OtherBot:
+		return "other"
+	}
+	return "unknown"
+}
+
+// IsBot reports whether the kind is an automated client
+func (k ClientKind) IsBot() bool {
+	return k != HumanClient && k != UnknownClient
+}
+
+// parseClientKind parses a kind name
+func parseClientKind(name string) (ClientKind, bool) {
+	for _, k := range ClientKinds {
+		if strings.EqualFold(k.String(), name) {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+//go:embed bots.txt
+var bundledBotSignatures string
+
+// botSignature maps a lower-cased user agent substring to a kind
+type botSignature struct {
+	kind    ClientKind
+	pattern string
+}
+
+var (
+	botMu         sync.RWMutex
+	botSignatures = mustParseBotSignatures(bundledBotSignatures)
+	botKindCache  = make(map[string]ClientKind)
+)
+
+// botKindCacheSize bounds the classification cache; logs hold few distinct
+// user agents, so it is simply emptied when full
+const botKindCacheSize = 10000
+
+// LoadBotSignatures adds the signatures in the file at path ahead of the
+// bundled ones. An empty path keeps the bundled list only.
+func LoadBotSignatures(path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read bot signatures: %w", err)
+	}
+	extra, err := parseBotSignatures(string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	botMu.Lock()
+	defer botMu.Unlock()
+	botSignatures = append(extra, mustParseBotSignatures(bundledBotSignatures)...)
+	botKindCache = make(map[string]ClientKind)
+	return nil
+}
+
+// parseBotSignatures parses "<kind> <substring>" lines, skipping blank lines and comments
+func parseBotSignatures(data string) ([]botSignature, error) {
+	var signatures []botSignature
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		split := strings.IndexAny(line, " \t")
+		if split < 0 {
+			return nil, fmt.Errorf("line %d: want \"<kind> <substring>\"", i+1)
+		}
+		name, pattern := line[:split], strings.TrimSpace(line[split:])
+		if pattern == "" {
+			return nil, fmt.Errorf("line %d: want \"<kind> <substring>\"", i+1)
+		}
+		kind, ok := parseClientKind(name)
+		if !ok || !kind.IsBot() {
+			return nil, fmt.Errorf("line %d: unknown kind %q", i+1, name)
+		}
+		signatures = append(signatures, botSignature{kind: kind, pattern: strings.ToLower(pattern)})
+	}
+	return signatures, nil
+}
+
+func mustParseBotSignatures(data string) []botSignature {
+	signatures, err := parseBotSignatures(data)
+	if err != nil {
+		panic("bundled bot signatures: " + err.Error())
+	}
+	return signatures
+}
+
+// ClassifyUserAgent returns the kind of client that sent a user agent
+func ClassifyUserAgent(userAgent string) ClientKind {
+	botMu.RLock()
+	kind, ok := botKindCache[userAgent]
+	botMu.RUnlock()
+	if ok {
+		return kind
+	}
+
+	kind = classifyUserAgent(userAgent)
+	botMu.Lock()
+	if len(botKindCache) >= botKindCacheSize {
+		botKindCache = make(map[string]ClientKind)
+	}
+	botKindCache[userAgent] = kind
+	botMu.Unlock()
+	return kind
+}
+
+func classifyUserAgent(userAgent string) ClientKind {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" || ua == "-" {
+		return UnknownClient
+	}
+
+	botMu.RLock()
+	defer botMu.RUnlock()
+	for _, sig := range botSignatures {
+		if strings.Contains(ua, sig.pattern) {
+			return sig.kind
+		}
+	}
+
+	// Every mainstream browser still starts with Mozilla/
+	if strings.HasPrefix(ua, "mozilla/") || strings.HasPrefix(ua, "opera/") {
+		return HumanClient
+	}
+	return UnknownClient
+}
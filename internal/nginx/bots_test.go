@@ -0,0 +1,131 @@
+package nginx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClassifyUserAgent(t *testing.T) {
+	tests := []struct {
+		ua   string
+		want ClientKind
+	}{
+		{firefoxUA, HumanClient},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1", HumanClient},
+		{"Opera/9.80 (Windows NT 6.1) Presto/2.12.388 Version/12.18", HumanClient},
+		{googlebotUA, SearchCrawler},
+		{"Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)", SearchCrawler},
+		{"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", SearchCrawler},
+		{"Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; GPTBot/1.2; +https://openai.com/gptbot)", AICrawler},
+		{"Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; ClaudeBot/1.0; +claudebot@anthropic.com)", AICrawler},
+		// Specific names are listed before the generic ones they contain
+		{"Mozilla/5.0 (compatible; Applebot-Extended/0.1)", AICrawler},
+		{"Mozilla/5.0 (Macintosh) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15 (Applebot/0.1)", SearchCrawler},
+		{"Mozilla/5.0+(compatible; UptimeRobot/2.0; http://www.uptimerobot.com/)", MonitorProbe},
+		{"kube-probe/1.30", MonitorProbe},
+		{"ELB-HealthChecker/2.0", MonitorProbe},
+		{"curl/8.5.0", ScriptClient},
+		{"python-requests/2.32.3", ScriptClient},
+		{"Go-http-client/1.1", ScriptClient},
+		{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/126.0.0.0 Safari/537.36", ScriptClient},
+		{"Mozilla/5.0 (compatible; SomeNewBot/1.0)", OtherBot},
+		{"my-spider 0.1", OtherBot},
+		{"", UnknownClient},
+		{"-", UnknownClient},
+		{"  ", UnknownClient},
+		{"Winamp/5.0", UnknownClient},
+	}
+	for _, tt := range tests {
+		// Twice, so the cached answer is checked too
+		for range 2 {
+			if got := ClassifyUserAgent(tt.ua); got != tt.want {
+				t.Errorf("ClassifyUserAgent(%q) = %s, want %s", tt.ua, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestClientKind(t *testing.T) {
+	for _, k := range ClientKinds {
+		got, ok := parseClientKind(k.String())
+		if !ok || got != k {
+			t.Errorf("parseClientKind(%q) = %s, %v", k.String(), got, ok)
+		}
+	}
+	if k, ok := parseClientKind("AI"); !ok || k != AICrawler {
+		t.Errorf("kind names aren't case-insensitive: %s, %v", k, ok)
+	}
+	if HumanClient.IsBot() || UnknownClient.IsBot() || !SearchCrawler.IsBot() || !OtherBot.IsBot() {
+		t.Error("IsBot misclassifies a kind")
+	}
+}
+
+func TestParseBotSignatures(t *testing.T) {
+	signatures, err := parseBotSignatures("# comment\n\nai  Example-AI\nmonitor\tMyProbe\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []botSignature{{AICrawler, "example-ai"}, {MonitorProbe, "myprobe"}}
+	if len(signatures) != len(want) || signatures[0] != want[0] || signatures[1] != want[1] {
+		t.Errorf("got %+v, want %+v", signatures, want)
+	}
+
+	for _, data := range []string{
+		"ai",
+		"ai   ",
+		"robots Example",
+		"human Firefox",
+		"unknown Thing",
+	} {
+		if _, err := parseBotSignatures(data); err == nil {
+			t.Errorf("parseBotSignatures(%q) succeeded, want an error", data)
+		}
+	}
+}
+
+func TestLoadBotSignatures(t *testing.T) {
+	dir := t.TempDir()
+	extra := filepath.Join(dir, "bots.txt")
+	if err := os.WriteFile(extra, []byte("monitor curl/\nother AcmeFetch\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bundled := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(bundled, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer LoadBotSignatures(bundled)
+
+	// Warm the cache so loading has to empty it
+	if ClassifyUserAgent("curl/8.5.0") != ScriptClient {
+		t.Fatal("curl isn't a script")
+	}
+	if err := LoadBotSignatures(extra); err != nil {
+		t.Fatal(err)
+	}
+	// Loaded lines are checked before the bundled ones
+	if got := ClassifyUserAgent("curl/8.5.0"); got != MonitorProbe {
+		t.Errorf("curl = %s, want monitor", got)
+	}
+	if got := ClassifyUserAgent("AcmeFetch/2"); got != OtherBot {
+		t.Errorf("AcmeFetch = %s, want other", got)
+	}
+	if got := ClassifyUserAgent(googlebotUA); got != SearchCrawler {
+		t.Errorf("bundled signatures lost: Googlebot = %s", got)
+	}
+
+	if err := LoadBotSignatures(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("loading a missing file succeeded")
+	}
+	if err := os.WriteFile(extra, []byte("robots Acme\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadBotSignatures(extra); err == nil {
+		t.Error("loading a bad file succeeded")
+	}
+	// A failed load keeps the signatures it had
+	if got := ClassifyUserAgent("curl/8.5.0"); got != MonitorProbe {
+		t.Errorf("failed load changed the signatures: curl = %s", got)
+	}
+}
@@ -0,0 +1,188 @@
+package nginx
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aitmiloud/ngxtui/internal/config"
+)
+
+// CertificateGenerator creates self-signed certificates and certificates
+// signed by a local CA that NgxTUI manages
+type CertificateGenerator struct {
+	cfg config.LocalCertConfig
+}
+
+// NewCertificateGenerator creates a certificate generator
+func NewCertificateGenerator(cfg config.LocalCertConfig) *CertificateGenerator {
+	return &CertificateGenerator{cfg: cfg}
+}
+
+// CACertPath is the local CA certificate clients need to trust
+func (g *CertificateGenerator) CACertPath() string {
+	return filepath.Join(g.cfg.CADir, "ca.crt")
+}
+
+// caKeyPath is the local CA private key
+func (g *CertificateGenerator) caKeyPath() string {
+	return filepath.Join(g.cfg.CADir, "ca.key")
+}
+
+// paths returns where the certificate and key for name are written
+func (g *CertificateGenerator) paths(name string) (string, string) {
+	dir := filepath.Join(g.cfg.CertsDir, name)
+	return filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+}
+
+// SelfSigned writes a self-signed certificate for the domains and returns its paths
+func (g *CertificateGenerator) SelfSigned(name string, domains []string) (string, string, error) {
+	if err := requireNativeNginx("certificate generation"); err != nil {
+		return "", "", err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	template, err := leafTemplate(domains, time.Duration(g.cfg.ValidDays)*24*time.Hour)
+	if err != nil {
+		return "", "", err
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create certificate: %w", err)
+	}
+
+	certPath, keyPath := g.paths(name)
+	if err := writeKeyPair(certPath, keyPath, [][]byte{der}, key); err != nil {
+		return "", "", err
+	}
+	return certPath, keyPath, nil
+}
+
+// SignWithCA writes a certificate for the domains signed by the local CA and
+// returns its paths. The certificate file includes the CA so the chain is complete.
+func (g *CertificateGenerator) SignWithCA(name string, domains []string) (string, string, error) {
+	if err := requireNativeNginx("certificate generation"); err != nil {
+		return "", "", err
+	}
+
+	ca, caKey, err := g.EnsureCA()
+	if err != nil {
+		return "", "", err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	template, err := leafTemplate(domains, time.Duration(g.cfg.ValidDays)*24*time.Hour)
+	if err != nil {
+		return "", "", err
+	}
+	if template.NotAfter.After(ca.NotAfter) {
+		template.NotAfter = ca.NotAfter
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, key.Public(), caKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign certificate: %w", err)
+	}
+
+	certPath, keyPath := g.paths(name)
+	if err := writeKeyPair(certPath, keyPath, [][]byte{der, ca.Raw}, key); err != nil {
+		return "", "", err
+	}
+	return certPath, keyPath, nil
+}
+
+// EnsureCA loads the local CA, creating it on first use
+func (g *CertificateGenerator) EnsureCA() (*x509.Certificate, crypto.Signer, error) {
+	if certPEM, err := os.ReadFile(g.CACertPath()); err == nil {
+		keyPEM, err := os.ReadFile(g.caKeyPath())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read CA key: %w", err)
+		}
+		chain, err := decodeCertificateChain(certPEM)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, err := parsePrivateKey(keyPEM)
+		if err != nil {
+			return nil, nil, err
+		}
+		return chain[0], key, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, big128)
+	if err != nil {
+		return nil, nil, err
+	}
+	hostname, _ := os.Hostname()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "NgxTUI Internal CA " + hostname, Organization: []string{"ngxtui"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+	if err := os.MkdirAll(g.cfg.CADir, 0700); err != nil {
+		return nil, nil, fmt.Errorf("failed to create CA directory: %w", err)
+	}
+	if err := writeKeyPair(g.CACertPath(), g.caKeyPath(), [][]byte{der}, key); err != nil {
+		return nil, nil, err
+	}
+
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ca, key, nil
+}
+
+// leafTemplate returns a server certificate template for the domains
+func leafTemplate(domains []string, validity time.Duration) (*x509.Certificate, error) {
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("at least one server name is required")
+	}
+	serial, err := rand.Int(rand.Reader, big128)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: domains[0]},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, domain := range domains {
+		if ip := net.ParseIP(domain); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, domain)
+		}
+	}
+	return template, nil
+}
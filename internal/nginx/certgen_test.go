@@ -0,0 +1,109 @@
+package nginx
+
+import (
+	"crypto/x509"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/aitmiloud/ngxtui/internal/config"
+)
+
+// newTestGenerator returns a generator writing under a temporary directory.
+// Docker is kept off the PATH, where generation isn't supported.
+func newTestGenerator(t *testing.T) *CertificateGenerator {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+	return NewCertificateGenerator(config.LocalCertConfig{
+		CertsDir:  filepath.Join(dir, "certs"),
+		CADir:     filepath.Join(dir, "ca"),
+		ValidDays: 90,
+	})
+}
+
+func TestSelfSigned(t *testing.T) {
+	g := newTestGenerator(t)
+	certPath, keyPath, err := g.SelfSigned("shop", []string{"shop.example", "www.shop.example", "192.0.2.10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert := Certificate{CertPath: certPath, KeyPath: keyPath, ServerNames: []string{"shop.example", "www.shop.example"}}
+	inspectCertificate(&cert)
+	if cert.Err != nil {
+		t.Fatal(cert.Err)
+	}
+	if !cert.KeyMatch || cert.KeyError != "" {
+		t.Errorf("key match %v, key error %q", cert.KeyMatch, cert.KeyError)
+	}
+	if want := []string{"shop.example", "www.shop.example", "192.0.2.10"}; !slices.Equal(cert.SANs, want) {
+		t.Errorf("SANs = %q, want %q", cert.SANs, want)
+	}
+	if cert.Subject != "shop.example" || cert.Issuer != "shop.example" || cert.KeyType != "ECDSA P-256" || cert.ChainLength != 1 {
+		t.Errorf("details = %+v", cert)
+	}
+	if cert.DaysLeft != 89 || len(cert.UncoveredNames) != 0 {
+		t.Errorf("days left %d, uncovered %q", cert.DaysLeft, cert.UncoveredNames)
+	}
+
+	if _, _, err := g.SelfSigned("empty", nil); err == nil {
+		t.Error("a certificate without names was generated")
+	}
+}
+
+func TestSignWithCA(t *testing.T) {
+	g := newTestGenerator(t)
+	certPath, keyPath, err := g.SignWithCA("shop", []string{"shop.example"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert := Certificate{CertPath: certPath, KeyPath: keyPath, ServerNames: []string{"shop.example"}}
+	inspectCertificate(&cert)
+	if cert.Err != nil {
+		t.Fatal(cert.Err)
+	}
+	if !cert.KeyMatch || cert.ChainLength != 2 || cert.Subject != "shop.example" {
+		t.Errorf("details = %+v", cert)
+	}
+	// The local CA isn't in the system roots
+	if cert.ChainComplete {
+		t.Error("chain verified against the system roots")
+	}
+
+	// The CA is created once and signs every certificate
+	ca, _, err := g.EnsureCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ca.IsCA || cert.Issuer != ca.Subject.CommonName {
+		t.Errorf("issuer %q, CA %q (IsCA %v)", cert.Issuer, ca.Subject.CommonName, ca.IsCA)
+	}
+	caCert := Certificate{CertPath: g.CACertPath(), KeyPath: g.caKeyPath()}
+	if inspectCertificate(&caCert); caCert.Err != nil || !caCert.KeyMatch {
+		t.Errorf("CA = %+v", caCert)
+	}
+
+	data, err := readNginxFile(certPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := decodeCertificateChain(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	if _, err := chain[0].Verify(x509.VerifyOptions{Roots: roots, DNSName: "shop.example"}); err != nil {
+		t.Errorf("leaf doesn't verify against the local CA: %v", err)
+	}
+	if !chain[1].Equal(ca) {
+		t.Error("the certificate file doesn't carry the CA")
+	}
+
+	again, _, err := g.EnsureCA()
+	if err != nil || !again.Equal(ca) {
+		t.Errorf("EnsureCA created another CA: %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+package nginx
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Certificate describes a TLS certificate referenced by a server block
+type Certificate struct {
+	Site        string
+	ServerNames []string
+	CertPath    string
+	KeyPath     string
+
+	Subject   string
+	SANs      []string
+	Issuer    string
+	NotBefore time.Time
+	NotAfter  time.Time
+	DaysLeft  int
+	KeyType   string
+
+	ChainLength    int    // Number of certificates in the file, leaf included
+	ChainComplete  bool   // True if the file chain verifies against the system roots
+	ChainError     string // Why verification failed, if it did
+	KeyMatch       bool   // True if the private key matches the certificate
+	KeyError       string // Why the private key couldn't be read or parsed, if it couldn't
+	UncoveredNames []string
+
+	Err error // Set when the certificate could not be loaded
+}
+
+// Expired reports whether the certificate is past its NotAfter date
+func (c Certificate) Expired() bool {
+	return c.Err == nil && time.Now().After(c.NotAfter)
+}
+
+// ExpiresWithin reports whether the certificate expires within the given number of days
+func (c Certificate) ExpiresWithin(days int) bool {
+	return c.Err == nil && c.DaysLeft <= days
+}
+
+// GetCertificates loads every ssl_certificate referenced by the parsed configuration
+func (s *Service) GetCertificates() ([]Certificate, error) {
+	blocks, err := s.ServerBlocks()
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []Certificate
+	for _, block := range blocks {
+		// A server block may declare several certificates (e.g. RSA and ECDSA)
+		certDirectives := block.LookupAll("ssl_certificate")
+		keyDirectives := block.LookupAll("ssl_certificate_key")
+
+		for i, certDirective := range certDirectives {
+			if len(certDirective.Args) == 0 {
+				continue
+			}
+			cert := Certificate{
+				Site:        block.Site,
+				ServerNames: block.ServerNames,
+				CertPath:    resolveConfigPath(certDirective.Args[0]),
+			}
+			if i < len(keyDirectives) && len(keyDirectives[i].Args) > 0 {
+				cert.KeyPath = resolveConfigPath(keyDirectives[i].Args[0])
+			}
+			inspectCertificate(&cert)
+			certs = append(certs, cert)
+		}
+	}
+
+	// Soonest expiry first; unreadable certificates at the top
+	sort.SliceStable(certs, func(i, j int) bool {
+		if (certs[i].Err != nil) != (certs[j].Err != nil) {
+			return certs[i].Err != nil
+		}
+		return certs[i].NotAfter.Before(certs[j].NotAfter)
+	})
+
+	return certs, nil
+}
+
+// inspectCertificate decodes the certificate and key files and fills in the details
+func inspectCertificate(cert *Certificate) {
+	if strings.Contains(cert.CertPath, "$") {
+		cert.Err = fmt.Errorf("certificate path uses variables: %s", cert.CertPath)
+		return
+	}
+
+	certPEM, err := readNginxFile(cert.CertPath)
+	if err != nil {
+		cert.Err = fmt.Errorf("failed to read certificate: %w", err)
+		return
+	}
+
+	chain, err := decodeCertificateChain(certPEM)
+	if err != nil {
+		cert.Err = err
+		return
+	}
+
+	leaf := chain[0]
+	cert.Subject = leaf.Subject.CommonName
+	if cert.Subject == "" {
+		cert.Subject = leaf.Subject.String()
+	}
+	cert.Issuer = leaf.Issuer.CommonName
+	if cert.Issuer == "" {
+		cert.Issuer = leaf.Issuer.String()
+	}
+	cert.NotBefore = leaf.NotBefore
+	cert.NotAfter = leaf.NotAfter
+	cert.DaysLeft = int(time.Until(leaf.NotAfter).Hours() / 24)
+	cert.KeyType = describeKeyType(leaf)
+	cert.ChainLength = len(chain)
+	cert.SANs = append(cert.SANs, leaf.DNSNames...)
+	for _, ip := range leaf.IPAddresses {
+		cert.SANs = append(cert.SANs, ip.String())
+	}
+
+	// Verify the chain as shipped in the file, using only the system roots
+	intermediates := x509.NewCertPool()
+	for _, c := range chain[1:] {
+		intermediates.AddCert(c)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{Intermediates: intermediates}); err != nil {
+		cert.ChainError = err.Error()
+	} else {
+		cert.ChainComplete = true
+	}
+
+	// Check the key matches the certificate
+	if cert.KeyPath != "" {
+		keyPEM, err := readNginxFile(cert.KeyPath)
+		if err != nil {
+			cert.KeyError = fmt.Sprintf("failed to read key: %v", err)
+		} else if key, err := parseCertificateKey(keyPEM); err != nil {
+			cert.KeyError = err.Error()
+		} else if pub, ok := leaf.PublicKey.(interface{ Equal(crypto.PublicKey) bool }); ok {
+			cert.KeyMatch = pub.Equal(key.Public())
+		}
+	}
+
+	cert.UncoveredNames = uncoveredServerNames(leaf, cert.ServerNames)
+}
+
+// decodeCertificateChain parses every CERTIFICATE block in a PEM file
+func decodeCertificateChain(data []byte) ([]*x509.Certificate, error) {
+	var chain []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		chain = append(chain, c)
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no certificate found in file")
+	}
+	return chain, nil
+}
+
+// parseCertificateKey parses the private key in an ssl_certificate_key file,
+// skipping blocks such as EC PARAMETERS that openssl writes before it
+func parseCertificateKey(data []byte) (crypto.Signer, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("no private key found in key file")
+		}
+		if strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			return parsePrivateKey(pem.EncodeToMemory(block))
+		}
+	}
+}
+
+// describeKeyType returns a short description of the certificate public key
+func describeKeyType(c *x509.Certificate) string {
+	switch c.PublicKeyAlgorithm {
+	case x509.RSA:
+		if key, ok := c.PublicKey.(interface{ Size() int }); ok {
+			return fmt.Sprintf("RSA %d", key.Size()*8)
+		}
+		return "RSA"
+	case x509.ECDSA:
+		if key, ok := c.PublicKey.(*ecdsa.PublicKey); ok {
+			return "ECDSA " + key.Curve.Params().Name
+		}
+		return "ECDSA"
+	case x509.Ed25519:
+		return "Ed25519"
+	default:
+		return c.PublicKeyAlgorithm.String()
+	}
+}
+
+// uncoveredServerNames returns the server_names the certificate is not valid for
+func uncoveredServerNames(leaf *x509.Certificate, serverNames []string) []string {
+	var uncovered []string
+	for _, name := range serverNames {
+		switch {
+		case name == "" || name == "_" || strings.HasPrefix(name, "~") || strings.HasSuffix(name, ".*") || strings.Contains(name, "$"):
+			// Catch-all, regex, trailing wildcard ("example.*") and variable
+			// names can't be checked
+			continue
+		case strings.HasPrefix(name, "*."):
+			if !hasSAN(leaf, name) {
+				uncovered = append(uncovered, name)
+			}
+		case strings.HasPrefix(name, "."):
+			// ".example.com" matches both example.com and *.example.com
+			if leaf.VerifyHostname(name[1:]) != nil || !hasSAN(leaf, "*"+name) {
+				uncovered = append(uncovered, name)
+			}
+		default:
+			if leaf.VerifyHostname(name) != nil {
+				uncovered = append(uncovered, name)
+			}
+		}
+	}
+	return uncovered
+}
+
+// hasSAN reports whether the certificate lists the exact DNS name
+func hasSAN(leaf *x509.Certificate, name string) bool {
+	for _, san := range leaf.DNSNames {
+		if strings.EqualFold(san, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package nginx
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	crossplane "github.com/nginxinc/nginx-go-crossplane"
+)
+
+// ServerBlock is a server {} block resolved from the full NGINX configuration
+type ServerBlock struct {
+	Site        string                // Base name of the file declaring the block
+	File        string                // Full path of the file declaring the block
+	Line        int                   // Line of the server directive
+	ServerNames []string              // Values of server_name
+	Directives  crossplane.Directives // Directives of the server block, includes expanded
+	HTTP        crossplane.Directives // Directives of the enclosing http block, includes expanded
+}
+
+// Lookup returns the arguments of the first directive with the given name,
+// falling back to the http level the way NGINX inherits settings
+func (b ServerBlock) Lookup(name string) []string {
+	for _, d := range b.Directives {
+		if d.Directive == name {
+			return d.Args
+		}
+	}
+	for _, d := range b.HTTP {
+		if d.Directive == name {
+			return d.Args
+		}
+	}
+	return nil
+}
+
+// LookupAll returns every directive with the given name in the server block,
+// or the http-level ones if the server block declares none
+func (b ServerBlock) LookupAll(name string) crossplane.Directives {
+	var found crossplane.Directives
+	for _, d := range b.Directives {
+		if d.Directive == name {
+			found = append(found, d)
+		}
+	}
+	if len(found) > 0 {
+		return found
+	}
+	for _, d := range b.HTTP {
+		if d.Directive == name {
+			found = append(found, d)
+		}
+	}
+	return found
+}
+
+// ServerBlocks parses the full configuration starting at nginx.conf and
+// returns every server block inside the http context
+func (s *Service) ServerBlocks() ([]ServerBlock, error) {
+	if err := s.parseConfig(); err != nil {
+		return nil, err
+	}
+	if len(s.payload.Config) == 0 {
+		return nil, fmt.Errorf("empty nginx configuration")
+	}
+
+	root := s.payload.Config[0]
+	var blocks []ServerBlock
+	for _, d := range s.expandIncludes(root.Parsed, root.File) {
+		if d.Directive != "http" {
+			continue
+		}
+		httpDirectives := s.expandIncludes(d.Block, d.File)
+		for _, hd := range httpDirectives {
+			if hd.Directive != "server" {
+				continue
+			}
+			block := ServerBlock{
+				Site:       filepath.Base(hd.File),
+				File:       hd.File,
+				Line:       hd.Line,
+				Directives: s.expandIncludes(hd.Block, hd.File),
+				HTTP:       httpDirectives,
+			}
+			for _, sd := range block.Directives {
+				if sd.Directive == "server_name" {
+					block.ServerNames = append(block.ServerNames, sd.Args...)
+				}
+			}
+			blocks = append(blocks, block)
+		}
+	}
+
+	return blocks, nil
+}
+
+// expandIncludes inlines the contents of include directives and records the
+// file each directive came from
+func (s *Service) expandIncludes(directives crossplane.Directives, file string) crossplane.Directives {
+	var expanded crossplane.Directives
+	for _, d := range directives {
+		if d.IsInclude() {
+			for _, idx := range d.Includes {
+				if idx < 0 || idx >= len(s.payload.Config) {
+					continue
+				}
+				included := s.payload.Config[idx]
+				expanded = append(expanded, s.expandIncludes(included.Parsed, included.File)...)
+			}
+			continue
+		}
+		if d.File == "" {
+			d.File = file
+		}
+		expanded = append(expanded, d)
+	}
+	return expanded
+}
+
+// readNginxFile reads a file referenced by the NGINX configuration, from inside
+// the container when NGINX runs in Docker
+func readNginxFile(path string) ([]byte, error) {
+	if IsDockerAvailable() {
+		if containerID, err := getCachedContainerID(); err == nil {
+			output, err := exec.Command("docker", "exec", containerID, "cat", path).Output()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s from container: %w", path, err)
+			}
+			return output, nil
+		}
+	}
+	return os.ReadFile(path)
+}
+
+// resolveConfigPath resolves a path relative to the NGINX prefix the way
+// NGINX does for directives like ssl_certificate
+func resolveConfigPath(path string) string {
+	if path == "" || filepath.IsAbs(path) || strings.HasPrefix(path, "data:") {
+		return path
+	}
+	return filepath.Join(filepath.Dir(nginxConfPath), path)
+}
@@ -2,84 +2,65 @@ package nginx
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"os/exec"
 	"strings"
-	"time"
 )
 
 // GetDockerAccessLogs reads access logs from Docker container
-func GetDockerAccessLogs(containerID string, lines int) ([]LogEntry, error) {
-	// Try to get logs from container with timeout
-	cmd := exec.Command("docker", "logs", "--tail", fmt.Sprintf("%d", lines), containerID)
-	output, err := cmd.Output()
-	if err != nil {
-		// Fallback: try reading from log file inside container
-		cmd = exec.Command("docker", "exec", containerID, "sh", "-c", fmt.Sprintf("tail -n %d /var/log/nginx/access.log 2>/dev/null || echo ''", lines))
-		output, err = cmd.Output()
+func GetDockerAccessLogs(containerID string, lines int, logs []AccessLog) ([]LogEntry, error) {
+	var entries []LogEntry
+	for _, log := range logs {
+		output, err := readDockerAccessLog(containerID, lines, log.Path)
 		if err != nil {
-			// Return empty if can't read logs
-			return []LogEntry{}, nil
+			// Skip logs that can't be read
+			continue
 		}
-	}
-
-	// Parse log lines
-	var entries []LogEntry
-	scanner := bufio.NewScanner(strings.NewReader(string(output)))
-	for scanner.Scan() {
-		line := scanner.Text()
-		entry, err := parseAccessLogLine(line)
-		if err == nil {
-			entries = append(entries, entry)
+		var logLines []string
+		scanner := bufio.NewScanner(strings.NewReader(string(output)))
+		for scanner.Scan() {
+			logLines = append(logLines, scanner.Text())
 		}
+		entries = append(entries, parseAccessLogLines(logLines, log)...)
 	}
 
-	return entries, nil
+	return lastEntries(entries, lines), nil
 }
 
-// CalculateDockerRequestRate calculates request rate from Docker container logs
-func CalculateDockerRequestRate(containerID string) (float64, int64, error) {
-	// Try docker logs first (faster)
-	cmd := exec.Command("docker", "logs", "--tail", "1000", containerID)
+// readDockerAccessLog returns the last lines of an access log in the container
+func readDockerAccessLog(containerID string, lines int, path string) ([]byte, error) {
+	tail := exec.Command("docker", "exec", containerID, "sh", "-c", fmt.Sprintf("tail -n %d %s 2>/dev/null || echo ''", lines, path))
+	if path != DefaultAccessLog {
+		return tail.Output()
+	}
+
+	// The official image links the default access log to stdout
+	cmd := exec.Command("docker", "logs", "--tail", fmt.Sprintf("%d", lines), containerID)
 	output, err := cmd.Output()
 	if err != nil {
-		// Fallback: try reading from log file
-		cmd = exec.Command("docker", "exec", containerID, "sh", "-c", "tail -n 1000 /var/log/nginx/access.log 2>/dev/null || echo ''")
-		output, err = cmd.Output()
-		if err != nil || len(output) == 0 {
-			// Return 0 if can't read logs
-			return 0, 0, nil
-		}
+		// Fallback: try reading from log file inside container
+		return tail.Output()
 	}
+	return output, nil
+}
 
-	var totalRequests int64
-	var recentRequests int
-	now := time.Now()
-	oneMinuteAgo := now.Add(-1 * time.Minute)
-
-	scanner := bufio.NewScanner(strings.NewReader(string(output)))
-	for scanner.Scan() {
-		totalRequests++
-		line := scanner.Text()
-
-		// Try to parse timestamp from log line
-		// Typical format: 127.0.0.1 - - [05/Nov/2024:10:24:57 +0100] ...
-		if strings.Contains(line, "[") && strings.Contains(line, "]") {
-			start := strings.Index(line, "[") + 1
-			end := strings.Index(line, "]")
-			if start > 0 && end > start {
-				timestampStr := line[start:end]
-				// Parse nginx timestamp format
-				timestamp, err := time.Parse("02/Jan/2006:15:04:05 -0700", timestampStr)
-				if err == nil && timestamp.After(oneMinuteAgo) {
-					recentRequests++
-				}
-			}
-		}
+// readDockerErrorLog returns the last lines of an error log in the container
+func readDockerErrorLog(containerID string, lines int, path string) ([]string, error) {
+	var output []byte
+	var err error
+	if path == DefaultErrorLog {
+		// The official image links the default error log to stderr
+		var stderr bytes.Buffer
+		cmd := exec.Command("docker", "logs", "--tail", fmt.Sprintf("%d", lines), containerID)
+		cmd.Stderr = &stderr
+		err = cmd.Run()
+		output = stderr.Bytes()
+	} else {
+		output, err = exec.Command("docker", "exec", containerID, "tail", "-n", fmt.Sprintf("%d", lines), path).Output()
 	}
-
-	// Calculate rate (requests per second over last minute)
-	rate := float64(recentRequests) / 60.0
-
-	return rate, totalRequests, nil
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimRight(string(output), "\n"), "\n"), nil
 }
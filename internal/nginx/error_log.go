@@ -0,0 +1,359 @@
+package nginx
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultErrorLog is used when the configuration can't be read or declares no error_log
+const DefaultErrorLog = "/var/log/nginx/error.log"
+
+// ErrorLog is an error_log file and the sites writing to it
+type ErrorLog struct {
+	Path  string
+	Sites []string // Sites whose server blocks write to this file
+}
+
+// ErrorLevel is an error log severity, from debug to emerg
+type ErrorLevel int
+
+const (
+	LevelDebug ErrorLevel = iota
+	LevelInfo
+	LevelNotice
+	LevelWarn
+	LevelError
+	LevelCrit
+	LevelAlert
+	LevelEmerg
+)
+
+// errorLevelNames are the names NGINX writes, by level
+var errorLevelNames = []string{"debug", "info", "notice", "warn", "error", "crit", "alert", "emerg"}
+
+// String returns the level as NGINX writes it
+func (l ErrorLevel) String() string {
+	if l < 0 || int(l) >= len(errorLevelNames) {
+		return "unknown"
+	}
+	return errorLevelNames[l]
+}
+
+// ErrorLogEntry is a parsed line of an error log
+type ErrorLogEntry struct {
+	Timestamp  time.Time
+	Level      ErrorLevel
+	PID        int
+	TID        int
+	Connection int64  // Connection serial number, 0 when the line has none
+	Message    string // The message without the context fields below
+	Client     string
+	Server     string
+	Request    string // Request line, e.g. "GET /api HTTP/1.1"
+	Upstream   string
+	Host       string
+
+	Log      string // Path of the error log the line came from
+	Site     string // Site whose error_log it is, when only one site writes to it
+	Raw      string // The line as written
+	ParseErr error  // Set when the line isn't in the error log format; only Raw is filled in
+}
+
+// errorLineRe matches "2025/01/31 12:00:00 [error] 1234#5678: *42 message"
+var errorLineRe = regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}) \[(\w+)\] (\d+)#(\d+): (?:\*(\d+) )?(.*)$`)
+
+// errorContextRe matches the ", key: value" context NGINX appends to messages
+var errorContextRe = regexp.MustCompile(`, (client|server|request|upstream|host|referrer|subrequest): ("(?:[^"\\]|\\.)*"|[^,]*)`)
+
+// ParseErrorLogLine parses an error log line
+func ParseErrorLogLine(line string) (ErrorLogEntry, error) {
+	entry := ErrorLogEntry{Raw: line}
+	matches := errorLineRe.FindStringSubmatch(line)
+	if matches == nil {
+		return entry, fmt.Errorf("line is not in the error log format")
+	}
+
+	// NGINX writes error log times in local time
+	entry.Timestamp, _ = time.ParseInLocation("2006/01/02 15:04:05", matches[1], time.Local)
+	entry.Level = LevelError
+	if i := slices.Index(errorLevelNames, matches[2]); i >= 0 {
+		entry.Level = ErrorLevel(i)
+	}
+	entry.PID, _ = strconv.Atoi(matches[3])
+	entry.TID, _ = strconv.Atoi(matches[4])
+	entry.Connection, _ = strconv.ParseInt(matches[5], 10, 64)
+
+	// The message runs up to the first context field
+	message := matches[6]
+	if loc := errorContextRe.FindStringIndex(message); loc != nil {
+		for _, field := range errorContextRe.FindAllStringSubmatch(message[loc[0]:], -1) {
+			value := strings.Trim(field[2], `"`)
+			switch field[1] {
+			case "client":
+				entry.Client = value
+			case "server":
+				entry.Server = value
+			case "request":
+				entry.Request = value
+			case "upstream":
+				entry.Upstream = value
+			case "host":
+				entry.Host = value
+			}
+		}
+		message = message[:loc[0]]
+	}
+	entry.Message = message
+	return entry, nil
+}
+
+// RequestPath returns the URI of the request the error happened in
+func (e ErrorLogEntry) RequestPath() string {
+	if fields := strings.Fields(e.Request); len(fields) >= 2 {
+		return fields[1]
+	}
+	return ""
+}
+
+// AccessQuery returns a Logs tab filter that finds the access log line of the
+// request the error happened in: same client and URI, logged when the request
+// finished, so from the error's second up to a typical proxy timeout later.
+func (e ErrorLogEntry) AccessQuery() string {
+	if e.Client == "" || e.Timestamp.IsZero() {
+		return ""
+	}
+	terms := []string{
+		"ip:" + exactIPQueryValue(e.Client),
+		"since:" + e.Timestamp.Format(time.RFC3339),
+		"until:" + e.Timestamp.Add(accessLogLag).Format(time.RFC3339),
+	}
+	if path := e.RequestPath(); path != "" && !strings.ContainsAny(path, `", `) {
+		terms = append(terms, "path:"+path)
+	}
+	return strings.Join(terms, " ")
+}
+
+// exactIPQueryValue returns an ip: filter value matching only the address
+// itself, a single-address network when it parses
+func exactIPQueryValue(addr string) string {
+	ip := net.ParseIP(addr)
+	switch {
+	case ip == nil:
+		return exactQueryValue(addr)
+	case ip.To4() != nil:
+		return ip.String() + "/32"
+	}
+	return ip.String() + "/128"
+}
+
+// accessLogLag is how long after an error the request's access log line is
+// looked for; NGINX writes it when the request completes
+const accessLogLag = 61 * time.Second
+
+// FilterErrorLogEntries returns the entries at minLevel or above, newest first
+func FilterErrorLogEntries(entries []ErrorLogEntry, minLevel ErrorLevel) []ErrorLogEntry {
+	var kept []ErrorLogEntry
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Level >= minLevel {
+			kept = append(kept, entries[i])
+		}
+	}
+	return kept
+}
+
+// ErrorGroup is a set of error log entries with the same message
+type ErrorGroup struct {
+	Key     string
+	Level   ErrorLevel // Highest level in the group
+	Count   int
+	First   time.Time
+	Last    time.Time
+	Clients int           // Distinct clients
+	Latest  ErrorLogEntry // Most recent entry, as an example
+}
+
+// groupNumberRe matches numbers that differ between repeats of a message
+var groupNumberRe = regexp.MustCompile(`\d+`)
+
+// GroupErrorLogEntries groups repeated messages, most frequent first.
+// Numbers in messages (ports, file descriptors, byte counts) are ignored.
+func GroupErrorLogEntries(entries []ErrorLogEntry) []ErrorGroup {
+	index := map[string]int{}
+	clients := map[string]map[string]bool{}
+	var groups []ErrorGroup
+	for _, entry := range entries {
+		message := entry.Message
+		if entry.ParseErr != nil {
+			message = entry.Raw
+		}
+		key := groupNumberRe.ReplaceAllString(message, "N")
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			clients[key] = map[string]bool{}
+			groups = append(groups, ErrorGroup{Key: key, Level: entry.Level, First: entry.Timestamp, Last: entry.Timestamp, Latest: entry})
+		}
+		group := &groups[i]
+		group.Count++
+		group.Level = max(group.Level, entry.Level)
+		// Entries may come in either order
+		if entry.Timestamp.Before(group.First) {
+			group.First = entry.Timestamp
+		}
+		if entry.Timestamp.After(group.Last) {
+			group.Last = entry.Timestamp
+			group.Latest = entry
+		}
+		if entry.Client != "" {
+			clients[key][entry.Client] = true
+		}
+	}
+	for i := range groups {
+		groups[i].Clients = len(clients[groups[i].Key])
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Count > groups[j].Count
+	})
+	return groups
+}
+
+// ErrorLogs returns every error_log file that applies to a server block,
+// following NGINX's inheritance from the http and main contexts. When nothing
+// is declared it falls back to the default log.
+func (s *Service) ErrorLogs() []ErrorLog {
+	defaultLogs := []ErrorLog{{Path: DefaultErrorLog}}
+	blocks, err := s.ServerBlocks()
+	if err != nil {
+		return defaultLogs
+	}
+
+	// error_log is also valid outside http; those apply when nothing closer does
+	root := s.payload.Config[0]
+	var mainLogs []string
+	for _, d := range s.expandIncludes(root.Parsed, root.File) {
+		if d.Directive == "error_log" && len(d.Args) > 0 && isErrorLogFile(d.Args[0]) {
+			mainLogs = append(mainLogs, resolveConfigPath(d.Args[0]))
+		}
+	}
+	if len(mainLogs) == 0 {
+		mainLogs = []string{DefaultErrorLog}
+	}
+
+	var logs []ErrorLog
+	index := map[string]int{}
+	add := func(path, site string) {
+		if i, ok := index[path]; ok {
+			if !slices.Contains(logs[i].Sites, site) {
+				logs[i].Sites = append(logs[i].Sites, site)
+			}
+			return
+		}
+		index[path] = len(logs)
+		logs = append(logs, ErrorLog{Path: path, Sites: []string{site}})
+	}
+
+	for _, block := range blocks {
+		declared := block.LookupAll("error_log")
+		if len(declared) == 0 {
+			for _, path := range mainLogs {
+				add(path, block.Site)
+			}
+			continue
+		}
+		for _, d := range declared {
+			if len(d.Args) > 0 && isErrorLogFile(d.Args[0]) {
+				add(resolveConfigPath(d.Args[0]), block.Site)
+			}
+		}
+	}
+
+	if len(logs) == 0 {
+		return defaultLogs
+	}
+	return logs
+}
+
+// isErrorLogFile reports whether an error_log target is a file that can be read
+func isErrorLogFile(target string) bool {
+	return target != "stderr" &&
+		!strings.HasPrefix(target, "syslog:") &&
+		!strings.HasPrefix(target, "memory:") &&
+		target != "/dev/null"
+}
+
+// parseErrorLogLines turns error log lines into entries
+func parseErrorLogLines(lines []string, log ErrorLog) []ErrorLogEntry {
+	site := ""
+	if len(log.Sites) == 1 {
+		site = log.Sites[0]
+	}
+
+	var entries []ErrorLogEntry
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entry, err := ParseErrorLogLine(line)
+		entry.ParseErr = err
+		entry.Log = log.Path
+		entry.Site = site
+		entries = append(entries, entry)
+	}
+
+	// Continuation lines and foreign output take the neighbouring time and level
+	for i := range entries {
+		if entries[i].ParseErr != nil && i > 0 {
+			entries[i].Timestamp = entries[i-1].Timestamp
+			entries[i].Level = entries[i-1].Level
+		}
+	}
+	return entries
+}
+
+// GetErrorLogs returns the last maxLines entries of every configured error log
+func (s *Service) GetErrorLogs(maxLines int) ([]ErrorLogEntry, error) {
+	containerID := ""
+	if IsDockerAvailable() {
+		containerID, _ = getCachedContainerID()
+	}
+
+	var entries []ErrorLogEntry
+	var firstErr error
+	opened := 0
+	for _, log := range s.ErrorLogs() {
+		var lines []string
+		var err error
+		if containerID != "" {
+			lines, err = readDockerErrorLog(containerID, maxLines, log.Path)
+		} else {
+			lines, err = tailFile(log.Path, maxLines)
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to open error log: %w", err)
+			}
+			continue
+		}
+		opened++
+		entries = append(entries, parseErrorLogLines(lines, log)...)
+	}
+	if opened == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	if len(entries) > maxLines {
+		entries = entries[len(entries)-maxLines:]
+	}
+	return entries, nil
+}
@@ -0,0 +1,226 @@
+package nginx
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseErrorLogLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want ErrorLogEntry
+	}{
+		{
+			line: `2025/10/10 13:55:36 [error] 1234#5678: *42 connect() failed (111: Connection refused) while connecting to upstream, ` +
+				`client: 203.0.113.7, server: shop.example.com, request: "GET /api/cart HTTP/1.1", upstream: "http://10.0.0.5:8080/api/cart", host: "shop.example.com"`,
+			want: ErrorLogEntry{
+				Timestamp:  time.Date(2025, 10, 10, 13, 55, 36, 0, time.Local),
+				Level:      LevelError,
+				PID:        1234,
+				TID:        5678,
+				Connection: 42,
+				Message:    "connect() failed (111: Connection refused) while connecting to upstream",
+				Client:     "203.0.113.7",
+				Server:     "shop.example.com",
+				Request:    "GET /api/cart HTTP/1.1",
+				Upstream:   "http://10.0.0.5:8080/api/cart",
+				Host:       "shop.example.com",
+			},
+		},
+		{
+			line: `2025/10/10 13:55:37 [warn] 1#1: conflicting server name "example.com" on 0.0.0.0:80, ignored`,
+			want: ErrorLogEntry{
+				Timestamp: time.Date(2025, 10, 10, 13, 55, 37, 0, time.Local),
+				Level:     LevelWarn,
+				PID:       1,
+				TID:       1,
+				Message:   `conflicting server name "example.com" on 0.0.0.0:80, ignored`,
+			},
+		},
+		{
+			line: `2025/10/10 13:55:38 [emerg] 7#7: bind() to [::]:443 failed (98: Address in use)`,
+			want: ErrorLogEntry{
+				Timestamp: time.Date(2025, 10, 10, 13, 55, 38, 0, time.Local),
+				Level:     LevelEmerg,
+				PID:       7,
+				TID:       7,
+				Message:   "bind() to [::]:443 failed (98: Address in use)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := ParseErrorLogLine(tt.line)
+		if err != nil {
+			t.Errorf("ParseErrorLogLine(%q): %v", tt.line, err)
+			continue
+		}
+		tt.want.Raw = tt.line
+		if got != tt.want {
+			t.Errorf("ParseErrorLogLine(%q)\n got %+v\nwant %+v", tt.line, got, tt.want)
+		}
+	}
+
+	for _, line := range []string{"", "PHP message: PHP Warning: ...", "2025/10/10 [error] 1#1: x"} {
+		if _, err := ParseErrorLogLine(line); err == nil {
+			t.Errorf("ParseErrorLogLine(%q) succeeded, want an error", line)
+		}
+	}
+}
+
+func TestErrorLevelString(t *testing.T) {
+	if LevelCrit.String() != "crit" || LevelDebug.String() != "debug" || ErrorLevel(42).String() != "unknown" {
+		t.Errorf("level names: %s %s %s", LevelCrit, LevelDebug, ErrorLevel(42))
+	}
+}
+
+func TestParseErrorLogLines(t *testing.T) {
+	lines := []string{
+		`2025/10/10 13:55:36 [error] 1#1: *1 FastCGI sent in stderr: "PHP message: boom" while reading response header from upstream`,
+		`PHP message: PHP Stack trace:`,
+		``,
+		`2025/10/10 13:55:40 [warn] 1#1: *2 an upstream response is buffered to a temporary file`,
+	}
+	entries := parseErrorLogLines(lines, ErrorLog{Path: "/var/log/nginx/shop.error.log", Sites: []string{"shop"}})
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	// Continuation lines take the time and level of the line before
+	cont := entries[1]
+	if cont.ParseErr == nil || cont.Level != LevelError || !cont.Timestamp.Equal(entries[0].Timestamp) {
+		t.Errorf("continuation line: %+v", cont)
+	}
+	for _, e := range entries {
+		if e.Site != "shop" || e.Log != "/var/log/nginx/shop.error.log" {
+			t.Errorf("entry not attributed: site %q log %q", e.Site, e.Log)
+		}
+	}
+}
+
+func TestFilterErrorLogEntries(t *testing.T) {
+	entries := []ErrorLogEntry{
+		{Level: LevelInfo, Message: "a"},
+		{Level: LevelError, Message: "b"},
+		{Level: LevelWarn, Message: "c"},
+		{Level: LevelCrit, Message: "d"},
+	}
+	got := FilterErrorLogEntries(entries, LevelWarn)
+	want := []string{"d", "c", "b"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Message != want[i] {
+			t.Errorf("entry %d = %q, want %q (newest first)", i, got[i].Message, want[i])
+		}
+	}
+}
+
+func TestGroupErrorLogEntries(t *testing.T) {
+	at := func(sec int) time.Time { return time.Date(2025, 10, 10, 13, 0, sec, 0, time.UTC) }
+	refused := func(sec int, client string) ErrorLogEntry {
+		return ErrorLogEntry{
+			Timestamp: at(sec),
+			Level:     LevelError,
+			Message:   "connect() failed (111: Connection refused) while connecting to upstream " + client,
+			Client:    client,
+		}
+	}
+
+	// The Errors view passes entries newest first
+	newestFirst := []ErrorLogEntry{
+		refused(50, "10.0.0.2"),
+		{Timestamp: at(40), Level: LevelCrit, Message: "SSL_do_handshake() failed"},
+		refused(30, "10.0.0.1"),
+		refused(10, "10.0.0.2"),
+	}
+	// Logs merged from several files come in any order
+	shuffled := []ErrorLogEntry{newestFirst[2], newestFirst[0], newestFirst[3], newestFirst[1]}
+
+	for name, entries := range map[string][]ErrorLogEntry{"newest first": newestFirst, "shuffled": shuffled} {
+		groups := GroupErrorLogEntries(entries)
+		if len(groups) != 2 {
+			t.Fatalf("%s: got %d groups, want 2", name, len(groups))
+		}
+		refusedGroup, handshake := groups[0], groups[1]
+		if refusedGroup.Count != 3 || handshake.Count != 1 {
+			t.Errorf("%s: counts %d and %d, want 3 and 1 (most frequent first)", name, refusedGroup.Count, handshake.Count)
+		}
+		if !refusedGroup.First.Equal(at(10)) || !refusedGroup.Last.Equal(at(50)) {
+			t.Errorf("%s: first %v last %v, want %v and %v", name, refusedGroup.First, refusedGroup.Last, at(10), at(50))
+		}
+		if !refusedGroup.Latest.Timestamp.Equal(at(50)) {
+			t.Errorf("%s: latest entry from %v, want the newest", name, refusedGroup.Latest.Timestamp)
+		}
+		if refusedGroup.Clients != 2 {
+			t.Errorf("%s: %d clients, want 2", name, refusedGroup.Clients)
+		}
+		if handshake.Level != LevelCrit || !handshake.First.Equal(handshake.Last) {
+			t.Errorf("%s: single entry group %+v", name, handshake)
+		}
+	}
+}
+
+func TestGroupErrorLogEntriesKeys(t *testing.T) {
+	errNotErrorLog := errors.New("line is not in the error log format")
+	entries := []ErrorLogEntry{
+		{Level: LevelWarn, Message: "upstream timed out (110: Connection timed out) on port 8080"},
+		{Level: LevelError, Message: "upstream timed out (110: Connection timed out) on port 9090"},
+		{Raw: "PHP message: oops 1", ParseErr: errNotErrorLog},
+		{Raw: "PHP message: oops 2", ParseErr: errNotErrorLog},
+	}
+	groups := GroupErrorLogEntries(entries)
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2: %+v", len(groups), groups)
+	}
+	if groups[0].Key != "upstream timed out (N: Connection timed out) on port N" || groups[0].Level != LevelError {
+		t.Errorf("numbers not folded or level not raised: %+v", groups[0])
+	}
+	if groups[1].Key != "PHP message: oops N" || groups[1].Count != 2 {
+		t.Errorf("unparsed lines not grouped by their text: %+v", groups[1])
+	}
+}
+
+func TestErrorLogEntryAccessQuery(t *testing.T) {
+	ts := time.Date(2025, 10, 10, 13, 55, 36, 0, time.UTC)
+	since := "since:" + ts.Format(time.RFC3339)
+	until := "until:" + ts.Add(accessLogLag).Format(time.RFC3339)
+
+	tests := []struct {
+		entry ErrorLogEntry
+		want  string
+	}{
+		{
+			ErrorLogEntry{Timestamp: ts, Client: "10.0.0.1", Request: "GET /api/cart?id=7 HTTP/1.1"},
+			"ip:10.0.0.1/32 " + since + " " + until + " path:/api/cart?id=7",
+		},
+		{
+			ErrorLogEntry{Timestamp: ts, Client: "2001:db8::1", Request: "POST / HTTP/2.0"},
+			"ip:2001:db8::1/128 " + since + " " + until + " path:/",
+		},
+		{
+			// A path with a space or quote can't be written as a term
+			ErrorLogEntry{Timestamp: ts, Client: "10.0.0.1", Request: `GET /a"b HTTP/1.1`},
+			"ip:10.0.0.1/32 " + since + " " + until,
+		},
+		{ErrorLogEntry{Timestamp: ts}, ""},
+		{ErrorLogEntry{Client: "10.0.0.1"}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.entry.AccessQuery(); got != tt.want {
+			t.Errorf("AccessQuery() = %q, want %q", got, tt.want)
+		}
+	}
+
+	// The query finds the request's own line but not a neighbour's
+	q, err := ParseLogQuery(tests[0].entry.AccessQuery())
+	if err != nil {
+		t.Fatal(err)
+	}
+	own := LogEntry{IP: "10.0.0.1", Timestamp: ts.Add(2 * time.Second), Path: "/api/cart?id=7"}
+	neighbour := own
+	neighbour.IP = "10.0.0.10"
+	if !q.Match(own) || q.Match(neighbour) {
+		t.Errorf("query %q: own line %v, neighbour %v", q.Expr, q.Match(own), q.Match(neighbour))
+	}
+}
@@ -0,0 +1,313 @@
+package nginx
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportFormat is a file format log entries and statistics are exported to
+type ExportFormat int
+
+const (
+	ExportCSV ExportFormat = iota
+	ExportJSON
+	ExportNDJSON // One JSON object per line
+)
+
+// ExportFormats lists every format in the order the export picker shows them
+var ExportFormats = []ExportFormat{ExportCSV, ExportJSON, ExportNDJSON}
+
+// String returns the format's display name
+func (f ExportFormat) String() string {
+	switch f {
+	case ExportJSON:
+		return "JSON"
+	case ExportNDJSON:
+		return "NDJSON"
+	}
+	return "CSV"
+}
+
+// Extension returns the file extension for the format
+func (f ExportFormat) Extension() string {
+	return "." + strings.ToLower(f.String())
+}
+
+// ExportFile writes an export to a new file in dir, named after what it holds
+// and the time, and returns its path. An empty dir is the working directory.
+func ExportFile(dir, name string, f ExportFormat, write func(io.Writer) error) (string, error) {
+	path := filepath.Join(dir, fmt.Sprintf("ngxtui-%s-%s%s", name, time.Now().Format("20060102-150405"), f.Extension()))
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create export: %w", err)
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		os.Remove(path)
+		return "", fmt.Errorf("failed to write export: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write export: %w", err)
+	}
+	return path, nil
+}
+
+// exportedEntry is the exported form of a LogEntry
+type exportedEntry struct {
+	Time         time.Time         `json:"time"`
+	Site         string            `json:"site,omitempty"`
+	IP           string            `json:"ip"`
+	Method       string            `json:"method"`
+	Path         string            `json:"path"`
+	Status       int               `json:"status"`
+	Bytes        int               `json:"bytes"`
+	Referer      string            `json:"referer,omitempty"`
+	UserAgent    string            `json:"user_agent,omitempty"`
+	Host         string            `json:"host,omitempty"`
+	RequestTime  *float64          `json:"request_time,omitempty"`  // Seconds; nil when not logged
+	UpstreamTime *float64          `json:"upstream_time,omitempty"` // Seconds; nil when not logged
+	Client       string            `json:"client"`
+	Country      string            `json:"country,omitempty"`
+	Log          string            `json:"log,omitempty"`
+	Vars         map[string]string `json:"vars,omitempty"`
+	Raw          string            `json:"raw"`
+}
+
+// entryCSVHeader names the CSV columns of an exported entry; variables are left out
+var entryCSVHeader = []string{"time", "site", "ip", "method", "path", "status", "bytes", "referer",
+	"user_agent", "host", "request_time", "upstream_time", "client", "country", "log", "raw"}
+
+func exportEntry(e LogEntry) exportedEntry {
+	return exportedEntry{
+		Time:         e.Timestamp,
+		Site:         e.Site,
+		IP:           e.IP,
+		Method:       e.Method,
+		Path:         e.Path,
+		Status:       e.StatusCode,
+		Bytes:        e.BytesSent,
+		Referer:      e.Referer,
+		UserAgent:    e.UserAgent,
+		Host:         e.Host,
+		RequestTime:  exportSeconds(e.Vars, "request_time"),
+		UpstreamTime: exportSeconds(e.Vars, "upstream_response_time"),
+		Client:       ClassifyUserAgent(e.UserAgent).String(),
+		Country:      LookupGeo(e.IP).Country,
+		Log:          e.Log,
+		Vars:         e.Vars,
+		Raw:          e.Raw,
+	}
+}
+
+// exportSeconds returns a timing variable in seconds, or nil when the line
+// didn't log it, so a logged 0.000 is kept apart from a missing value
+func exportSeconds(vars map[string]string, name string) *float64 {
+	d, ok := loggedTime(vars, name)
+	if !ok {
+		return nil
+	}
+	seconds := d.Seconds()
+	return &seconds
+}
+
+// csvRecord returns the entry's CSV row. Fields the client sent are escaped
+// so a spreadsheet doesn't run them as formulas.
+func (x exportedEntry) csvRecord() []string {
+	return []string{x.Time.Format(time.RFC3339), x.Site, x.IP, x.Method, csvCell(x.Path), strconv.Itoa(x.Status),
+		strconv.Itoa(x.Bytes), csvCell(x.Referer), csvCell(x.UserAgent), csvCell(x.Host), formatSeconds(x.RequestTime),
+		formatSeconds(x.UpstreamTime), x.Client, x.Country, x.Log, csvCell(x.Raw)}
+}
+
+// ExportLogEntries writes entries in the given format, oldest first. Lines
+// that didn't match their log_format are left out.
+func ExportLogEntries(w io.Writer, entries []LogEntry, f ExportFormat) error {
+	var exported []exportedEntry
+	for _, e := range entries {
+		if e.ParseErr == nil {
+			exported = append(exported, exportEntry(e))
+		}
+	}
+
+	switch f {
+	case ExportJSON:
+		if exported == nil {
+			exported = []exportedEntry{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(exported)
+	case ExportNDJSON:
+		enc := json.NewEncoder(w)
+		for _, x := range exported {
+			if err := enc.Encode(x); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(entryCSVHeader)
+	for _, x := range exported {
+		cw.Write(x.csvRecord())
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// exportedStats is the exported form of LogStats
+type exportedStats struct {
+	First              time.Time                       `json:"first"`
+	Last               time.Time                       `json:"last"`
+	TotalRequests      int                             `json:"total_requests"`
+	UniqueIPs          int                             `json:"unique_ips"`
+	TotalBytes         int64                           `json:"total_bytes"`
+	AvgBytesPerRequest int64                           `json:"avg_bytes_per_request"`
+	UnparsedLines      int                             `json:"unparsed_lines"`
+	Statuses           map[string]int                  `json:"statuses"`
+	Clients            map[string]int                  `json:"clients"`
+	Countries          map[string]int                  `json:"countries,omitempty"`
+	LatencyMillis      *exportedLatency                `json:"latency_ms,omitempty"`
+	Breakdowns         map[string][]exportedTrafficRow `json:"breakdowns,omitempty"`
+}
+
+// exportedLatency is the request latency over every timed request
+type exportedLatency struct {
+	Count int     `json:"count"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+	Max   float64 `json:"max"`
+}
+
+// exportedTrafficRow is one key of a traffic breakdown
+type exportedTrafficRow struct {
+	Dimension    string `json:"dimension,omitempty"` // Only set on NDJSON lines
+	Key          string `json:"key"`
+	Requests     int    `json:"requests"`
+	Bytes        int64  `json:"bytes"`
+	ClientErrors int    `json:"4xx"`
+	ServerErrors int    `json:"5xx"`
+}
+
+// dimensionSlug names a dimension in exports, e.g. "client_ips"
+func dimensionSlug(d TrafficDimension) string {
+	return strings.ReplaceAll(strings.ToLower(d.String()), " ", "_")
+}
+
+func exportStats(stats *LogStats) exportedStats {
+	x := exportedStats{
+		First:              stats.First,
+		Last:               stats.Last,
+		TotalRequests:      stats.TotalRequests,
+		UniqueIPs:          stats.UniqueIPs,
+		TotalBytes:         stats.TotalBytes,
+		AvgBytesPerRequest: stats.AvgBytesPerRequest,
+		UnparsedLines:      stats.UnparsedLines,
+		Statuses:           stats.StatusCounts,
+		Clients:            make(map[string]int),
+		Breakdowns:         make(map[string][]exportedTrafficRow),
+	}
+	for kind, count := range stats.ClientKinds {
+		x.Clients[kind.String()] = count
+	}
+	if len(stats.Countries) > 0 {
+		x.Countries = stats.Countries
+	}
+	if stats.Latency.Timed() {
+		overall := stats.Latency.Overall.Request
+		x.LatencyMillis = &exportedLatency{
+			Count: overall.Count,
+			P50:   millis(overall.P50),
+			P90:   millis(overall.P90),
+			P99:   millis(overall.P99),
+			Max:   millis(overall.Max),
+		}
+	}
+	for _, d := range TrafficDimensions {
+		rows := []exportedTrafficRow{}
+		for _, row := range stats.Top(d, ByRequests, 0) {
+			rows = append(rows, exportedTrafficRow{
+				Key:          row.Key,
+				Requests:     row.Requests,
+				Bytes:        row.Bytes,
+				ClientErrors: row.ClientErrors,
+				ServerErrors: row.ServerErrors,
+			})
+		}
+		x.Breakdowns[dimensionSlug(d)] = rows
+	}
+	return x
+}
+
+// ExportLogStats writes statistics in the given format. JSON holds one
+// object; NDJSON a summary line followed by a line per breakdown row; CSV
+// the breakdown rows after a "total" row.
+func ExportLogStats(w io.Writer, stats *LogStats, f ExportFormat) error {
+	x := exportStats(stats)
+
+	switch f {
+	case ExportJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(x)
+	case ExportNDJSON:
+		enc := json.NewEncoder(w)
+		breakdowns := x.Breakdowns
+		x.Breakdowns = nil
+		if err := enc.Encode(x); err != nil {
+			return err
+		}
+		for _, d := range TrafficDimensions {
+			for _, row := range breakdowns[dimensionSlug(d)] {
+				row.Dimension = dimensionSlug(d)
+				if err := enc.Encode(row); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"dimension", "key", "requests", "bytes", "4xx", "5xx"})
+	cw.Write([]string{"total", "", strconv.Itoa(stats.TotalRequests), strconv.FormatInt(stats.TotalBytes, 10),
+		strconv.Itoa(stats.StatusCounts["4xx"]), strconv.Itoa(stats.StatusCounts["5xx"])})
+	for _, d := range TrafficDimensions {
+		for _, row := range x.Breakdowns[dimensionSlug(d)] {
+			cw.Write([]string{dimensionSlug(d), csvCell(row.Key), strconv.Itoa(row.Requests), strconv.FormatInt(row.Bytes, 10),
+				strconv.Itoa(row.ClientErrors), strconv.Itoa(row.ServerErrors)})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatSeconds formats a timing for CSV, empty when it wasn't logged
+func formatSeconds(s *float64) string {
+	if s == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*s, 'f', -1, 64)
+}
+
+// csvCell prefixes a value starting with a formula character with a quote,
+// which spreadsheets show as text instead of evaluating
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// millis converts a duration to fractional milliseconds
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
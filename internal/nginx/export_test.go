@@ -0,0 +1,164 @@
+package nginx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// exportEntries parses lines written with timedLogFormat
+func exportEntries(t *testing.T) []LogEntry {
+	t.Helper()
+	f, err := CompileLogFormat("timed", timedLogFormat, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []LogEntry
+	for _, line := range []string{
+		`203.0.113.7 - - [10/Oct/2025:13:55:36 +0000] "GET =1+1 HTTP/1.1" 200 512 "-" "@SUM(A1)" rt=0.000 urt="-" host=shop.example`,
+		`198.51.100.2 - - [10/Oct/2025:13:55:37 +0000] "POST /cart HTTP/1.1" 502 0 "+https://evil.example/" "curl/8.5.0" rt=1.250 urt="0.004, 1.200" host=shop.example`,
+		`not a log line`,
+	} {
+		entry, err := f.Parse(line)
+		entry.ParseErr = err
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestExportLogEntriesCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportLogEntries(&buf, exportEntries(t), ExportCSV); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want the header and two entries", len(records))
+	}
+	column := func(record []string, name string) string {
+		for i, h := range entryCSVHeader {
+			if h == name {
+				return record[i]
+			}
+		}
+		t.Fatalf("no %s column", name)
+		return ""
+	}
+
+	tests := []struct {
+		row          int
+		column, want string
+	}{
+		// Fields the client sent can't start a formula
+		{1, "path", "'=1+1"},
+		{1, "user_agent", "'@SUM(A1)"},
+		{1, "referer", "'-"},
+		{2, "referer", "'+https://evil.example/"},
+		{2, "user_agent", "curl/8.5.0"},
+		{1, "host", "shop.example"},
+		// A logged zero is kept apart from a timing that wasn't logged
+		{1, "request_time", "0"},
+		{1, "upstream_time", ""},
+		{2, "request_time", "1.25"},
+		{2, "upstream_time", "1.204"},
+		{2, "status", "502"},
+	}
+	for _, tt := range tests {
+		if got := column(records[tt.row], tt.column); got != tt.want {
+			t.Errorf("row %d %s = %q, want %q", tt.row, tt.column, got, tt.want)
+		}
+	}
+}
+
+func TestExportLogEntriesJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportLogEntries(&buf, exportEntries(t), ExportJSON); err != nil {
+		t.Fatal(err)
+	}
+	var exported []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &exported); err != nil {
+		t.Fatal(err)
+	}
+	if len(exported) != 2 {
+		t.Fatalf("got %d entries, want the two that parsed", len(exported))
+	}
+	// JSON isn't opened as a spreadsheet, so values are written as logged
+	if exported[0]["path"] != "=1+1" || exported[0]["user_agent"] != "@SUM(A1)" {
+		t.Errorf("first entry = %+v", exported[0])
+	}
+	if rt, ok := exported[0]["request_time"]; !ok || rt != 0.0 {
+		t.Errorf("request_time = %v, %v; want a logged 0", rt, ok)
+	}
+	if urt, ok := exported[0]["upstream_time"]; ok {
+		t.Errorf("upstream_time = %v, want it left out", urt)
+	}
+	if exported[1]["upstream_time"] != 1.204 || exported[1]["status"] != 502.0 {
+		t.Errorf("second entry = %+v", exported[1])
+	}
+
+	buf.Reset()
+	if err := ExportLogEntries(&buf, nil, ExportJSON); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != "[]" {
+		t.Errorf("no entries = %q, want []", got)
+	}
+}
+
+func TestExportLogEntriesNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportLogEntries(&buf, exportEntries(t), ExportNDJSON); err != nil {
+		t.Fatal(err)
+	}
+	var ips []string
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var x exportedEntry
+		if err := json.Unmarshal(scanner.Bytes(), &x); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		ips = append(ips, x.IP)
+		if x.IP == "203.0.113.7" && (x.RequestTime == nil || *x.RequestTime != 0 || x.UpstreamTime != nil) {
+			t.Errorf("timings = %v, %v; want a logged 0 and none", x.RequestTime, x.UpstreamTime)
+		}
+	}
+	if len(ips) != 2 || ips[0] != "203.0.113.7" || ips[1] != "198.51.100.2" {
+		t.Errorf("lines for %q, want one per parsed entry, oldest first", ips)
+	}
+}
+
+func TestExportLogStatsCSV(t *testing.T) {
+	b := NewLogStatsBuilder()
+	for _, e := range exportEntries(t) {
+		b.Add(e)
+	}
+	var buf bytes.Buffer
+	if err := ExportLogStats(&buf, b.Stats(), ExportCSV); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total := records[1]; total[0] != "total" || total[2] != "2" || total[5] != "1" {
+		t.Errorf("total row = %q", total)
+	}
+	found := false
+	for _, record := range records[1:] {
+		if key := record[1]; key != "" && strings.ContainsRune("=+-@", rune(key[0])) {
+			t.Errorf("%s key %q isn't escaped", record[0], key)
+		}
+		if record[1] == "'@SUM(A1)" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("no escaped user agent row in %q", records)
+	}
+}
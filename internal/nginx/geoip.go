@@ -0,0 +1,127 @@
+package nginx
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// GeoInfo is what the GeoIP databases know about a client address
+type GeoInfo struct {
+	Country     string // ISO 3166 code, e.g. "DE"
+	CountryName string
+	ASN         uint
+	ASOrg       string
+}
+
+// ASLabel returns the autonomous system as "AS3320 Deutsche Telekom AG"
+func (g GeoInfo) ASLabel() string {
+	if g.ASN == 0 {
+		return g.ASOrg
+	}
+	return strings.TrimSpace(fmt.Sprintf("AS%d %s", g.ASN, g.ASOrg))
+}
+
+var (
+	geoMu    sync.RWMutex
+	geoDBs   []*mmdbReader
+	geoCache = make(map[string]GeoInfo)
+)
+
+// geoCacheSize bounds the lookup cache, which is emptied when full
+const geoCacheSize = 50000
+
+// LoadGeoIP opens the MaxMind-format databases client IPs are looked up in.
+// Country (or City) and ASN data usually ship as separate files; either
+// path may be empty. Lookups never leave the machine.
+func LoadGeoIP(paths ...string) error {
+	var dbs []*mmdbReader
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		db, err := openMMDB(path)
+		if err != nil {
+			return fmt.Errorf("failed to open GeoIP database: %w", err)
+		}
+		dbs = append(dbs, db)
+	}
+
+	geoMu.Lock()
+	defer geoMu.Unlock()
+	geoDBs = dbs
+	geoCache = make(map[string]GeoInfo)
+	return nil
+}
+
+// GeoIPEnabled reports whether a GeoIP database is loaded
+func GeoIPEnabled() bool {
+	geoMu.RLock()
+	defer geoMu.RUnlock()
+	return len(geoDBs) > 0
+}
+
+// LookupGeo returns the country and autonomous system of an address, empty
+// when no database is loaded or none of them knows it
+func LookupGeo(addr string) GeoInfo {
+	geoMu.RLock()
+	info, ok := geoCache[addr]
+	dbs := geoDBs
+	geoMu.RUnlock()
+	if ok || len(dbs) == 0 {
+		return info
+	}
+
+	if ip := net.ParseIP(addr); ip != nil {
+		for _, db := range dbs {
+			record, err := db.lookup(ip)
+			if err != nil {
+				continue
+			}
+			if fields, ok := record.(map[string]any); ok {
+				mergeGeoFields(&info, fields)
+			}
+		}
+	}
+
+	geoMu.Lock()
+	if len(geoCache) >= geoCacheSize {
+		geoCache = make(map[string]GeoInfo)
+	}
+	geoCache[addr] = info
+	geoMu.Unlock()
+	return info
+}
+
+// mergeGeoFields fills in the fields a record knows and info doesn't yet.
+// It reads the MaxMind and DB-IP layout as well as IPinfo's flat one.
+func mergeGeoFields(info *GeoInfo, fields map[string]any) {
+	for _, key := range []string{"country", "registered_country"} {
+		country, ok := fields[key].(map[string]any)
+		if !ok || info.Country != "" {
+			continue
+		}
+		info.Country, _ = country["iso_code"].(string)
+		if names, ok := country["names"].(map[string]any); ok {
+			info.CountryName, _ = names["en"].(string)
+		}
+	}
+	if info.Country == "" {
+		info.Country, _ = fields["country_code"].(string)
+		if name, ok := fields["country"].(string); ok {
+			info.CountryName = name
+		}
+	}
+
+	if info.ASN == 0 {
+		info.ASN = mmdbUint(fields["autonomous_system_number"])
+		info.ASOrg, _ = fields["autonomous_system_organization"].(string)
+	}
+	if asn, ok := fields["asn"].(string); ok && info.ASN == 0 {
+		n, _ := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(asn), "AS"), 10, 32)
+		info.ASN = uint(n)
+		info.ASOrg, _ = fields["as_name"].(string)
+	}
+}
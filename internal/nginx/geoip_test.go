@@ -0,0 +1,180 @@
+package nginx
+
+import (
+	"net"
+	"testing"
+)
+
+// The fixtures in testdata hold:
+//
+//	geo-country-v6.mmdb  IPv6 tree, 24-bit records, MaxMind layout:
+//	                     1.2.3.0/24 country DE (through a pointer),
+//	                     1.2.4.0/24 registered_country DE (through a pointer),
+//	                     2001:db8::/32 country AT with AS3320
+//	geo-ipinfo-v4.mmdb   IPv4 tree, 28-bit records, IPinfo layout:
+//	                     8.8.8.0/24 US AS15169, 9.9.9.0/24 CH AS19281
+//	geo-asn-v6-32.mmdb   IPv6 tree, 32-bit records, MaxMind ASN layout:
+//	                     1.2.3.0/24 AS3320
+
+func TestOpenMMDB(t *testing.T) {
+	tests := []struct {
+		path       string
+		ipVersion  uint
+		recordSize uint
+	}{
+		{"testdata/geo-country-v6.mmdb", 6, 24},
+		{"testdata/geo-ipinfo-v4.mmdb", 4, 28},
+		{"testdata/geo-asn-v6-32.mmdb", 6, 32},
+	}
+	for _, tt := range tests {
+		r, err := openMMDB(tt.path)
+		if err != nil {
+			t.Fatalf("openMMDB(%s): %v", tt.path, err)
+		}
+		if r.ipVersion != tt.ipVersion || r.recordSize != tt.recordSize {
+			t.Errorf("%s: ip_version %d record_size %d, want %d and %d", tt.path, r.ipVersion, r.recordSize, tt.ipVersion, tt.recordSize)
+		}
+		if r.databaseType != "ngxtui-Test" {
+			t.Errorf("%s: database_type %q", tt.path, r.databaseType)
+		}
+	}
+
+	if _, err := openMMDB("testdata/missing.mmdb"); err == nil {
+		t.Error("openMMDB of a missing file succeeded")
+	}
+	if _, err := openMMDB("geoip_test.go"); err == nil {
+		t.Error("openMMDB of a file without metadata succeeded")
+	}
+}
+
+func TestMMDBLookup(t *testing.T) {
+	tests := []struct {
+		path   string
+		ip     string
+		key    string // Field the record must have; empty for a miss
+		subkey string
+		want   any
+	}{
+		// IPv4 addresses in an IPv6 tree go through the ::/96 subtree
+		{"testdata/geo-country-v6.mmdb", "1.2.3.4", "country", "iso_code", "DE"},
+		{"testdata/geo-country-v6.mmdb", "1.2.4.200", "registered_country", "iso_code", "DE"},
+		{"testdata/geo-country-v6.mmdb", "2001:db8:1::1", "autonomous_system_number", "", uint64(3320)},
+		{"testdata/geo-country-v6.mmdb", "1.2.5.1", "", "", nil},
+		{"testdata/geo-country-v6.mmdb", "2001:db9::1", "", "", nil},
+		{"testdata/geo-ipinfo-v4.mmdb", "8.8.8.8", "asn", "", "AS15169"},
+		{"testdata/geo-ipinfo-v4.mmdb", "9.9.9.9", "country_code", "", "CH"},
+		{"testdata/geo-ipinfo-v4.mmdb", "8.8.4.4", "", "", nil},
+		{"testdata/geo-ipinfo-v4.mmdb", "2001:db8::1", "", "", nil},
+		{"testdata/geo-asn-v6-32.mmdb", "1.2.3.4", "autonomous_system_organization", "", "Deutsche Telekom AG"},
+	}
+	for _, tt := range tests {
+		r, err := openMMDB(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		record, err := r.lookup(net.ParseIP(tt.ip))
+		if err != nil {
+			t.Errorf("%s %s: %v", tt.path, tt.ip, err)
+			continue
+		}
+		if tt.key == "" {
+			if record != nil {
+				t.Errorf("%s %s: got %v, want no record", tt.path, tt.ip, record)
+			}
+			continue
+		}
+		fields, ok := record.(map[string]any)
+		if !ok {
+			t.Errorf("%s %s: got %v, want a map", tt.path, tt.ip, record)
+			continue
+		}
+		got := fields[tt.key]
+		if tt.subkey != "" {
+			sub, _ := got.(map[string]any)
+			got = sub[tt.subkey]
+		}
+		if got != tt.want {
+			t.Errorf("%s %s: %s = %v, want %v", tt.path, tt.ip, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestMergeGeoFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []map[string]any
+		want    GeoInfo
+	}{
+		{
+			name: "maxmind country and asn",
+			records: []map[string]any{
+				{"country": map[string]any{"iso_code": "DE", "names": map[string]any{"en": "Germany"}}},
+				{"autonomous_system_number": uint64(3320), "autonomous_system_organization": "Deutsche Telekom AG"},
+			},
+			want: GeoInfo{Country: "DE", CountryName: "Germany", ASN: 3320, ASOrg: "Deutsche Telekom AG"},
+		},
+		{
+			name: "registered country when country is missing",
+			records: []map[string]any{
+				{"registered_country": map[string]any{"iso_code": "FR", "names": map[string]any{"en": "France"}}},
+			},
+			want: GeoInfo{Country: "FR", CountryName: "France"},
+		},
+		{
+			name: "ipinfo flat layout",
+			records: []map[string]any{
+				{"country_code": "US", "country": "United States", "asn": "AS15169", "as_name": "Google LLC"},
+			},
+			want: GeoInfo{Country: "US", CountryName: "United States", ASN: 15169, ASOrg: "Google LLC"},
+		},
+		{
+			name: "first database wins",
+			records: []map[string]any{
+				{"country": map[string]any{"iso_code": "AT"}},
+				{"country_code": "US", "country": "United States"},
+			},
+			want: GeoInfo{Country: "AT"},
+		},
+	}
+	for _, tt := range tests {
+		var info GeoInfo
+		for _, record := range tt.records {
+			mergeGeoFields(&info, record)
+		}
+		if info != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, info, tt.want)
+		}
+	}
+}
+
+func TestCountryQuery(t *testing.T) {
+	if err := LoadGeoIP("testdata/geo-country-v6.mmdb", "testdata/geo-ipinfo-v4.mmdb"); err != nil {
+		t.Fatal(err)
+	}
+	defer LoadGeoIP()
+
+	tests := []struct {
+		expr string
+		ip   string
+		want bool
+	}{
+		{"country:DE", "1.2.3.4", true},
+		{"country:de", "1.2.3.4", true},
+		{"country:D", "1.2.3.4", false},
+		{"country:E", "1.2.3.4", false},
+		{"country:AT,DE", "1.2.3.4", true},
+		{"country:US", "8.8.8.8", true},
+		{"country:US", "10.0.0.1", false},
+		{"country:D*", "1.2.3.4", true},
+		{"-country:DE", "8.8.8.8", true},
+	}
+	for _, tt := range tests {
+		q, err := ParseLogQuery(tt.expr)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.expr, err)
+		}
+		if got := q.Match(LogEntry{IP: tt.ip}); got != tt.want {
+			t.Errorf("%s on %s = %v, want %v", tt.expr, tt.ip, got, tt.want)
+		}
+	}
+}
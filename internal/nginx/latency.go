@@ -0,0 +1,341 @@
+package nginx
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LatencyWindows are the sliding windows live latency is reported over
+var LatencyWindows = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+// LatencySummary holds the percentiles of a set of timings
+type LatencySummary struct {
+	Count         int
+	P50, P90, P99 time.Duration
+	Max           time.Duration
+}
+
+// LatencyRow is the request and upstream latency of one site, path prefix or upstream
+type LatencyRow struct {
+	Name     string
+	Request  LatencySummary // $request_time
+	Upstream LatencySummary // $upstream_response_time
+}
+
+// LatencyReport breaks latency down overall and per site, path prefix and
+// upstream server. Rows are ordered by request count, busiest first.
+type LatencyReport struct {
+	Window    time.Duration // Zero when the report covers a time range instead
+	Overall   LatencyRow
+	Sites     []LatencyRow
+	Prefixes  []LatencyRow
+	Upstreams []LatencyRow
+}
+
+// Timed reports whether any entry carried $request_time or $upstream_response_time
+func (r *LatencyReport) Timed() bool {
+	return r != nil && (r.Overall.Request.Count > 0 || r.Overall.Upstream.Count > 0)
+}
+
+// ComputeLatency reports latency over the entries logged since the given time
+func ComputeLatency(entries []LogEntry, since time.Time) *LatencyReport {
+	builder := NewLatencyBuilder()
+	for _, entry := range entries {
+		if !entry.Timestamp.Before(since) {
+			builder.Add(entry)
+		}
+	}
+	return builder.Report()
+}
+
+// LiveLatency collects tailed timings per second over the longest of
+// LatencyWindows, so each window spans its whole duration however many
+// entries the log view keeps. Entries are counted under the second they were
+// logged, not when they were read.
+type LiveLatency struct {
+	buckets map[int64]*LatencyBuilder // By Unix second
+}
+
+// NewLiveLatency creates an empty collector
+func NewLiveLatency() *LiveLatency {
+	return &LiveLatency{buckets: make(map[int64]*LatencyBuilder)}
+}
+
+// Add collects tailed entries and forgets seconds that left the longest window
+func (l *LiveLatency) Add(entries []LogEntry, now time.Time) {
+	oldest := now.Add(-LatencyWindows[len(LatencyWindows)-1]).Unix()
+	for _, entry := range entries {
+		second := entry.Timestamp.Unix()
+		if entry.ParseErr != nil || second <= oldest {
+			continue
+		}
+		builder, ok := l.buckets[second]
+		if !ok {
+			builder = NewLatencyBuilder()
+			l.buckets[second] = builder
+		}
+		builder.Add(entry)
+	}
+	for second := range l.buckets {
+		if second <= oldest {
+			delete(l.buckets, second)
+		}
+	}
+}
+
+// Windows reports latency over each of LatencyWindows, ending now
+func (l *LiveLatency) Windows(now time.Time) []*LatencyReport {
+	// The windows are nested, so each one adds the seconds beyond the last
+	builder := NewLatencyBuilder()
+	reports := make([]*LatencyReport, len(LatencyWindows))
+	newest := now.Unix()
+	for i, window := range LatencyWindows {
+		oldest := now.Add(-window).Unix()
+		if l != nil {
+			for second, bucket := range l.buckets {
+				if second > oldest && second <= newest {
+					builder.merge(bucket)
+				}
+			}
+		}
+		newest = oldest
+		reports[i] = builder.Report()
+		reports[i].Window = window
+	}
+	return reports
+}
+
+// latencyTimings counts timings by value. NGINX logs them with millisecond
+// resolution, so there are few distinct values and percentiles stay exact
+// however many requests are counted.
+type latencyTimings struct {
+	counts map[time.Duration]int
+	total  int
+}
+
+func (t *latencyTimings) add(d time.Duration) {
+	if t.counts == nil {
+		t.counts = make(map[time.Duration]int)
+	}
+	t.counts[d]++
+	t.total++
+}
+
+// merge adds another set of timings
+func (t *latencyTimings) merge(o latencyTimings) {
+	if o.total == 0 {
+		return
+	}
+	if t.counts == nil {
+		t.counts = make(map[time.Duration]int)
+	}
+	for d, n := range o.counts {
+		t.counts[d] += n
+	}
+	t.total += o.total
+}
+
+// summary computes nearest-rank percentiles over the timings
+func (t *latencyTimings) summary() LatencySummary {
+	if t.total == 0 {
+		return LatencySummary{}
+	}
+	values := make([]time.Duration, 0, len(t.counts))
+	for d := range t.counts {
+		values = append(values, d)
+	}
+	slices.Sort(values)
+
+	percentile := func(p float64) time.Duration {
+		rank := int(p*float64(t.total) + 0.999999)
+		seen := 0
+		for _, d := range values {
+			seen += t.counts[d]
+			if seen >= rank {
+				return d
+			}
+		}
+		return values[len(values)-1]
+	}
+	return LatencySummary{
+		Count: t.total,
+		P50:   percentile(0.50),
+		P90:   percentile(0.90),
+		P99:   percentile(0.99),
+		Max:   values[len(values)-1],
+	}
+}
+
+// latencyGroup collects the request and upstream timings of one row
+type latencyGroup struct {
+	request, upstream latencyTimings
+}
+
+// LatencyBuilder collects timings one entry at a time, so latency over logs
+// of any size can be computed while they are streamed
+type LatencyBuilder struct {
+	overall   latencyGroup
+	sites     map[string]*latencyGroup
+	prefixes  map[string]*latencyGroup
+	upstreams map[string]*latencyGroup
+}
+
+// NewLatencyBuilder creates an empty builder
+func NewLatencyBuilder() *LatencyBuilder {
+	return &LatencyBuilder{
+		sites:     make(map[string]*latencyGroup),
+		prefixes:  make(map[string]*latencyGroup),
+		upstreams: make(map[string]*latencyGroup),
+	}
+}
+
+// Add counts an entry's timings. Entries whose log_format has neither
+// $request_time nor $upstream_response_time are skipped.
+func (b *LatencyBuilder) Add(entry LogEntry) {
+	if entry.ParseErr != nil {
+		return
+	}
+	requestTime, hasRequest := loggedTime(entry.Vars, "request_time")
+	upstreamTime, hasUpstream := loggedTime(entry.Vars, "upstream_response_time")
+	if !hasRequest && !hasUpstream {
+		return
+	}
+
+	groups := []*latencyGroup{&b.overall, latencyGroupFor(b.prefixes, PathPrefix(entry.Path))}
+	if entry.Site != "" {
+		groups = append(groups, latencyGroupFor(b.sites, entry.Site))
+	}
+	for _, g := range groups {
+		if hasRequest {
+			g.request.add(requestTime)
+		}
+		if hasUpstream {
+			g.upstream.add(upstreamTime)
+		}
+	}
+
+	// Each upstream server tried is timed on its own
+	for addr, d := range upstreamTimings(entry.Vars) {
+		g := latencyGroupFor(b.upstreams, addr)
+		g.upstream.add(d)
+		if hasRequest {
+			g.request.add(requestTime)
+		}
+	}
+}
+
+// merge adds the timings collected by another builder
+func (b *LatencyBuilder) merge(o *LatencyBuilder) {
+	b.overall.merge(&o.overall)
+	mergeLatencyGroups(b.sites, o.sites)
+	mergeLatencyGroups(b.prefixes, o.prefixes)
+	mergeLatencyGroups(b.upstreams, o.upstreams)
+}
+
+// mergeLatencyGroups adds the timings of each group in src to dst
+func mergeLatencyGroups(dst, src map[string]*latencyGroup) {
+	for name, g := range src {
+		latencyGroupFor(dst, name).merge(g)
+	}
+}
+
+// Report returns the latency collected so far
+func (b *LatencyBuilder) Report() *LatencyReport {
+	return &LatencyReport{
+		Overall:   b.overall.row(""),
+		Sites:     latencyRows(b.sites),
+		Prefixes:  latencyRows(b.prefixes),
+		Upstreams: latencyRows(b.upstreams),
+	}
+}
+
+func (g *latencyGroup) merge(o *latencyGroup) {
+	g.request.merge(o.request)
+	g.upstream.merge(o.upstream)
+}
+
+func (g *latencyGroup) row(name string) LatencyRow {
+	return LatencyRow{Name: name, Request: g.request.summary(), Upstream: g.upstream.summary()}
+}
+
+// latencyGroupFor returns the named group, creating it on first use
+func latencyGroupFor(groups map[string]*latencyGroup, name string) *latencyGroup {
+	g, ok := groups[name]
+	if !ok {
+		g = &latencyGroup{}
+		groups[name] = g
+	}
+	return g
+}
+
+// latencyRows summarises groups, busiest first
+func latencyRows(groups map[string]*latencyGroup) []LatencyRow {
+	rows := make([]LatencyRow, 0, len(groups))
+	for name, g := range groups {
+		rows = append(rows, g.row(name))
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		ci := max(rows[i].Request.Count, rows[i].Upstream.Count)
+		cj := max(rows[j].Request.Count, rows[j].Upstream.Count)
+		if ci != cj {
+			return ci > cj
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	return rows
+}
+
+// PathPrefix returns the first segment of a request path: "/api/users/1?x=y" is "/api"
+func PathPrefix(path string) string {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	if !strings.HasPrefix(path, "/") {
+		return "/"
+	}
+	if i := strings.Index(path[1:], "/"); i >= 0 {
+		return path[:i+1]
+	}
+	return path
+}
+
+// loggedTime returns a timing variable, if the line logged one. "-" means
+// the request never got that far, e.g. no upstream was contacted.
+func loggedTime(vars map[string]string, name string) (time.Duration, bool) {
+	value, ok := vars[name]
+	if !ok || value == "" || value == "-" {
+		return 0, false
+	}
+	return parseLogSeconds(value).Round(time.Microsecond), true
+}
+
+// upstreamListRe splits $upstream_addr and $upstream_response_time: servers
+// tried within a group are separated by commas, internal redirects to
+// another group by colons
+var upstreamListRe = regexp.MustCompile(`\s*,\s*|\s+:\s+`)
+
+// upstreamTimings pairs each address in $upstream_addr with its time in
+// $upstream_response_time. Servers tried more than once are summed.
+func upstreamTimings(vars map[string]string) map[string]time.Duration {
+	addrs, times := vars["upstream_addr"], vars["upstream_response_time"]
+	if addrs == "" || addrs == "-" || times == "" || times == "-" {
+		return nil
+	}
+	addrList := upstreamListRe.Split(strings.TrimSpace(addrs), -1)
+	timeList := upstreamListRe.Split(strings.TrimSpace(times), -1)
+	if len(addrList) != len(timeList) {
+		return nil
+	}
+
+	timings := make(map[string]time.Duration)
+	for i, addr := range addrList {
+		if addr == "" || timeList[i] == "-" {
+			continue
+		}
+		timings[addr] += parseLogSeconds(timeList[i]).Round(time.Microsecond)
+	}
+	return timings
+}
@@ -0,0 +1,226 @@
+package nginx
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// timedEntry is a parsed entry with the timing variables a custom log_format logs
+func timedEntry(at time.Time, site, path, requestTime, upstreamAddr, upstreamTime string) LogEntry {
+	vars := map[string]string{}
+	if requestTime != "" {
+		vars["request_time"] = requestTime
+	}
+	if upstreamAddr != "" {
+		vars["upstream_addr"] = upstreamAddr
+	}
+	if upstreamTime != "" {
+		vars["upstream_response_time"] = upstreamTime
+	}
+	return LogEntry{Timestamp: at, Site: site, Path: path, Vars: vars}
+}
+
+func TestLatencyPercentiles(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name    string
+		timings []time.Duration
+		want    LatencySummary
+	}{
+		{"empty", nil, LatencySummary{}},
+		{"one", []time.Duration{7 * ms}, LatencySummary{Count: 1, P50: 7 * ms, P90: 7 * ms, P99: 7 * ms, Max: 7 * ms}},
+		{
+			// Nearest rank: p50 is the 50th value, p90 the 90th, p99 the 99th
+			"1 to 100ms",
+			func() (d []time.Duration) {
+				for i := 100; i >= 1; i-- {
+					d = append(d, time.Duration(i)*ms)
+				}
+				return d
+			}(),
+			LatencySummary{Count: 100, P50: 50 * ms, P90: 90 * ms, P99: 99 * ms, Max: 100 * ms},
+		},
+		{
+			// A slow tail smaller than 1% shows in the max only
+			"repeated values",
+			append(repeat(10*ms, 995), repeat(2*time.Second, 5)...),
+			LatencySummary{Count: 1000, P50: 10 * ms, P90: 10 * ms, P99: 10 * ms, Max: 2 * time.Second},
+		},
+		{
+			"tail at p99",
+			append(repeat(10*ms, 98), repeat(500*ms, 2)...),
+			LatencySummary{Count: 100, P50: 10 * ms, P90: 10 * ms, P99: 500 * ms, Max: 500 * ms},
+		},
+	}
+	for _, tt := range tests {
+		var timings latencyTimings
+		for _, d := range tt.timings {
+			timings.add(d)
+		}
+		if got := timings.summary(); got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func repeat(d time.Duration, n int) []time.Duration {
+	out := make([]time.Duration, n)
+	for i := range out {
+		out[i] = d
+	}
+	return out
+}
+
+func TestComputeLatency(t *testing.T) {
+	now := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+	entries := []LogEntry{
+		timedEntry(now.Add(-time.Hour), "shop", "/old", "9.000", "", ""),
+		timedEntry(now, "shop", "/api/cart?id=1", "0.120", "10.0.0.1:80", "0.100"),
+		timedEntry(now, "shop", "/api/cart", "0.300", "10.0.0.1:80, 10.0.0.2:80", "0.050, 0.200"),
+		timedEntry(now, "blog", "/posts/1", "0.010", "-", "-"),
+		timedEntry(now, "blog", "/", "", "", ""), // Format without timings
+		{Timestamp: now, ParseErr: fmt.Errorf("garbage")},
+	}
+	report := ComputeLatency(entries, now.Add(-time.Minute))
+
+	if !report.Timed() {
+		t.Fatal("report has no timings")
+	}
+	overall := report.Overall
+	if overall.Request.Count != 3 || overall.Request.Max != 300*time.Millisecond {
+		t.Errorf("overall request = %+v", overall.Request)
+	}
+	// The second request's upstream time is summed over both servers tried
+	if overall.Upstream.Count != 2 || overall.Upstream.Max != 250*time.Millisecond {
+		t.Errorf("overall upstream = %+v", overall.Upstream)
+	}
+
+	rows := func(rows []LatencyRow) map[string]LatencyRow {
+		m := map[string]LatencyRow{}
+		for _, r := range rows {
+			m[r.Name] = r
+		}
+		return m
+	}
+	if sites := rows(report.Sites); sites["shop"].Request.Count != 2 || sites["blog"].Request.Count != 1 {
+		t.Errorf("sites = %+v", report.Sites)
+	}
+	if report.Sites[0].Name != "shop" {
+		t.Errorf("busiest site first: %+v", report.Sites)
+	}
+	if prefixes := rows(report.Prefixes); prefixes["/api"].Request.Count != 2 || prefixes["/posts"].Request.Count != 1 {
+		t.Errorf("prefixes = %+v", report.Prefixes)
+	}
+	upstreams := rows(report.Upstreams)
+	if u := upstreams["10.0.0.1:80"]; u.Upstream.Count != 2 || u.Upstream.Max != 100*time.Millisecond {
+		t.Errorf("upstream 10.0.0.1:80 = %+v", u)
+	}
+	if u := upstreams["10.0.0.2:80"]; u.Upstream.Count != 1 || u.Upstream.P50 != 200*time.Millisecond {
+		t.Errorf("upstream 10.0.0.2:80 = %+v", u)
+	}
+	if _, ok := upstreams["-"]; ok {
+		t.Error("requests without an upstream counted as one")
+	}
+
+	if ComputeLatency(nil, now).Timed() || (*LatencyReport)(nil).Timed() {
+		t.Error("empty report claims timings")
+	}
+}
+
+func TestUpstreamTimings(t *testing.T) {
+	tests := []struct {
+		addrs, times string
+		want         map[string]time.Duration
+	}{
+		{"10.0.0.1:80", "0.250", map[string]time.Duration{"10.0.0.1:80": 250 * time.Millisecond}},
+		{"10.0.0.1:80, 10.0.0.2:80", "0.010, 0.020", map[string]time.Duration{"10.0.0.1:80": 10 * time.Millisecond, "10.0.0.2:80": 20 * time.Millisecond}},
+		{"10.0.0.1:80 : 10.0.0.3:80", "0.010 : 0.030", map[string]time.Duration{"10.0.0.1:80": 10 * time.Millisecond, "10.0.0.3:80": 30 * time.Millisecond}},
+		{"10.0.0.1:80, 10.0.0.1:80", "0.010, 0.015", map[string]time.Duration{"10.0.0.1:80": 25 * time.Millisecond}},
+		{"10.0.0.1:80, 10.0.0.2:80", "-, 0.020", map[string]time.Duration{"10.0.0.2:80": 20 * time.Millisecond}},
+		{"10.0.0.1:80, 10.0.0.2:80", "0.020", nil},
+		{"-", "-", nil},
+	}
+	for _, tt := range tests {
+		got := upstreamTimings(map[string]string{"upstream_addr": tt.addrs, "upstream_response_time": tt.times})
+		if len(got) != len(tt.want) {
+			t.Errorf("%q %q = %v, want %v", tt.addrs, tt.times, got, tt.want)
+			continue
+		}
+		for addr, d := range tt.want {
+			if got[addr] != d {
+				t.Errorf("%q %q = %v, want %v", tt.addrs, tt.times, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestPathPrefix(t *testing.T) {
+	tests := map[string]string{
+		"/api/users/1?x=y": "/api",
+		"/api?x=/y":        "/api",
+		"/":                "/",
+		"/index.html":      "/index.html",
+		"/docs#intro":      "/docs",
+		"*":                "/",
+		"":                 "/",
+	}
+	for path, want := range tests {
+		if got := PathPrefix(path); got != want {
+			t.Errorf("PathPrefix(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestLiveLatencyWindows(t *testing.T) {
+	now := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+	live := NewLiveLatency()
+
+	// One request every 10 seconds over the last 20 minutes, fed in two
+	// batches. Each takes a millisecond per second of age.
+	var first, second []LogEntry
+	for age := 20 * time.Minute; age >= 0; age -= 10 * time.Second {
+		entry := timedEntry(now.Add(-age), "shop", "/", fmt.Sprintf("%.3f", age.Seconds()/1000), "", "")
+		if age > 10*time.Minute {
+			first = append(first, entry)
+		} else {
+			second = append(second, entry)
+		}
+	}
+	live.Add(first, now.Add(-10*time.Minute))
+	live.Add(second, now)
+
+	reports := live.Windows(now)
+	if len(reports) != len(LatencyWindows) {
+		t.Fatalf("got %d reports, want %d", len(reports), len(LatencyWindows))
+	}
+	// Windows are open at their start: the last minute holds ages 0s to 50s
+	want := []struct {
+		count int
+		max   time.Duration
+	}{
+		{6, 50 * time.Millisecond},
+		{30, 290 * time.Millisecond},
+		{90, 890 * time.Millisecond},
+	}
+	for i, report := range reports {
+		if report.Window != LatencyWindows[i] {
+			t.Errorf("report %d covers %v, want %v", i, report.Window, LatencyWindows[i])
+		}
+		got := report.Overall.Request
+		if got.Count != want[i].count || got.Max != want[i].max {
+			t.Errorf("%v window: %d requests, max %v; want %d, %v", report.Window, got.Count, got.Max, want[i].count, want[i].max)
+		}
+	}
+
+	// Seconds past the longest window are forgotten as time moves on
+	live.Add(nil, now.Add(15*time.Minute))
+	if reports := live.Windows(now.Add(15 * time.Minute)); reports[2].Timed() {
+		t.Errorf("old seconds kept: %+v", reports[2].Overall)
+	}
+
+	var none *LiveLatency
+	if reports := none.Windows(now); len(reports) != len(LatencyWindows) || reports[0].Timed() {
+		t.Error("nil collector reports timings")
+	}
+}
@@ -0,0 +1,136 @@
+package nginx
+
+import "time"
+
+// LiveTrafficWindow is how far back live request rates are measured
+const LiveTrafficWindow = time.Minute
+
+// LiveTraffic counts tailed requests per second and per key over the last
+// LiveTrafficWindow, so rates stay exact however busy the logs are. Entries
+// are counted under the second they were logged, not when they were read.
+type LiveTraffic struct {
+	buckets map[int64]*liveBucket // By Unix second
+}
+
+// liveBucket is the traffic logged within one second
+type liveBucket struct {
+	total TrafficCount
+	keys  map[TrafficDimension]map[string]TrafficCount
+}
+
+// LiveTrafficRow is a key's traffic over the window
+type LiveTrafficRow struct {
+	TrafficRow
+	Rate  float64   // Requests per second over the window
+	Spark []float64 // Requests in each second of the window, oldest first
+}
+
+// NewLiveTraffic creates an empty counter
+func NewLiveTraffic() *LiveTraffic {
+	return &LiveTraffic{buckets: make(map[int64]*liveBucket)}
+}
+
+// Add counts tailed entries and forgets seconds that left the window
+func (t *LiveTraffic) Add(entries []LogEntry, now time.Time) {
+	oldest := now.Add(-LiveTrafficWindow).Unix()
+	for _, entry := range entries {
+		second := entry.Timestamp.Unix()
+		if entry.ParseErr != nil || second <= oldest {
+			continue
+		}
+		bucket, ok := t.buckets[second]
+		if !ok {
+			bucket = &liveBucket{keys: make(map[TrafficDimension]map[string]TrafficCount)}
+			t.buckets[second] = bucket
+		}
+		bucket.total.add(entry)
+		addTraffic(bucket.keys, entry)
+	}
+	for second := range t.buckets {
+		if second <= oldest {
+			delete(t.buckets, second)
+		}
+	}
+}
+
+// seconds returns the Unix seconds of the window ending now, oldest first
+func (t *LiveTraffic) seconds(now time.Time) []int64 {
+	n := int(LiveTrafficWindow / time.Second)
+	last := now.Unix()
+	seconds := make([]int64, n)
+	for i := range seconds {
+		seconds[i] = last - int64(n-1-i)
+	}
+	return seconds
+}
+
+// Total returns every request over the window as a row with an empty key
+func (t *LiveTraffic) Total(now time.Time) LiveTrafficRow {
+	var row LiveTrafficRow
+	for _, second := range t.seconds(now) {
+		var count TrafficCount
+		if t != nil {
+			if bucket, ok := t.buckets[second]; ok {
+				count = bucket.total
+			}
+		}
+		row.Spark = append(row.Spark, float64(count.Requests))
+		row.merge(count)
+	}
+	row.Rate = float64(row.Requests) / LiveTrafficWindow.Seconds()
+	return row
+}
+
+// Rate returns requests per second over the window
+func (t *LiveTraffic) Rate(now time.Time) float64 {
+	return t.Total(now).Rate
+}
+
+// Top returns the first n keys of a dimension over the window in the given
+// order, or every key when n is 0
+func (t *LiveTraffic) Top(d TrafficDimension, order TrafficSort, n int, now time.Time) []LiveTrafficRow {
+	if t == nil {
+		return nil
+	}
+	seconds := t.seconds(now)
+	byKey := make(map[string]*LiveTrafficRow)
+	for i, second := range seconds {
+		bucket, ok := t.buckets[second]
+		if !ok {
+			continue
+		}
+		for key, count := range bucket.keys[d] {
+			row, ok := byKey[key]
+			if !ok {
+				row = &LiveTrafficRow{TrafficRow: TrafficRow{Key: key}, Spark: make([]float64, len(seconds))}
+				byKey[key] = row
+			}
+			row.merge(count)
+			row.Spark[i] = float64(count.Requests)
+		}
+	}
+
+	// Sort the plain rows, then attach the rates and sparklines again
+	rows := make([]TrafficRow, 0, len(byKey))
+	for _, row := range byKey {
+		rows = append(rows, row.TrafficRow)
+	}
+	SortTrafficRows(rows, order)
+	if n > 0 && len(rows) > n {
+		rows = rows[:n]
+	}
+	live := make([]LiveTrafficRow, len(rows))
+	for i, row := range rows {
+		live[i] = *byKey[row.Key]
+		live[i].Rate = float64(row.Requests) / LiveTrafficWindow.Seconds()
+	}
+	return live
+}
+
+// merge adds a second's counts to the row
+func (row *LiveTrafficRow) merge(count TrafficCount) {
+	row.Requests += count.Requests
+	row.Bytes += count.Bytes
+	row.ClientErrors += count.ClientErrors
+	row.ServerErrors += count.ServerErrors
+}
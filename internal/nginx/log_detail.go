@@ -0,0 +1,90 @@
+package nginx
+
+import (
+	"strings"
+	"time"
+)
+
+// RelatedErrors returns the error log entries written while the request was
+// handled: same client and URI, from its start up to when the access log
+// line was written. Without $request_time the request may have run for up to
+// a typical proxy timeout. The errors are returned oldest first.
+func (e LogEntry) RelatedErrors(errors []ErrorLogEntry) []ErrorLogEntry {
+	if e.ParseErr != nil || e.IP == "" || e.Timestamp.IsZero() {
+		return nil
+	}
+	// Both logs are written with second precision
+	since := e.Timestamp.Add(-accessLogLag)
+	if e.RequestTime > 0 {
+		since = e.Timestamp.Add(-e.RequestTime - time.Second)
+	}
+	until := e.Timestamp.Add(time.Second)
+
+	var related []ErrorLogEntry
+	for _, entry := range errors {
+		if entry.ParseErr != nil || entry.Client != e.IP {
+			continue
+		}
+		if entry.Timestamp.Before(since) || entry.Timestamp.After(until) {
+			continue
+		}
+		if path := entry.RequestPath(); path != "" && e.Path != "" && path != e.Path {
+			continue
+		}
+		related = append(related, entry)
+	}
+	return related
+}
+
+// Scheme returns the scheme the request was made over when the log_format
+// records it ($scheme, $https or $server_port), or fallback otherwise
+func (e LogEntry) Scheme(fallback string) string {
+	switch {
+	case e.Vars["scheme"] != "":
+		return e.Vars["scheme"]
+	case e.Vars["https"] == "on", e.Vars["server_port"] == "443":
+		return "https"
+	case e.Vars["https"] != "", e.Vars["server_port"] == "80":
+		return "http"
+	}
+	return fallback
+}
+
+// CurlCommand returns a curl command line that repeats the request with the
+// same method, host, user agent and referer. Request bodies and cookies
+// aren't logged, so they can't be repeated.
+func (e LogEntry) CurlCommand(scheme string) string {
+	host := e.Host
+	if host == "" {
+		host = e.Vars["http_host"]
+	}
+	if host == "" || host == "-" {
+		host = "localhost"
+	}
+	path := e.Path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	args := []string{"curl"}
+	switch e.Method {
+	case "", "GET":
+	case "HEAD":
+		args = append(args, "--head")
+	default:
+		args = append(args, "-X", e.Method)
+	}
+	args = append(args, shellQuote(scheme+"://"+host+path))
+	if e.UserAgent != "" && e.UserAgent != "-" {
+		args = append(args, "-A", shellQuote(e.UserAgent))
+	}
+	if e.Referer != "" && e.Referer != "-" {
+		args = append(args, "-e", shellQuote(e.Referer))
+	}
+	return strings.Join(args, " ")
+}
+
+// shellQuote quotes s for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,320 @@
+package nginx
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CombinedLogFormat is the format NGINX predefines as "combined"
+const CombinedLogFormat = `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent"`
+
+// DefaultAccessLog is used when the configuration can't be read or declares no access_log
+const DefaultAccessLog = "/var/log/nginx/access.log"
+
+// LogFormat is a compiled log_format definition
+type LogFormat struct {
+	Name   string
+	Format string
+	Escape string   // "default", "json" or "none"
+	Vars   []string // Variable names in the order they appear
+
+	// JSONFields maps keys of JSON log lines to variable names
+	JSONFields map[string]string
+
+	re *regexp.Regexp
+}
+
+// logVarRe matches $name and ${name} in a log_format string
+var logVarRe = regexp.MustCompile(`\$(?:\{(\w+)\}|(\w+))`)
+
+// CompileLogFormat compiles a log_format string into a line parser
+func CompileLogFormat(name, format, escape string) (*LogFormat, error) {
+	if escape == "" {
+		escape = "default"
+	}
+	f := &LogFormat{Name: name, Format: format, Escape: escape, JSONFields: inferJSONFields(format)}
+
+	// Literal text must match exactly; each variable captures lazily up to
+	// the next literal so values containing spaces or ", " lists still parse
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	last := 0
+	for _, loc := range logVarRe.FindAllStringSubmatchIndex(format, -1) {
+		pattern.WriteString(regexp.QuoteMeta(format[last:loc[0]]))
+		varName := ""
+		if loc[2] >= 0 {
+			varName = format[loc[2]:loc[3]]
+		} else {
+			varName = format[loc[4]:loc[5]]
+		}
+		f.Vars = append(f.Vars, varName)
+		pattern.WriteString("(.*?)")
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(format[last:]))
+	pattern.WriteString("$")
+
+	if len(f.Vars) == 0 {
+		return nil, fmt.Errorf("log_format %s has no variables", name)
+	}
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile log_format %s: %w", name, err)
+	}
+	f.re = re
+	return f, nil
+}
+
+// combinedLogFormat is the compiled predefined combined format
+var combinedLogFormat, _ = CompileLogFormat("combined", CombinedLogFormat, "default")
+
+// Parse parses a log line written with this format
+func (f *LogFormat) Parse(line string) (LogEntry, error) {
+	// JSON lines are decoded whatever the format; key order and spacing
+	// don't have to match the template
+	if isJSONLine(line) {
+		if entry, err := f.parseJSON(line); err == nil {
+			return entry, nil
+		}
+	}
+
+	matches := f.re.FindStringSubmatch(line)
+	if matches == nil {
+		return LogEntry{Raw: line}, fmt.Errorf("line does not match log_format %s", f.Name)
+	}
+
+	vars := make(map[string]string, len(f.Vars))
+	for i, name := range f.Vars {
+		vars[name] = f.unescape(matches[i+1])
+	}
+	entry := entryFromVars(vars)
+	entry.Raw = line
+	entry.Format = f.Name
+	return entry, nil
+}
+
+// unescape reverses the escaping NGINX applied when writing a value
+func (f *LogFormat) unescape(value string) string {
+	if f.Escape == "none" || !strings.Contains(value, `\`) {
+		return value
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c != '\\' || i+1 >= len(value) {
+			sb.WriteByte(c)
+			continue
+		}
+		next := value[i+1]
+		switch {
+		case next == 'x' && i+3 < len(value):
+			// default escaping writes \xHH
+			if b, err := strconv.ParseUint(value[i+2:i+4], 16, 8); err == nil {
+				sb.WriteByte(byte(b))
+				i += 3
+				continue
+			}
+			sb.WriteByte(c)
+		case f.Escape == "json" && next == 'u' && i+5 < len(value):
+			if r, err := strconv.ParseUint(value[i+2:i+6], 16, 16); err == nil {
+				sb.WriteRune(rune(r))
+				i += 5
+				continue
+			}
+			sb.WriteByte(c)
+		case f.Escape == "json" && jsonEscapes[next] != 0:
+			sb.WriteByte(jsonEscapes[next])
+			i++
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// jsonEscapes maps the single character JSON escapes to the byte they stand for
+var jsonEscapes = map[byte]byte{'"': '"', '\\': '\\', '/': '/', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t'}
+
+// entryFromVars fills in the well-known LogEntry fields from NGINX variables
+func entryFromVars(vars map[string]string) LogEntry {
+	entry := LogEntry{Vars: vars}
+
+	entry.IP = firstVar(vars, "remote_addr", "realip_remote_addr")
+	entry.Host = firstVar(vars, "host", "http_host", "server_name")
+
+	// Prefer the most precise timestamp the format provides
+	if msec, err := strconv.ParseFloat(vars["msec"], 64); err == nil {
+		entry.Timestamp = time.UnixMilli(int64(msec * 1000))
+	} else if t, err := time.Parse(time.RFC3339, vars["time_iso8601"]); err == nil {
+		entry.Timestamp = t
+	} else if t, err := time.Parse("02/Jan/2006:15:04:05 -0700", vars["time_local"]); err == nil {
+		entry.Timestamp = t
+	} else {
+		entry.Timestamp = time.Now()
+	}
+
+	// "$request" is "METHOD URI PROTOCOL"
+	if request := strings.Fields(vars["request"]); len(request) >= 2 {
+		entry.Method = request[0]
+		entry.Path = request[1]
+	}
+	if method := vars["request_method"]; method != "" {
+		entry.Method = method
+	}
+	if entry.Path == "" {
+		entry.Path = firstVar(vars, "request_uri", "uri")
+	}
+
+	entry.StatusCode, _ = strconv.Atoi(vars["status"])
+	entry.BytesSent, _ = strconv.Atoi(firstVar(vars, "body_bytes_sent", "bytes_sent"))
+	entry.Referer = vars["http_referer"]
+	entry.UserAgent = vars["http_user_agent"]
+	entry.StatusClass = statusClass(entry.StatusCode)
+
+	entry.RequestTime = parseLogSeconds(vars["request_time"])
+	entry.UpstreamTime = parseLogSeconds(vars["upstream_response_time"])
+
+	return entry
+}
+
+// firstVar returns the first of the named variables that has a value
+func firstVar(vars map[string]string, names ...string) string {
+	for _, name := range names {
+		if v := vars[name]; v != "" && v != "-" {
+			return v
+		}
+	}
+	return ""
+}
+
+// statusClass returns "1xx" to "5xx" for a status code, or "" for a
+// missing or invalid one
+func statusClass(statusCode int) string {
+	switch {
+	case statusCode >= 100 && statusCode < 200:
+		return "1xx"
+	case statusCode >= 200 && statusCode < 300:
+		return "2xx"
+	case statusCode >= 300 && statusCode < 400:
+		return "3xx"
+	case statusCode >= 400 && statusCode < 500:
+		return "4xx"
+	case statusCode >= 500 && statusCode < 600:
+		return "5xx"
+	}
+	return ""
+}
+
+// parseLogSeconds parses a seconds value such as $request_time. Upstream
+// timings list one value per upstream tried ("0.010, 0.250 : 0.004"), which
+// are summed.
+func parseLogSeconds(value string) time.Duration {
+	var total float64
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ':' || r == ' '
+	}) {
+		if seconds, err := strconv.ParseFloat(part, 64); err == nil {
+			total += seconds
+		}
+	}
+	return time.Duration(total * float64(time.Second))
+}
+
+// AccessLog is an access_log file and the format it is written with
+type AccessLog struct {
+	Path   string
+	Format *LogFormat
+	Sites  []string // Sites whose server blocks write to this file
+}
+
+// LogFormats returns the log_format definitions in the configuration,
+// including the predefined combined format
+func (s *Service) LogFormats() (map[string]*LogFormat, error) {
+	blocks, err := s.ServerBlocks()
+	if err != nil {
+		return nil, err
+	}
+	return logFormatsFromBlocks(blocks), nil
+}
+
+// logFormatsFromBlocks compiles the log_format directives of the http context
+func logFormatsFromBlocks(blocks []ServerBlock) map[string]*LogFormat {
+	formats := map[string]*LogFormat{"combined": combinedLogFormat}
+	if len(blocks) == 0 {
+		return formats
+	}
+
+	// Every block carries the same http directives
+	for _, d := range blocks[0].HTTP {
+		if d.Directive != "log_format" || len(d.Args) < 2 {
+			continue
+		}
+		name, args := d.Args[0], d.Args[1:]
+		escape := ""
+		if strings.HasPrefix(args[0], "escape=") {
+			escape = strings.TrimPrefix(args[0], "escape=")
+			args = args[1:]
+		}
+		// The format may be split over several strings that NGINX concatenates
+		if f, err := CompileLogFormat(name, strings.Join(args, ""), escape); err == nil {
+			formats[name] = f
+		}
+	}
+	return formats
+}
+
+// AccessLogs returns every access_log file declared for a server block with
+// the format it is written in. Server blocks without an access_log of their
+// own or from the http context write to the default log in the combined
+// format, as does everything when the configuration can't be read.
+func (s *Service) AccessLogs() []AccessLog {
+	defaultLogs := []AccessLog{{Path: DefaultAccessLog, Format: combinedLogFormat.WithLogConfig(s.logConfig)}}
+	blocks, err := s.ServerBlocks()
+	if err != nil {
+		return defaultLogs
+	}
+	formats := logFormatsFromBlocks(blocks)
+
+	var logs []AccessLog
+	index := map[string]int{}
+	add := func(path string, format *LogFormat, site string) {
+		if i, ok := index[path]; ok {
+			if !slices.Contains(logs[i].Sites, site) {
+				logs[i].Sites = append(logs[i].Sites, site)
+			}
+			return
+		}
+		index[path] = len(logs)
+		logs = append(logs, AccessLog{Path: path, Format: format.WithLogConfig(s.logConfig), Sites: []string{site}})
+	}
+
+	for _, block := range blocks {
+		declared := block.LookupAll("access_log")
+		if len(declared) == 0 {
+			add(DefaultAccessLog, combinedLogFormat, block.Site)
+			continue
+		}
+		for _, d := range declared {
+			if len(d.Args) == 0 || d.Args[0] == "off" || strings.HasPrefix(d.Args[0], "syslog:") || strings.Contains(d.Args[0], "$") {
+				continue
+			}
+			format := combinedLogFormat
+			if len(d.Args) > 1 && !strings.Contains(d.Args[1], "=") {
+				if f, ok := formats[d.Args[1]]; ok {
+					format = f
+				}
+			}
+			add(resolveConfigPath(d.Args[0]), format, block.Site)
+		}
+	}
+
+	if len(logs) == 0 {
+		return defaultLogs
+	}
+	return logs
+}
@@ -0,0 +1,283 @@
+package nginx
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	crossplane "github.com/nginxinc/nginx-go-crossplane"
+)
+
+// timedLogFormat is a typical custom format with timings and the host
+const timedLogFormat = `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent ` +
+	`"$http_referer" "$http_user_agent" rt=$request_time urt="$upstream_response_time" host=${host}`
+
+// jsonLogFormat is a log_format ... escape=json template
+const jsonLogFormat = `{"time":"$time_iso8601","remote_addr":"$remote_addr","request":"$request",` +
+	`"status":$status,"bytes":$body_bytes_sent,"ua":"$http_user_agent","request_time":$request_time}`
+
+func TestCompileLogFormat(t *testing.T) {
+	tests := []struct {
+		name, format string
+		vars         []string
+		wantErr      bool
+	}{
+		{"combined", CombinedLogFormat, []string{"remote_addr", "remote_user", "time_local", "request", "status", "body_bytes_sent", "http_referer", "http_user_agent"}, false},
+		{"braces", `${remote_addr}:${status}`, []string{"remote_addr", "status"}, false},
+		{"regex characters", `[$status] (+$msec) ^$uri$`, []string{"status", "msec", "uri"}, false},
+		{"literal only", `no variables here`, nil, true},
+	}
+	for _, tt := range tests {
+		f, err := CompileLogFormat(tt.name, tt.format, "")
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: compiled, want an error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !slices.Equal(f.Vars, tt.vars) {
+			t.Errorf("%s: vars = %q, want %q", tt.name, f.Vars, tt.vars)
+		}
+		if f.Escape != "default" {
+			t.Errorf("%s: escape = %q, want default", tt.name, f.Escape)
+		}
+	}
+}
+
+func TestLogFormatParse(t *testing.T) {
+	timed, err := CompileLogFormat("timed", timedLogFormat, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	literal, err := CompileLogFormat("literal", `[$status] (+$msec) ^$uri$`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonFormat, err := CompileLogFormat("json", jsonLogFormat, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		format *LogFormat
+		line   string
+		check  func(t *testing.T, e LogEntry)
+	}{
+		{
+			name:   "combined",
+			format: combinedLogFormat,
+			line:   `203.0.113.7 - alice [10/Oct/2025:13:55:36 +0200] "GET /index.html?q=1 HTTP/1.1" 200 2326 "https://example.com/" "Mozilla/5.0 (X11; Linux x86_64)"`,
+			check: func(t *testing.T, e LogEntry) {
+				want := time.Date(2025, 10, 10, 11, 55, 36, 0, time.UTC)
+				if e.IP != "203.0.113.7" || !e.Timestamp.Equal(want) || e.Method != "GET" || e.Path != "/index.html?q=1" ||
+					e.StatusCode != 200 || e.StatusClass != "2xx" || e.BytesSent != 2326 ||
+					e.Referer != "https://example.com/" || e.UserAgent != "Mozilla/5.0 (X11; Linux x86_64)" || e.Vars["remote_user"] != "alice" {
+					t.Errorf("got %+v", e)
+				}
+			},
+		},
+		{
+			name:   "user agent with quotes and escapes",
+			format: combinedLogFormat,
+			line:   `203.0.113.7 - - [10/Oct/2025:13:55:36 +0000] "GET / HTTP/1.1" 404 0 "-" "curl \x22quoted\x22"`,
+			check: func(t *testing.T, e LogEntry) {
+				if e.UserAgent != `curl "quoted"` || e.StatusClass != "4xx" {
+					t.Errorf("got ua %q class %s", e.UserAgent, e.StatusClass)
+				}
+			},
+		},
+		{
+			name:   "timings and host",
+			format: timed,
+			line:   `10.0.0.1 - - [10/Oct/2025:13:55:36 +0000] "POST /api HTTP/2.0" 502 157 "-" "k6/0.50" rt=1.250 urt="0.500, 0.700 : 0.010" host=api.example.com`,
+			check: func(t *testing.T, e LogEntry) {
+				if e.RequestTime != 1250*time.Millisecond || e.UpstreamTime != 1210*time.Millisecond || e.Host != "api.example.com" || e.Format != "timed" {
+					t.Errorf("got rt %v urt %v host %q format %q", e.RequestTime, e.UpstreamTime, e.Host, e.Format)
+				}
+			},
+		},
+		{
+			name:   "regex characters in the format",
+			format: literal,
+			line:   `[301] (+1760104536.123) ^/old$`,
+			check: func(t *testing.T, e LogEntry) {
+				if e.StatusCode != 301 || e.Path != "/old" || e.Timestamp.UnixMilli() != 1760104536123 {
+					t.Errorf("got status %d path %q time %v", e.StatusCode, e.Path, e.Timestamp)
+				}
+			},
+		},
+		{
+			name:   "json",
+			format: jsonFormat,
+			line:   `{"time":"2025-10-10T13:55:36+00:00","remote_addr":"2001:db8::1","request":"GET /feed HTTP/1.1","status":304,"bytes":0,"ua":"Feedly\/1.0","request_time":0.002}`,
+			check: func(t *testing.T, e LogEntry) {
+				if e.IP != "2001:db8::1" || e.StatusCode != 304 || e.Path != "/feed" || e.UserAgent != "Feedly/1.0" ||
+					e.RequestTime != 2*time.Millisecond || e.Timestamp.Unix() != 1760104536 {
+					t.Errorf("got %+v", e)
+				}
+			},
+		},
+		{
+			name:   "json line in a text format",
+			format: combinedLogFormat,
+			line:   `{"remote_addr":"10.1.1.1","status":"201","request_method":"PUT","request_uri":"/item/7"}`,
+			check: func(t *testing.T, e LogEntry) {
+				if e.IP != "10.1.1.1" || e.StatusCode != 201 || e.Method != "PUT" || e.Path != "/item/7" {
+					t.Errorf("got %+v", e)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := tt.format.Parse(tt.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if e.Raw != tt.line {
+				t.Errorf("raw = %q", e.Raw)
+			}
+			tt.check(t, e)
+		})
+	}
+
+	if e, err := combinedLogFormat.Parse("not an access log line"); err == nil || e.Raw != "not an access log line" {
+		t.Errorf("unmatched line: got %+v, %v", e, err)
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	tests := map[int]string{
+		0:   "",
+		99:  "",
+		101: "1xx",
+		200: "2xx",
+		304: "3xx",
+		499: "4xx",
+		502: "5xx",
+		600: "",
+	}
+	for code, want := range tests {
+		if got := statusClass(code); got != want {
+			t.Errorf("statusClass(%d) = %q, want %q", code, got, want)
+		}
+	}
+
+	// Neither a WebSocket upgrade nor a format without $status is a server error
+	noStatus, err := CompileLogFormat("nostatus", `$remote_addr "$request"`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewLogStatsBuilder()
+	for _, line := range []struct {
+		format *LogFormat
+		line   string
+	}{
+		{combinedLogFormat, `10.0.0.1 - - [10/Oct/2025:13:55:36 +0000] "GET /ws HTTP/1.1" 101 0 "-" "-"`},
+		{noStatus, `10.0.0.1 "GET / HTTP/1.1"`},
+	} {
+		e, err := line.format.Parse(line.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Add(e)
+	}
+	if stats := b.Stats(); stats.StatusCounts["5xx"] != 0 || stats.StatusCounts["1xx"] != 1 || stats.TotalRequests != 2 {
+		t.Errorf("status counts = %v over %d requests", stats.StatusCounts, stats.TotalRequests)
+	}
+}
+
+func TestLogFormatUnescape(t *testing.T) {
+	tests := []struct {
+		escape, value, want string
+	}{
+		{"default", `a\x22b\x5C`, `a"b\`},
+		{"default", `\x`, `\x`},
+		{"default", `\n`, `\n`},
+		{"json", `a\"b\\c\/d\n`, "a\"b\\c/d\n"},
+		{"json", `café`, "café"},
+		{"none", `a\x22b`, `a\x22b`},
+	}
+	for _, tt := range tests {
+		f := &LogFormat{Escape: tt.escape}
+		if got := f.unescape(tt.value); got != tt.want {
+			t.Errorf("%s unescape(%q) = %q, want %q", tt.escape, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseLogSeconds(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"0.250", 250 * time.Millisecond},
+		{"-", 0},
+		{"", 0},
+		{"0.010, 0.250", 260 * time.Millisecond},
+		{"0.010, 0.250 : 0.004", 264 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := parseLogSeconds(tt.value); got != tt.want {
+			t.Errorf("parseLogSeconds(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestLogFormatsFromBlocks(t *testing.T) {
+	blocks := []ServerBlock{{HTTP: crossplane.Directives{
+		{Directive: "log_format", Args: []string{"timed", `$remote_addr [$time_local] `, `"$request" $status`}},
+		{Directive: "log_format", Args: []string{"json", "escape=json", jsonLogFormat}},
+		{Directive: "log_format", Args: []string{"broken", "no variables"}},
+		{Directive: "access_log", Args: []string{"/var/log/nginx/access.log", "timed"}},
+	}}}
+	formats := logFormatsFromBlocks(blocks)
+
+	if formats["combined"] != combinedLogFormat {
+		t.Error("combined format missing")
+	}
+	if f := formats["timed"]; f == nil || f.Format != `$remote_addr [$time_local] "$request" $status` {
+		t.Errorf("timed format not concatenated: %+v", f)
+	}
+	if f := formats["json"]; f == nil || f.Escape != "json" || f.JSONFields["ua"] != "http_user_agent" {
+		t.Errorf("json format: %+v", f)
+	}
+	if _, ok := formats["broken"]; ok {
+		t.Error("a format without variables was kept")
+	}
+}
+
+func TestParseAccessLogLines(t *testing.T) {
+	lines := []string{
+		`10.0.0.1 - - [10/Oct/2025:13:55:36 +0000] "GET /a HTTP/1.1" 200 1 "-" "-"`,
+		`garbage`,
+		``,
+		`10.0.0.2 - - [10/Oct/2025:13:55:40 +0000] "GET /b HTTP/1.1" 200 1 "-" "-"`,
+	}
+	entries := parseAccessLogLines(lines, AccessLog{Path: "/var/log/nginx/shop.log", Format: combinedLogFormat, Sites: []string{"shop"}})
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	if entries[1].ParseErr == nil || entries[1].Raw != "garbage" {
+		t.Errorf("unparsed line: %+v", entries[1])
+	}
+	// Unparsed lines take the time of the next parsed one
+	if !entries[1].Timestamp.Equal(entries[2].Timestamp) {
+		t.Errorf("unparsed line at %v, want %v", entries[1].Timestamp, entries[2].Timestamp)
+	}
+	for _, e := range entries {
+		if e.Site != "shop" || e.Log != "/var/log/nginx/shop.log" {
+			t.Errorf("entry not attributed: site %q log %q", e.Site, e.Log)
+		}
+	}
+
+	shared := parseAccessLogLines(lines[:1], AccessLog{Format: combinedLogFormat, Sites: []string{"a", "b"}})
+	if shared[0].Site != "" {
+		t.Errorf("shared log attributed to %q", shared[0].Site)
+	}
+}
@@ -0,0 +1,247 @@
+package nginx
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeRange is a span of log history. The zero Since means the live window
+// kept by the tailer; the zero Until means up to now.
+type TimeRange struct {
+	Label string
+	Since time.Time
+	Until time.Time
+}
+
+// Live reports whether the range is the tailer's live window
+func (r TimeRange) Live() bool {
+	return r.Since.IsZero()
+}
+
+// Contains reports whether t falls within the range
+func (r TimeRange) Contains(t time.Time) bool {
+	if r.Live() {
+		return true
+	}
+	return !t.Before(r.Since) && (r.Until.IsZero() || t.Before(r.Until))
+}
+
+// TimeRanges returns the ranges offered by the time-range picker, relative to now
+func TimeRanges(now time.Time) []TimeRange {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	weekday := (int(today.Weekday()) + 6) % 7 // Days since Monday
+	thisWeek := today.AddDate(0, 0, -weekday)
+	return []TimeRange{
+		{Label: "Live"},
+		{Label: "Last 15 minutes", Since: now.Add(-15 * time.Minute)},
+		{Label: "Last hour", Since: now.Add(-time.Hour)},
+		{Label: "Last 24 hours", Since: now.Add(-24 * time.Hour)},
+		{Label: "Today", Since: today},
+		{Label: "Yesterday", Since: today.AddDate(0, 0, -1), Until: today},
+		{Label: "Last 7 days", Since: today.AddDate(0, 0, -7)},
+		{Label: "Last week", Since: thisWeek.AddDate(0, 0, -7), Until: thisWeek},
+		{Label: "Last 30 days", Since: today.AddDate(0, 0, -30)},
+	}
+}
+
+// SelectedTimeRange returns range i of TimeRanges, or the live window when
+// i is out of range
+func SelectedTimeRange(i int, now time.Time) TimeRange {
+	ranges := TimeRanges(now)
+	if i > 0 && i < len(ranges) {
+		return ranges[i]
+	}
+	return ranges[0]
+}
+
+// logOrderSlack is how far out of order lines of one file may be: NGINX logs
+// a request when it completes, so slow requests land after faster later ones
+const logOrderSlack = time.Minute
+
+// rotatedSuffixRe matches logrotate suffixes: ".1", ".2.gz", "-20250131", "-20250131.gz"
+var rotatedSuffixRe = regexp.MustCompile(`^[.-](\d+)(\.gz)?$`)
+
+// logHistoryFiles returns a log's rotated files followed by the log itself,
+// oldest first. Dated files come before numbered ones, numbered ones from
+// the highest number down.
+func logHistoryFiles(containerID, path string) ([]string, error) {
+	var candidates []string
+	if containerID == "" {
+		for _, pattern := range []string{path + ".*", path + "-*"} {
+			matches, err := filepath.Glob(pattern)
+			if err != nil {
+				return nil, err
+			}
+			candidates = append(candidates, matches...)
+		}
+	} else {
+		output, _ := exec.Command("docker", "exec", containerID, "sh", "-c", `ls -1 -- "$0".* "$0"-* 2>/dev/null`, path).Output()
+		for _, line := range strings.Split(string(output), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				candidates = append(candidates, line)
+			}
+		}
+	}
+
+	type rotated struct {
+		file   string
+		dated  bool
+		number int
+	}
+	var files []rotated
+	for _, file := range candidates {
+		match := rotatedSuffixRe.FindStringSubmatch(strings.TrimPrefix(file, path))
+		if match == nil {
+			continue
+		}
+		n, _ := strconv.Atoi(match[1])
+		// dateext suffixes are eight digits or more; rotation counts are small
+		files = append(files, rotated{file: file, dated: len(match[1]) >= 8, number: n})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].dated != files[j].dated {
+			return files[i].dated
+		}
+		if files[i].dated {
+			return files[i].number < files[j].number
+		}
+		return files[i].number > files[j].number
+	})
+
+	var ordered []string
+	for _, f := range files {
+		ordered = append(ordered, f.file)
+	}
+	return append(ordered, path), nil
+}
+
+// openLogStream opens a current or rotated log, decompressing .gz files as they are read
+func openLogStream(containerID, file string) (io.ReadCloser, error) {
+	reader, err := openAccessLog(containerID, file)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(file, ".gz") {
+		return reader, nil
+	}
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		reader.Close()
+		return nil, fmt.Errorf("failed to decompress %s: %w", file, err)
+	}
+	return &gzipStream{Reader: gz, source: reader}, nil
+}
+
+// gzipStream closes the decompressor together with the file under it
+type gzipStream struct {
+	*gzip.Reader
+	source io.Closer
+}
+
+// Close closes the decompressor and the underlying file
+func (g *gzipStream) Close() error {
+	g.Reader.Close()
+	return g.source.Close()
+}
+
+// ScanAccessLogs streams every entry within the range to fn, log by log and
+// oldest file first. A live range covers the current files only; other ranges
+// also read rotated and compressed files, skipping those that end before the
+// range starts and stopping once entries pass its end.
+func (s *Service) ScanAccessLogs(tr TimeRange, fn func(LogEntry)) error {
+	containerID := ""
+	if IsDockerAvailable() {
+		containerID, _ = getCachedContainerID()
+	}
+
+	var firstErr error
+	scanned := 0
+	for _, log := range s.AccessLogs() {
+		files := []string{log.Path}
+		if !tr.Live() {
+			var err error
+			if files, err = logHistoryFiles(containerID, log.Path); err != nil {
+				files = []string{log.Path}
+			}
+		}
+
+		for _, file := range files {
+			if containerID == "" && !tr.Live() {
+				// A file last written before the range can't hold any of it
+				if info, err := os.Stat(file); err == nil && info.ModTime().Before(tr.Since) {
+					continue
+				}
+			}
+			done, err := scanLogFile(containerID, file, log, tr, fn)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			scanned++
+			if done {
+				break
+			}
+		}
+	}
+	if scanned == 0 && firstErr != nil {
+		return firstErr
+	}
+	return nil
+}
+
+// scanLogFile streams one file of a log through fn. It reports done once the
+// file holds entries past the range end, so newer files needn't be read.
+func scanLogFile(containerID, file string, log AccessLog, tr TimeRange, fn func(LogEntry)) (bool, error) {
+	reader, err := openLogStream(containerID, file)
+	if err != nil {
+		return false, err
+	}
+	defer reader.Close()
+
+	site := ""
+	if len(log.Sites) == 1 {
+		site = log.Sites[0]
+	}
+
+	var last LogEntry
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		entry, err := log.Format.Parse(line)
+		entry.ParseErr = err
+		entry.Log = log.Path
+		entry.Site = site
+		if err != nil {
+			// Unparsed lines sort after the line before them
+			entry.Timestamp = last.Timestamp
+		} else {
+			last = entry
+			if !tr.Until.IsZero() && entry.Timestamp.After(tr.Until.Add(logOrderSlack)) {
+				return true, nil
+			}
+		}
+		if tr.Contains(entry.Timestamp) {
+			fn(entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	return false, nil
+}
@@ -0,0 +1,172 @@
+package nginx
+
+import (
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogHistoryFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	for _, name := range []string{
+		"access.log",
+		"access.log.1",
+		"access.log.2.gz",
+		"access.log.10.gz",
+		"access.log-20250130.gz",
+		"access.log-20250131",
+		// Not rotations of access.log
+		"access.log.bak",
+		"access.log-old",
+		"access.log.1.swp",
+		"access.log2",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := logHistoryFiles("", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	want := []string{
+		"access.log-20250130.gz",
+		"access.log-20250131",
+		"access.log.10.gz",
+		"access.log.2.gz",
+		"access.log.1",
+		"access.log",
+	}
+	if !slices.Equal(names, want) {
+		t.Errorf("files = %q\nwant %q", names, want)
+	}
+
+	// A log that was never rotated is just itself
+	alone := filepath.Join(dir, "other.log")
+	if files, err := logHistoryFiles("", alone); err != nil || !slices.Equal(files, []string{alone}) {
+		t.Errorf("unrotated log: %q, %v", files, err)
+	}
+}
+
+func TestTimeRangeContains(t *testing.T) {
+	now := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+	ranges := TimeRanges(now)
+	byLabel := map[string]TimeRange{}
+	for _, r := range ranges {
+		byLabel[r.Label] = r
+	}
+
+	tests := []struct {
+		label string
+		at    time.Time
+		want  bool
+	}{
+		{"Live", time.Time{}, true},
+		{"Last hour", now.Add(-59 * time.Minute), true},
+		{"Last hour", now.Add(-61 * time.Minute), false},
+		{"Today", time.Date(2025, 10, 10, 0, 0, 0, 0, time.UTC), true},
+		{"Today", time.Date(2025, 10, 9, 23, 59, 59, 0, time.UTC), false},
+		{"Yesterday", time.Date(2025, 10, 9, 12, 0, 0, 0, time.UTC), true},
+		{"Yesterday", time.Date(2025, 10, 10, 0, 0, 0, 0, time.UTC), false},
+		// 2025-10-10 is a Friday, so last week is Monday the 29th to Sunday the 5th
+		{"Last week", time.Date(2025, 9, 29, 0, 0, 0, 0, time.UTC), true},
+		{"Last week", time.Date(2025, 10, 5, 23, 0, 0, 0, time.UTC), true},
+		{"Last week", time.Date(2025, 10, 6, 0, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		r, ok := byLabel[tt.label]
+		if !ok {
+			t.Fatalf("no %q range", tt.label)
+		}
+		if got := r.Contains(tt.at); got != tt.want {
+			t.Errorf("%s.Contains(%v) = %v, want %v", tt.label, tt.at, got, tt.want)
+		}
+	}
+
+	if !SelectedTimeRange(-1, now).Live() || !SelectedTimeRange(len(ranges), now).Live() || SelectedTimeRange(2, now).Label != "Last hour" {
+		t.Error("SelectedTimeRange doesn't fall back to the live window")
+	}
+}
+
+// writeLogFile writes combined format lines, one per time, gzipped for .gz names
+func writeLogFile(t *testing.T, file string, times ...time.Time) {
+	t.Helper()
+	var sb strings.Builder
+	for i, at := range times {
+		fmt.Fprintf(&sb, "10.0.0.%d - - [%s] \"GET /%d HTTP/1.1\" 200 1 \"-\" \"-\"\n", i+1, at.Format("02/Jan/2006:15:04:05 -0700"), i)
+		if i == 0 {
+			sb.WriteString("garbage\n")
+		}
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if strings.HasSuffix(file, ".gz") {
+		gz := gzip.NewWriter(f)
+		defer gz.Close()
+		gz.Write([]byte(sb.String()))
+		return
+	}
+	f.WriteString(sb.String())
+}
+
+func TestScanLogFile(t *testing.T) {
+	base := time.Date(2025, 10, 10, 12, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	log := AccessLog{Path: filepath.Join(dir, "access.log"), Format: combinedLogFormat, Sites: []string{"shop"}}
+
+	for _, name := range []string{"access.log.2.gz", "access.log.1"} {
+		file := filepath.Join(dir, name)
+		writeLogFile(t, file, base, base.Add(10*time.Minute), base.Add(20*time.Minute), base.Add(2*time.Hour))
+
+		tests := []struct {
+			name     string
+			tr       TimeRange
+			wantN    int
+			wantDone bool
+		}{
+			{"everything", TimeRange{Since: base.Add(-time.Hour)}, 5, false},
+			{"from the second line", TimeRange{Since: base.Add(5 * time.Minute)}, 3, false},
+			// Stops at the line two hours in, past the end and its slack
+			{"bounded", TimeRange{Since: base, Until: base.Add(15 * time.Minute)}, 3, true},
+		}
+		for _, tt := range tests {
+			var got []LogEntry
+			done, err := scanLogFile("", file, log, tt.tr, func(e LogEntry) { got = append(got, e) })
+			if err != nil {
+				t.Fatalf("%s %s: %v", name, tt.name, err)
+			}
+			if len(got) != tt.wantN || done != tt.wantDone {
+				t.Errorf("%s %s: %d entries, done %v; want %d, %v", name, tt.name, len(got), done, tt.wantN, tt.wantDone)
+			}
+			for _, e := range got {
+				if e.Site != "shop" || e.Log != log.Path {
+					t.Errorf("%s %s: entry not attributed: %+v", name, tt.name, e)
+				}
+			}
+		}
+	}
+
+	if _, err := scanLogFile("", filepath.Join(dir, "missing.log"), log, TimeRange{}, func(LogEntry) {}); err == nil {
+		t.Error("scanning a missing file succeeded")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.gz"), []byte("not gzip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scanLogFile("", filepath.Join(dir, "broken.gz"), log, TimeRange{}, func(LogEntry) {}); err == nil {
+		t.Error("scanning a corrupt .gz file succeeded")
+	}
+}
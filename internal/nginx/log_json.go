@@ -0,0 +1,105 @@
+package nginx
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+
+	"github.com/aitmiloud/ngxtui/internal/config"
+)
+
+// jsonFieldRe matches a "key": "$var" or "key": $var pair in a JSON log_format
+var jsonFieldRe = regexp.MustCompile(`"([^"]+)"\s*:\s*"?\$(?:\{(\w+)\}|(\w+))"?\s*[,}]`)
+
+// inferJSONFields maps the keys of a JSON log_format to the variable each one holds.
+// Keys whose value mixes text and variables are left out.
+func inferJSONFields(format string) map[string]string {
+	if !strings.HasPrefix(strings.TrimSpace(format), "{") {
+		return nil
+	}
+	fields := map[string]string{}
+	for _, match := range jsonFieldRe.FindAllStringSubmatch(format, -1) {
+		varName := match[2]
+		if varName == "" {
+			varName = match[3]
+		}
+		fields[match[1]] = varName
+	}
+	return fields
+}
+
+// WithLogConfig returns a copy of the format that also applies the JSON
+// field mapping from the user configuration
+func (f *LogFormat) WithLogConfig(cfg config.LogConfig) *LogFormat {
+	if len(cfg.JSONFields) == 0 {
+		return f
+	}
+	copied := *f
+	copied.JSONFields = maps.Clone(f.JSONFields)
+	if copied.JSONFields == nil {
+		copied.JSONFields = map[string]string{}
+	}
+	maps.Copy(copied.JSONFields, cfg.JSONFields)
+	return &copied
+}
+
+// parseJSON decodes a JSON log line. Keys are mapped to NGINX variables
+// through JSONFields; unmapped keys are taken to be variable names already.
+// Objects without a status aren't requests and are rejected.
+func (f *LogFormat) parseJSON(line string) (LogEntry, error) {
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil {
+		return LogEntry{Raw: line}, fmt.Errorf("invalid JSON log line: %w", err)
+	}
+
+	flat := map[string]string{}
+	flattenJSON("", object, flat)
+
+	vars := make(map[string]string, len(flat))
+	for key, value := range flat {
+		if varName, ok := f.JSONFields[key]; ok {
+			vars[varName] = value
+		} else if _, exists := vars[key]; !exists {
+			vars[key] = value
+		}
+	}
+
+	if vars["status"] == "" {
+		return LogEntry{Raw: line}, fmt.Errorf("JSON log line has no status")
+	}
+
+	entry := entryFromVars(vars)
+	entry.Raw = line
+	entry.Format = f.Name
+	return entry, nil
+}
+
+// flattenJSON turns nested objects into dotted keys ("request.method")
+func flattenJSON(prefix string, value interface{}, out map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flattenJSON(key, child, out)
+		}
+	case nil:
+		out[prefix] = ""
+	case string:
+		out[prefix] = v
+	default:
+		// Numbers (json.Number), booleans and arrays keep their JSON text
+		data, _ := json.Marshal(v)
+		out[prefix] = strings.Trim(string(data), `"`)
+	}
+}
+
+// isJSONLine reports whether a log line looks like a JSON object
+func isJSONLine(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "{")
+}
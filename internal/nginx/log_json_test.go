@@ -0,0 +1,60 @@
+package nginx
+
+import (
+	"testing"
+
+	"github.com/aitmiloud/ngxtui/internal/config"
+)
+
+func TestInferJSONFields(t *testing.T) {
+	fields := inferJSONFields(`{"ts":"$msec","client":"$remote_addr","status":$status,"req":"$request_method ${uri}","ua":"${http_user_agent}"}`)
+	want := map[string]string{"ts": "msec", "client": "remote_addr", "status": "status", "ua": "http_user_agent"}
+	if len(fields) != len(want) {
+		t.Errorf("fields = %v, want %v", fields, want)
+	}
+	for key, name := range want {
+		if fields[key] != name {
+			t.Errorf("fields[%s] = %q, want %q", key, fields[key], name)
+		}
+	}
+	if inferJSONFields(CombinedLogFormat) != nil {
+		t.Error("text format inferred JSON fields")
+	}
+}
+
+func TestWithLogConfig(t *testing.T) {
+	f, err := CompileLogFormat("json", jsonLogFormat, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.WithLogConfig(config.LogConfig{}) != f {
+		t.Error("empty config copied the format")
+	}
+	custom := f.WithLogConfig(config.LogConfig{JSONFields: map[string]string{"ua": "http_referer", "svc": "host"}})
+	if custom.JSONFields["ua"] != "http_referer" || custom.JSONFields["svc"] != "host" || custom.JSONFields["time"] != "time_iso8601" {
+		t.Errorf("merged fields = %v", custom.JSONFields)
+	}
+	if f.JSONFields["ua"] != "http_user_agent" {
+		t.Error("config changed the shared format")
+	}
+}
+
+func TestParseJSONWithoutStatus(t *testing.T) {
+	f, err := CompileLogFormat("json", jsonLogFormat, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`{"time":"2025-10-10T13:55:36+00:00","remote_addr":"10.0.0.1","request":"GET / HTTP/1.1"}`,
+		`{"level":"info","msg":"worker started"}`,
+		`{"status":null}`,
+	} {
+		if e, err := f.Parse(line); err == nil {
+			t.Errorf("Parse(%q) = status %d, want an error", line, e.StatusCode)
+		}
+	}
+	entries := parseAccessLogLines([]string{`{"msg":"no request"}`}, AccessLog{Format: f})
+	if len(entries) != 1 || entries[0].ParseErr == nil {
+		t.Errorf("line without a status counted as a request: %+v", entries)
+	}
+}
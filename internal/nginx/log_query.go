@@ -0,0 +1,550 @@
+package nginx
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// LogQuery is a parsed filter expression such as
+// "status:5xx method:POST path:/api/* ip:10.0.0.0/8 client:bot since:15m bytes>1M".
+// Terms are ANDed; a value list ("status:4xx,5xx") matches any of its values
+// and a leading "-" or "!" negates a term.
+type LogQuery struct {
+	Expr  string
+	terms []queryTerm
+}
+
+// queryTerm is one predicate of a query
+type queryTerm struct {
+	field  string
+	negate bool
+	match  func(e LogEntry) bool
+	// highlight finds the matched text in a rendered field; nil when the
+	// term isn't about text (status, sizes, times, networks)
+	highlight *regexp.Regexp
+}
+
+// queryTermRe splits "key<op>value", the key optionally written as a
+// variable ("$upstream_addr"); anything else is free text
+var queryTermRe = regexp.MustCompile(`^(\$?[A-Za-z_][\w.]*)(>=|<=|>|<|:)(.*)$`)
+
+// ParseLogQuery parses a filter expression. An empty expression matches everything.
+func ParseLogQuery(expr string) (*LogQuery, error) {
+	q := &LogQuery{Expr: strings.TrimSpace(expr)}
+	for _, token := range splitQuery(q.Expr) {
+		term, err := parseQueryTerm(token)
+		if err != nil {
+			return nil, err
+		}
+		q.terms = append(q.terms, term)
+	}
+	return q, nil
+}
+
+// Empty reports whether the query has no terms
+func (q *LogQuery) Empty() bool {
+	return q == nil || len(q.terms) == 0
+}
+
+// Match reports whether the entry satisfies every term
+func (q *LogQuery) Match(e LogEntry) bool {
+	if q == nil {
+		return true
+	}
+	for _, term := range q.terms {
+		if term.match(e) == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// Highlight marks the text matched by the query's terms for field within s,
+// which may be padded but must not contain escape sequences yet
+func (q *LogQuery) Highlight(field, s string) string {
+	if q == nil {
+		return s
+	}
+
+	// Collect every match first so one term can't match inside another's markers
+	var ranges [][]int
+	for _, term := range q.terms {
+		if term.negate || term.highlight == nil || (term.field != field && term.field != "text") {
+			continue
+		}
+		for _, loc := range term.highlight.FindAllStringIndex(s, -1) {
+			if loc[1] > loc[0] {
+				ranges = append(ranges, loc)
+			}
+		}
+	}
+	if len(ranges) == 0 {
+		return s
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+
+	var sb strings.Builder
+	pos := 0
+	for _, loc := range ranges {
+		start, end := max(loc[0], pos), loc[1]
+		if end <= start {
+			continue
+		}
+		sb.WriteString(s[pos:start])
+		// Reverse video on/off keeps the surrounding colour intact
+		sb.WriteString("\033[7m" + s[start:end] + "\033[27m")
+		pos = end
+	}
+	sb.WriteString(s[pos:])
+	return sb.String()
+}
+
+// splitQuery splits on whitespace, keeping double-quoted values together
+func splitQuery(expr string) []string {
+	var tokens []string
+	var current strings.Builder
+	inQuotes := false
+	for _, r := range expr {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case unicode.IsSpace(r) && !inQuotes:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+// parseQueryTerm parses a single token
+func parseQueryTerm(token string) (queryTerm, error) {
+	term := queryTerm{}
+	if len(token) > 1 && (token[0] == '-' || token[0] == '!') {
+		term.negate = true
+		token = token[1:]
+	}
+
+	// "bots" and "humans" on their own are shortcuts for the client field
+	switch strings.ToLower(token) {
+	case "bots", "humans":
+		token = "client:" + token
+	}
+
+	parts := queryTermRe.FindStringSubmatch(token)
+	if parts == nil {
+		// Free text matches anywhere in the raw line
+		re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(token))
+		term.field = "text"
+		term.highlight = re
+		term.match = func(e LogEntry) bool { return re.MatchString(e.Raw) }
+		return term, nil
+	}
+
+	field, op, value := strings.ToLower(parts[1]), parts[2], parts[3]
+	if value == "" {
+		return term, fmt.Errorf("%s: missing value", token)
+	}
+	term.field = field
+
+	var err error
+	switch field {
+	case "status", "code":
+		term.field = "status"
+		term.match, err = statusMatcher(op, value)
+	case "method":
+		term.match, term.highlight, err = textMatcher(op, value, func(e LogEntry) string { return e.Method })
+	case "path", "uri", "url":
+		term.field = "path"
+		term.match, term.highlight, err = textMatcher(op, value, func(e LogEntry) string { return e.Path })
+	case "ip", "addr":
+		term.field = "ip"
+		term.match, term.highlight, err = ipMatcher(op, value)
+	case "ua", "agent":
+		term.field = "ua"
+		term.match, term.highlight, err = textMatcher(op, value, func(e LogEntry) string { return e.UserAgent })
+	case "client":
+		term.match, err = clientMatcher(op, value)
+	case "country":
+		term.match, err = countryMatcher(op, value)
+	case "asn", "as":
+		term.field = "asn"
+		term.match, _, err = textMatcher(op, value, func(e LogEntry) string { return LookupGeo(e.IP).ASLabel() })
+	case "host":
+		term.match, term.highlight, err = textMatcher(op, value, func(e LogEntry) string { return e.Host })
+	case "referer", "ref":
+		term.field = "referer"
+		term.match, term.highlight, err = textMatcher(op, value, func(e LogEntry) string { return e.Referer })
+	case "site":
+		term.match, term.highlight, err = textMatcher(op, value, func(e LogEntry) string { return e.Site })
+	case "bytes", "size":
+		term.field = "bytes"
+		term.match, err = numberMatcher(op, value, parseSize, func(e LogEntry) float64 { return float64(e.BytesSent) })
+	case "rt", "latency", "request_time":
+		term.field = "rt"
+		term.match, err = numberMatcher(op, value, parseQuerySeconds, func(e LogEntry) float64 { return e.RequestTime.Seconds() })
+	case "urt", "upstream_time":
+		term.field = "urt"
+		term.match, err = numberMatcher(op, value, parseQuerySeconds, func(e LogEntry) float64 { return e.UpstreamTime.Seconds() })
+	case "since", "until":
+		term.match, err = timeMatcher(field, op, value)
+	default:
+		// Anything else is a log_format variable, e.g. upstream_addr:10.0.0.5*
+		name := strings.TrimPrefix(field, "$")
+		term.match, term.highlight, err = varMatcher(op, value, name)
+	}
+	if err != nil {
+		return term, fmt.Errorf("%s: %w", token, err)
+	}
+	return term, nil
+}
+
+// statusMatcher matches codes ("404"), classes ("5xx"), ranges ("400-499") and comparisons
+func statusMatcher(op, value string) (func(LogEntry) bool, error) {
+	if op != ":" {
+		return numberMatcher(op, value, parseQueryNumber, func(e LogEntry) float64 { return float64(e.StatusCode) })
+	}
+
+	var checks []func(int) bool
+	for _, v := range strings.Split(value, ",") {
+		v = strings.ToLower(v)
+		switch {
+		case len(v) == 3 && strings.HasSuffix(v, "xx") && v[0] >= '1' && v[0] <= '5':
+			class := int(v[0] - '0')
+			checks = append(checks, func(code int) bool { return code/100 == class })
+		case strings.Contains(v, "-"):
+			lo, errLo := strconv.Atoi(strings.SplitN(v, "-", 2)[0])
+			hi, errHi := strconv.Atoi(strings.SplitN(v, "-", 2)[1])
+			if errLo != nil || errHi != nil {
+				return nil, fmt.Errorf("invalid status range %q", v)
+			}
+			checks = append(checks, func(code int) bool { return code >= lo && code <= hi })
+		default:
+			want, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid status %q", v)
+			}
+			checks = append(checks, func(code int) bool { return code == want })
+		}
+	}
+	return func(e LogEntry) bool {
+		for _, check := range checks {
+			if check(e.StatusCode) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// textMatcher matches a text field. "~re" is a regular expression, values
+// with * or ? are anchored globs, anything else is a case-insensitive substring.
+func textMatcher(op, value string, get func(LogEntry) string) (func(LogEntry) bool, *regexp.Regexp, error) {
+	if op != ":" {
+		return nil, nil, fmt.Errorf("use ':' with text fields")
+	}
+	matchRe, highlightRe, err := textPattern(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	return func(e LogEntry) bool { return matchRe.MatchString(get(e)) }, highlightRe, nil
+}
+
+// textPattern compiles a text value into a match and a highlight expression
+func textPattern(value string) (*regexp.Regexp, *regexp.Regexp, error) {
+	if strings.HasPrefix(value, "~") {
+		re, err := regexp.Compile("(?i)" + value[1:])
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid regular expression: %w", err)
+		}
+		return re, re, nil
+	}
+
+	var match, highlight []string
+	for _, v := range strings.Split(value, ",") {
+		if strings.ContainsAny(v, "*?") {
+			match = append(match, "^"+globToRegexp(v, ".*", ".")+"$")
+			highlight = append(highlight, globToRegexp(v, `\S*`, `\S`))
+		} else {
+			match = append(match, regexp.QuoteMeta(v))
+			highlight = append(highlight, regexp.QuoteMeta(v))
+		}
+	}
+	matchRe, err := regexp.Compile("(?i)" + strings.Join(match, "|"))
+	if err != nil {
+		return nil, nil, err
+	}
+	highlightRe, err := regexp.Compile("(?i)" + strings.Join(highlight, "|"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return matchRe, highlightRe, nil
+}
+
+// globToRegexp converts * and ? wildcards; the rest is matched literally
+func globToRegexp(glob, star, question string) string {
+	var sb strings.Builder
+	for _, r := range glob {
+		switch r {
+		case '*':
+			sb.WriteString(star)
+		case '?':
+			sb.WriteString(question)
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return sb.String()
+}
+
+// ipMatcher matches CIDR ranges ("10.0.0.0/8"), exact addresses, globs
+// ("192.168.1.*") or a regular expression ("~...")
+func ipMatcher(op, value string) (func(LogEntry) bool, *regexp.Regexp, error) {
+	if op != ":" {
+		return nil, nil, fmt.Errorf("use ':' with ip")
+	}
+	if strings.HasPrefix(value, "~") {
+		re, _, err := textPattern(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		return func(e LogEntry) bool { return re.MatchString(e.IP) }, re, nil
+	}
+
+	var networks []*net.IPNet
+	var addrs []net.IP
+	var globs, highlights []string
+	for _, v := range strings.Split(value, ",") {
+		switch {
+		case strings.Contains(v, "/"):
+			_, network, err := net.ParseCIDR(v)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid network %q", v)
+			}
+			networks = append(networks, network)
+		case strings.ContainsAny(v, "*?"):
+			globs = append(globs, v)
+		default:
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, nil, fmt.Errorf("invalid address %q (use a glob like %s* for a prefix)", v, v)
+			}
+			addrs = append(addrs, ip)
+			highlights = append(highlights, regexp.QuoteMeta(v))
+		}
+	}
+
+	var globRe, highlightRe *regexp.Regexp
+	if len(globs) > 0 {
+		var err error
+		var globHighlight *regexp.Regexp
+		globRe, globHighlight, err = textPattern(strings.Join(globs, ","))
+		if err != nil {
+			return nil, nil, err
+		}
+		highlights = append(highlights, globHighlight.String()[len("(?i)"):])
+	}
+	if len(highlights) > 0 {
+		highlightRe = regexp.MustCompile("(?i)" + strings.Join(highlights, "|"))
+	}
+
+	return func(e LogEntry) bool {
+		if ip := net.ParseIP(e.IP); ip != nil {
+			for _, addr := range addrs {
+				if addr.Equal(ip) {
+					return true
+				}
+			}
+			for _, network := range networks {
+				if network.Contains(ip) {
+					return true
+				}
+			}
+		}
+		return globRe != nil && globRe.MatchString(e.IP)
+	}, highlightRe, nil
+}
+
+// countryMatcher matches ISO country codes ("DE", "de,at") exactly; globs
+// and regular expressions are matched as text
+func countryMatcher(op, value string) (func(LogEntry) bool, error) {
+	if strings.HasPrefix(value, "~") || strings.ContainsAny(value, "*?") {
+		match, _, err := textMatcher(op, value, func(e LogEntry) string { return LookupGeo(e.IP).Country })
+		return match, err
+	}
+	if op != ":" {
+		return nil, fmt.Errorf("use ':' with country")
+	}
+	codes := strings.Split(strings.ToUpper(value), ",")
+	return func(e LogEntry) bool {
+		country := LookupGeo(e.IP).Country
+		return country != "" && slices.Contains(codes, strings.ToUpper(country))
+	}, nil
+}
+
+// clientMatcher matches the kind of client behind the user agent: "bot" for
+// any automated client, "human" for browsers, or a kind such as "search" or "ai"
+func clientMatcher(op, value string) (func(LogEntry) bool, error) {
+	if op != ":" {
+		return nil, fmt.Errorf("use ':' with client")
+	}
+
+	var kinds []ClientKind
+	for _, v := range strings.Split(strings.ToLower(value), ",") {
+		switch v {
+		case "bot", "bots":
+			for _, k := range ClientKinds {
+				if k.IsBot() {
+					kinds = append(kinds, k)
+				}
+			}
+		case "humans", "browser", "browsers":
+			kinds = append(kinds, HumanClient)
+		default:
+			k, ok := parseClientKind(v)
+			if !ok {
+				return nil, fmt.Errorf("unknown client kind %q (use bot, human, search, ai, monitor, script, other or unknown)", v)
+			}
+			kinds = append(kinds, k)
+		}
+	}
+	return func(e LogEntry) bool {
+		return slices.Contains(kinds, ClassifyUserAgent(e.UserAgent))
+	}, nil
+}
+
+// numberMatcher compares a numeric field; ":" means equality
+func numberMatcher(op, value string, parse func(string) (float64, error), get func(LogEntry) float64) (func(LogEntry) bool, error) {
+	want, err := parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %q", value)
+	}
+	return func(e LogEntry) bool {
+		return compare(get(e), op, want)
+	}, nil
+}
+
+// compare applies a query operator
+func compare(got float64, op string, want float64) bool {
+	switch op {
+	case ">":
+		return got > want
+	case ">=":
+		return got >= want
+	case "<":
+		return got < want
+	case "<=":
+		return got <= want
+	default:
+		return got == want
+	}
+}
+
+// parseSize parses byte counts with an optional K, M or G suffix ("1M", "512k", "2GB")
+func parseSize(value string) (float64, error) {
+	v := strings.TrimSuffix(strings.ToUpper(value), "B")
+	multiplier := 1.0
+	switch {
+	case strings.HasSuffix(v, "K"):
+		multiplier, v = 1<<10, strings.TrimSuffix(v, "K")
+	case strings.HasSuffix(v, "M"):
+		multiplier, v = 1<<20, strings.TrimSuffix(v, "M")
+	case strings.HasSuffix(v, "G"):
+		multiplier, v = 1<<30, strings.TrimSuffix(v, "G")
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	return n * multiplier, err
+}
+
+// parseQueryNumber parses a plain number
+func parseQueryNumber(value string) (float64, error) {
+	return strconv.ParseFloat(value, 64)
+}
+
+// parseQuerySeconds parses a duration ("250ms", "1.5s") or plain seconds ("0.25")
+func parseQuerySeconds(value string) (float64, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return d.Seconds(), nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// timeMatcher handles since: and until:, given as a duration ago or a timestamp
+func timeMatcher(field, op, value string) (func(LogEntry) bool, error) {
+	if op != ":" {
+		return nil, fmt.Errorf("use ':' with %s", field)
+	}
+
+	var at func() time.Time
+	if d, err := time.ParseDuration(value); err == nil {
+		// Relative times move with the clock so a live filter keeps sliding
+		at = func() time.Time { return time.Now().Add(-d) }
+	} else {
+		t, err := parseQueryTime(value)
+		if err != nil {
+			return nil, err
+		}
+		at = func() time.Time { return t }
+	}
+
+	if field == "since" {
+		return func(e LogEntry) bool { return !e.Timestamp.Before(at()) }, nil
+	}
+	return func(e LogEntry) bool { return e.Timestamp.Before(at()) }, nil
+}
+
+// ParseLogTime parses a time the way since: and until: terms take it, as a
+// duration ago or a timestamp
+func ParseLogTime(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return parseQueryTime(value)
+}
+
+// parseQueryTime parses an absolute time in local time unless it has a zone
+func parseQueryTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02T15:04:05", "2006-01-02", "15:04", "15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			if strings.HasPrefix(layout, "15:04") {
+				// A bare clock time means today
+				now := time.Now()
+				t = time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use a duration like 15m or a timestamp)", value)
+}
+
+// varMatcher matches a log_format variable as text, or as a number when compared
+func varMatcher(op, value, name string) (func(LogEntry) bool, *regexp.Regexp, error) {
+	if op != ":" {
+		want, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid number %q", value)
+		}
+		return func(e LogEntry) bool {
+			got, err := strconv.ParseFloat(e.Vars[name], 64)
+			return err == nil && compare(got, op, want)
+		}, nil, nil
+	}
+	match, _, err := textMatcher(op, value, func(e LogEntry) string { return e.Vars[name] })
+	return match, nil, err
+}
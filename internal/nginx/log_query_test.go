@@ -0,0 +1,226 @@
+package nginx
+
+import (
+	"testing"
+	"time"
+)
+
+const (
+	firefoxUA   = "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"
+	googlebotUA = "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+)
+
+func TestLogQueryMatch(t *testing.T) {
+	entry := LogEntry{
+		IP:           "192.168.1.10",
+		Timestamp:    time.Now().Add(-5 * time.Minute),
+		Method:       "POST",
+		Path:         "/api/v1/orders",
+		StatusCode:   502,
+		BytesSent:    2 << 20,
+		UserAgent:    firefoxUA,
+		Referer:      "https://example.com/cart",
+		Host:         "shop.example.com",
+		RequestTime:  1500 * time.Millisecond,
+		UpstreamTime: 1200 * time.Millisecond,
+		Vars:         map[string]string{"upstream_addr": "10.0.0.5:8080", "upstream_connect_time": "0.004"},
+		Site:         "shop",
+		Raw:          `192.168.1.10 - - [...] "POST /api/v1/orders HTTP/1.1" 502 2097152 "https://example.com/cart" "Firefox"`,
+	}
+	v6 := LogEntry{IP: "2001:db8::10", UserAgent: googlebotUA}
+
+	tests := []struct {
+		expr  string
+		entry LogEntry
+		want  bool
+	}{
+		{"", entry, true},
+		{"status:502", entry, true},
+		{"status:5xx", entry, true},
+		{"status:4xx", entry, false},
+		{"status:4xx,5xx", entry, true},
+		{"status:500-503", entry, true},
+		{"status>=500", entry, true},
+		{"status<500", entry, false},
+		{"code:502", entry, true},
+		{"-status:5xx", entry, false},
+		{"!status:2xx", entry, true},
+		{"method:post", entry, true},
+		{"method:GET,POST", entry, true},
+		{"method:GET", entry, false},
+		{"path:/api/*", entry, true},
+		{"path:/api", entry, true},
+		{"path:/api/v?/orders", entry, true},
+		{"path:/v1/*", entry, false},
+		{"path:~^/api/v[0-9]+/", entry, true},
+		{"host:shop.*", entry, true},
+		{"referer:cart", entry, true},
+		{"site:shop", entry, true},
+		{"ua:firefox", entry, true},
+		{"bytes>1M", entry, true},
+		{"bytes>=3M", entry, false},
+		{"size:2097152", entry, true},
+		{"rt>1s", entry, true},
+		{"rt>1.5", entry, false},
+		{"latency>=1500ms", entry, true},
+		{"urt<1.3s", entry, true},
+		{"since:15m", entry, true},
+		{"since:1m", entry, false},
+		{"until:1m", entry, true},
+		{"upstream_addr:10.0.0.5*", entry, true},
+		{"upstream_addr:10.0.0.6*", entry, false},
+		{"$upstream_connect_time<0.01", entry, true},
+		{"upstream_connect_time>0.01", entry, false},
+		{"orders", entry, true},
+		{`"HTTP/1.1" 502`, entry, true},
+		{"humans", entry, true},
+		{"bots", entry, false},
+		{"client:bot", v6, true},
+		{"client:search", v6, true},
+		{"status:5xx method:POST path:/api/*", entry, true},
+		{"status:5xx method:GET", entry, false},
+	}
+	for _, tt := range tests {
+		q, err := ParseLogQuery(tt.expr)
+		if err != nil {
+			t.Errorf("ParseLogQuery(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := q.Match(tt.entry); got != tt.want {
+			t.Errorf("%q.Match = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestLogQueryIP(t *testing.T) {
+	tests := []struct {
+		expr string
+		ip   string
+		want bool
+	}{
+		// A bare address is the address itself, not a prefix of others
+		{"ip:10.0.0.1", "10.0.0.1", true},
+		{"ip:10.0.0.1", "10.0.0.10", false},
+		{"ip:10.0.0.1", "110.0.0.1", false},
+		{"ip:10.0.0.1,10.0.0.2", "10.0.0.2", true},
+		{"ip:2001:db8::1", "2001:db8:0:0:0:0:0:1", true},
+		{"ip:2001:db8::1", "2001:db8::10", false},
+		{"ip:10.0.0.0/8", "10.20.30.40", true},
+		{"ip:10.0.0.0/8", "11.0.0.1", false},
+		{"ip:10.0.0.1/32", "10.0.0.1", true},
+		{"ip:10.0.0.1/32", "10.0.0.10", false},
+		{"ip:2001:db8::/32", "2001:db8:ffff::1", true},
+		{"ip:192.168.1.*", "192.168.1.77", true},
+		{"ip:192.168.1.*", "192.168.10.1", false},
+		{"ip:10.0.0.?", "10.0.0.7", true},
+		{"ip:10.0.0.?", "10.0.0.17", false},
+		{"ip:~^10\\.0\\.0\\.1\\d$", "10.0.0.15", true},
+		{"ip:10.0.0.0/8,192.168.1.*", "192.168.1.1", true},
+		{"-ip:10.0.0.0/8", "192.168.1.1", true},
+		{"ip:10.0.0.1", "not-an-ip", false},
+	}
+	for _, tt := range tests {
+		q, err := ParseLogQuery(tt.expr)
+		if err != nil {
+			t.Errorf("ParseLogQuery(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := q.Match(LogEntry{IP: tt.ip}); got != tt.want {
+			t.Errorf("%q on %s = %v, want %v", tt.expr, tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestParseLogQueryErrors(t *testing.T) {
+	for _, expr := range []string{
+		"status:",
+		"status:abc",
+		"status:4xx-5xx",
+		"method>GET",
+		"path:~(",
+		"ip:10.0.0",
+		"ip:10.0.0.0/33",
+		"ip>10.0.0.1",
+		"client:robots",
+		"bytes>lots",
+		"rt>soon",
+		"since:yesterday",
+		"since>15m",
+		"upstream_connect_time>fast",
+	} {
+		if _, err := ParseLogQuery(expr); err == nil {
+			t.Errorf("ParseLogQuery(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestLogQueryHighlight(t *testing.T) {
+	tests := []struct {
+		expr, field, s, want string
+	}{
+		{"path:/api", "path", "/api/v1", "\033[7m/api\033[27m/v1"},
+		{"path:/api/*", "path", "/api/v1 ", "\033[7m/api/v1\033[27m "},
+		{"method:post", "method", "POST", "\033[7mPOST\033[27m"},
+		{"ip:10.0.0.1", "ip", "10.0.0.1  ", "\033[7m10.0.0.1\033[27m  "},
+		{"orders", "path", "/orders", "/\033[7morders\033[27m"},
+		// Terms about other fields, negated terms and numbers don't mark anything
+		{"path:/api", "ip", "/api", "/api"},
+		{"-path:/api", "path", "/api", "/api"},
+		{"status:5xx", "status", "502", "502"},
+		// Overlapping matches are merged
+		{"path:/api path:api/v1", "path", "/api/v1", "\033[7m/api\033[27m\033[7m/v1\033[27m"},
+	}
+	for _, tt := range tests {
+		q, err := ParseLogQuery(tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := q.Highlight(tt.field, tt.s); got != tt.want {
+			t.Errorf("%q.Highlight(%s, %q) = %q, want %q", tt.expr, tt.field, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestSplitQuery(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"", nil},
+		{"  status:5xx   method:GET ", []string{"status:5xx", "method:GET"}},
+		{`ua:"Mozilla/5.0 (X11" path:/`, []string{"ua:Mozilla/5.0 (X11", "path:/"}},
+	}
+	for _, tt := range tests {
+		got := splitQuery(tt.expr)
+		if len(got) != len(tt.want) {
+			t.Errorf("splitQuery(%q) = %q, want %q", tt.expr, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("splitQuery(%q) = %q, want %q", tt.expr, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"512", 512},
+		{"1k", 1024},
+		{"1.5K", 1536},
+		{"2M", 2 << 20},
+		{"2MB", 2 << 20},
+		{"1G", 1 << 30},
+	}
+	for _, tt := range tests {
+		got, err := parseSize(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("parseSize(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package nginx
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// SearchAccessLogs returns the newest limit entries within the range that
+// match the query. Unlike the tailer's window it covers everything the files
+// hold: the current files for the live range, rotated ones too otherwise.
+func (s *Service) SearchAccessLogs(q *LogQuery, tr TimeRange, limit int) ([]LogEntry, error) {
+	var matches []LogEntry
+	err := s.ScanAccessLogs(tr, func(entry LogEntry) {
+		if !q.Match(entry) {
+			return
+		}
+		matches = append(matches, entry)
+		if len(matches) > 2*limit {
+			// Logs are read one after another, so order by time before trimming
+			matches = append([]LogEntry(nil), lastEntries(matches, limit)...)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lastEntries(matches, limit), nil
+}
+
+// openAccessLog opens a whole access log, inside the container when one is given
+func openAccessLog(containerID, path string) (io.ReadCloser, error) {
+	if containerID == "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open access log: %w", err)
+		}
+		return file, nil
+	}
+
+	var cmd *exec.Cmd
+	if path == DefaultAccessLog {
+		// The official image links the default access log to stdout
+		cmd = exec.Command("docker", "logs", containerID)
+	} else {
+		cmd = exec.Command("docker", "exec", containerID, "cat", path)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to read access log: %w", err)
+	}
+	return &commandReader{ReadCloser: stdout, cmd: cmd}, nil
+}
+
+// commandReader is a command's output that reaps the command when closed
+type commandReader struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+// Close stops the command if it is still writing and waits for it
+func (r *commandReader) Close() error {
+	r.ReadCloser.Close()
+	r.cmd.Process.Kill()
+	r.cmd.Wait()
+	return nil
+}
@@ -0,0 +1,100 @@
+package nginx
+
+import (
+	"slices"
+	"sort"
+)
+
+// LogKind tells access logs from error logs
+type LogKind int
+
+const (
+	AccessLogKind LogKind = iota
+	ErrorLogKind
+)
+
+// String names the kind, e.g. in errors
+func (k LogKind) String() string {
+	if k == ErrorLogKind {
+		return "error log"
+	}
+	return "access log"
+}
+
+// LogSource is a selectable set of logs: every log of a kind on the host,
+// or the logs a single site declares
+type LogSource struct {
+	Label  string
+	Site   string // Empty for host-wide sources
+	Kind   LogKind
+	Paths  []string // Empty for host-wide sources, which include every log of the kind
+	Shared bool     // Some of the files are also written by other sites
+}
+
+// Includes reports whether the source shows the log at path
+func (src LogSource) Includes(path string) bool {
+	return len(src.Paths) == 0 || slices.Contains(src.Paths, path)
+}
+
+// LogSources lists the host-wide sources followed by each site's access and
+// error logs, sites in name order
+func LogSources(access []AccessLog, errors []ErrorLog) []LogSource {
+	sources := []LogSource{
+		{Label: "All access logs", Kind: AccessLogKind},
+		{Label: "All error logs", Kind: ErrorLogKind},
+	}
+
+	siteAccess := map[string]*LogSource{}
+	siteErrors := map[string]*LogSource{}
+	var sites []string
+	add := func(bySite map[string]*LogSource, kind LogKind, label, path string, logSites []string) {
+		for _, site := range logSites {
+			src, ok := bySite[site]
+			if !ok {
+				src = &LogSource{Label: site + " " + label, Site: site, Kind: kind}
+				bySite[site] = src
+				if !slices.Contains(sites, site) {
+					sites = append(sites, site)
+				}
+			}
+			src.Paths = append(src.Paths, path)
+			src.Shared = src.Shared || len(logSites) > 1
+		}
+	}
+	for _, log := range access {
+		add(siteAccess, AccessLogKind, "access", log.Path, log.Sites)
+	}
+	for _, log := range errors {
+		add(siteErrors, ErrorLogKind, "errors", log.Path, log.Sites)
+	}
+
+	sort.Strings(sites)
+	for _, site := range sites {
+		if src, ok := siteAccess[site]; ok {
+			sources = append(sources, *src)
+		}
+		if src, ok := siteErrors[site]; ok {
+			sources = append(sources, *src)
+		}
+	}
+	return sources
+}
+
+// SiteLogSource returns the index of a site's source of the given kind
+func SiteLogSource(sources []LogSource, site string, kind LogKind) (int, bool) {
+	for i, src := range sources {
+		if src.Site == site && src.Kind == kind {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// SelectedLogSource returns the source at index i, or every access log when
+// the index is out of range (before the sources are resolved)
+func SelectedLogSource(sources []LogSource, i int) LogSource {
+	if i >= 0 && i < len(sources) {
+		return sources[i]
+	}
+	return LogSource{Label: "All access logs", Kind: AccessLogKind}
+}
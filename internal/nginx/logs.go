@@ -1,10 +1,9 @@
 package nginx
 
 import (
-	"bufio"
 	"fmt"
 	"os"
-	"regexp"
+	"sort"
 	"strings"
 	"time"
 )
@@ -19,195 +18,280 @@ type LogEntry struct {
 	BytesSent   int
 	UserAgent   string
 	Referer     string
-	StatusClass string // "2xx", "3xx", "4xx", "5xx"
+	StatusClass string // "1xx" to "5xx"; empty when the format logs no valid $status
+
+	Host         string
+	RequestTime  time.Duration     // $request_time
+	UpstreamTime time.Duration     // $upstream_response_time, summed over upstreams
+	Vars         map[string]string // Every variable of the log_format, by name
+
+	Log      string // Path of the access log the entry came from
+	Site     string // Site whose access_log the entry came from
+	Format   string // Name of the log_format used to parse the line
+	Raw      string // The line as written
+	ParseErr error  // Set when the line didn't match the format; only Raw is filled in
 }
 
-// GetAccessLogs returns the last N lines of the access log
+// GetAccessLogs returns the last N entries across the configured access logs
 func (s *Service) GetAccessLogs(maxLines int) ([]LogEntry, error) {
+	logs := s.AccessLogs()
+
 	// Check if Docker NGINX (with caching)
 	if IsDockerAvailable() {
 		containerID, err := getCachedContainerID()
 		if err == nil {
-			return GetDockerAccessLogs(containerID, maxLines)
+			return GetDockerAccessLogs(containerID, maxLines, logs)
 		}
 	}
 
 	// Native NGINX
-	logPath := "/var/log/nginx/access.log"
-
-	file, err := os.Open(logPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open access log: %w", err)
+	var entries []LogEntry
+	var firstErr error
+	opened := 0
+	for _, log := range logs {
+		lines, err := tailFile(log.Path, maxLines)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to open access log: %w", err)
+			}
+			continue
+		}
+		opened++
+		entries = append(entries, parseAccessLogLines(lines, log)...)
+	}
+	if opened == 0 && firstErr != nil {
+		return nil, firstErr
 	}
-	defer file.Close()
 
-	var entries []LogEntry
-	scanner := bufio.NewScanner(file)
+	return lastEntries(entries, maxLines), nil
+}
 
-	// Read all lines first, then take last N
-	var lines []string
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+// tailFile returns the last maxLines lines of a file
+func tailFile(path string, maxLines int) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
 	}
+	defer file.Close()
 
-	if err := scanner.Err(); err != nil {
+	info, err := file.Stat()
+	if err != nil {
 		return nil, err
 	}
+	return readLastLines(file, info.Size(), maxLines)
+}
 
-	// Take last maxLines
-	startIdx := 0
-	if len(lines) > maxLines {
-		startIdx = len(lines) - maxLines
+// parseAccessLogLines parses lines from an access log with its format. Lines
+// that don't match are kept with ParseErr set rather than dropped.
+func parseAccessLogLines(lines []string, log AccessLog) []LogEntry {
+	site := ""
+	if len(log.Sites) == 1 {
+		site = log.Sites[0]
 	}
 
-	// Parse each line
-	for i := startIdx; i < len(lines); i++ {
-		entry, err := parseAccessLogLine(lines[i])
-		if err == nil {
-			entries = append(entries, entry)
+	var entries []LogEntry
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
 		}
+		entry, err := log.Format.Parse(line)
+		entry.ParseErr = err
+		entry.Log = log.Path
+		entry.Site = site
+		entries = append(entries, entry)
 	}
 
-	return entries, nil
+	// Give unparsed lines the time of the next parsed one so sorting by
+	// time keeps them next to their neighbours
+	next := time.Now()
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].ParseErr != nil {
+			entries[i].Timestamp = next
+		} else {
+			next = entries[i].Timestamp
+		}
+	}
+	return entries
 }
 
-// parseAccessLogLine parses a standard NGINX access log line
-// Format: $remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent"
-func parseAccessLogLine(line string) (LogEntry, error) {
-	// Regex pattern for standard combined log format
-	pattern := `^(\S+) \S+ \S+ \[([^\]]+)\] "(\S+) ([^"]+) \S+" (\d+) (\d+) "([^"]*)" "([^"]*)"`
-	re := regexp.MustCompile(pattern)
-
-	matches := re.FindStringSubmatch(line)
-	if len(matches) < 9 {
-		return LogEntry{}, fmt.Errorf("failed to parse log line")
+// AppendLogEntries adds newly read entries to a time-ordered window and
+// keeps the newest maxEntries
+func AppendLogEntries(entries, batch []LogEntry, maxEntries int) []LogEntry {
+	if len(batch) == 0 {
+		return entries
 	}
-
-	// Parse timestamp
-	timestamp, err := time.Parse("02/Jan/2006:15:04:05 -0700", matches[2])
-	if err != nil {
-		timestamp = time.Now()
+	inOrder := len(entries) == 0 || !batch[0].Timestamp.Before(entries[len(entries)-1].Timestamp)
+	entries = append(entries, batch...)
+	if !inOrder {
+		// Batches from different logs interleave
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Timestamp.Before(entries[j].Timestamp)
+		})
 	}
-
-	// Parse status code
-	var statusCode int
-	fmt.Sscanf(matches[5], "%d", &statusCode)
-
-	// Parse bytes sent
-	var bytesSent int
-	fmt.Sscanf(matches[6], "%d", &bytesSent)
-
-	// Determine status class
-	statusClass := "5xx"
-	if statusCode >= 200 && statusCode < 300 {
-		statusClass = "2xx"
-	} else if statusCode >= 300 && statusCode < 400 {
-		statusClass = "3xx"
-	} else if statusCode >= 400 && statusCode < 500 {
-		statusClass = "4xx"
+	if len(entries) > maxEntries {
+		entries = append([]LogEntry(nil), entries[len(entries)-maxEntries:]...)
 	}
-
-	return LogEntry{
-		IP:          matches[1],
-		Timestamp:   timestamp,
-		Method:      matches[3],
-		Path:        matches[4],
-		StatusCode:  statusCode,
-		BytesSent:   bytesSent,
-		Referer:     matches[7],
-		UserAgent:   matches[8],
-		StatusClass: statusClass,
-	}, nil
+	return entries
 }
 
-// GetErrorLogs returns recent error log entries
-func (s *Service) GetErrorLogs(maxLines int) ([]string, error) {
-	logPath := "/var/log/nginx/error.log"
-
-	file, err := os.Open(logPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open error log: %w", err)
-	}
-	defer file.Close()
-
-	var lines []string
-	scanner := bufio.NewScanner(file)
-
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+// lastEntries orders entries from several logs by time and keeps the newest maxLines
+func lastEntries(entries []LogEntry, maxLines int) []LogEntry {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	if len(entries) > maxLines {
+		entries = entries[len(entries)-maxLines:]
 	}
+	return entries
+}
 
-	if err := scanner.Err(); err != nil {
+// GetLogStats returns statistics over a time range of the access logs,
+// streaming rotated and compressed files for historical ranges
+func (s *Service) GetLogStats(tr TimeRange) (*LogStats, error) {
+	builder := NewLogStatsBuilder()
+	if err := s.ScanAccessLogs(tr, builder.Add); err != nil {
 		return nil, err
 	}
+	return builder.Stats(), nil
+}
 
-	// Take last maxLines
-	startIdx := 0
-	if len(lines) > maxLines {
-		startIdx = len(lines) - maxLines
+// ComputeLogStats aggregates statistics over parsed log entries
+func ComputeLogStats(entries []LogEntry) *LogStats {
+	builder := NewLogStatsBuilder()
+	for _, entry := range entries {
+		builder.Add(entry)
 	}
+	return builder.Stats()
+}
 
-	return lines[startIdx:], nil
+// LogStatsBuilder aggregates statistics one entry at a time, so logs of any
+// size can be summarised while they are streamed
+type LogStatsBuilder struct {
+	stats     *LogStats
+	uniqueIPs map[string]bool
+	latency   *LatencyBuilder
+	geoIP     bool
 }
 
-// GetLogStats returns statistics from access logs
-func (s *Service) GetLogStats() (*LogStats, error) {
-	entries, err := s.GetAccessLogs(1000) // Analyze last 1000 entries
-	if err != nil {
-		return nil, err
+// NewLogStatsBuilder creates an empty builder
+func NewLogStatsBuilder() *LogStatsBuilder {
+	return &LogStatsBuilder{
+		stats: &LogStats{
+			StatusCounts: make(map[string]int),
+			MethodCounts: make(map[string]int),
+			TopPaths:     make(map[string]int),
+			ClientKinds:  make(map[ClientKind]int),
+			Countries:    make(map[string]int),
+			Traffic:      make(map[TrafficDimension]map[string]TrafficCount),
+			Timeline:     NewStatusTimeline(),
+		},
+		uniqueIPs: make(map[string]bool),
+		latency:   NewLatencyBuilder(),
+		geoIP:     GeoIPEnabled(),
 	}
+}
 
-	stats := &LogStats{
-		TotalRequests: len(entries),
-		StatusCounts:  make(map[string]int),
-		MethodCounts:  make(map[string]int),
-		TopPaths:      make(map[string]int),
+// Add counts an entry
+func (b *LogStatsBuilder) Add(entry LogEntry) {
+	stats := b.stats
+	if entry.ParseErr != nil {
+		stats.UnparsedLines++
+		return
 	}
 
-	var totalBytes int64
-	uniqueIPs := make(map[string]bool)
-
-	for _, entry := range entries {
-		// Count by status class
+	// Count by status class
+	if entry.StatusClass != "" {
 		stats.StatusCounts[entry.StatusClass]++
+	}
 
-		// Count by method
-		stats.MethodCounts[entry.Method]++
+	// Count by method
+	stats.MethodCounts[entry.Method]++
 
-		// Track top paths
-		stats.TopPaths[entry.Path]++
+	// Track top paths
+	stats.TopPaths[entry.Path]++
 
-		// Track unique IPs
-		uniqueIPs[entry.IP] = true
+	// Tell browsers from crawlers and scripts
+	stats.ClientKinds[ClassifyUserAgent(entry.UserAgent)]++
 
-		// Sum bytes
-		totalBytes += int64(entry.BytesSent)
+	// Count by country when a GeoIP database is loaded
+	if b.geoIP {
+		stats.Countries[LookupGeo(entry.IP).Country]++
 	}
 
-	stats.UniqueIPs = len(uniqueIPs)
-	stats.TotalBytes = totalBytes
+	// Break traffic down by site, IP, path, user agent, referer, status, method and client
+	addTraffic(stats.Traffic, entry)
+
+	// Track unique IPs
+	b.uniqueIPs[entry.IP] = true
+
+	// Sum bytes
+	stats.TotalBytes += int64(entry.BytesSent)
+	stats.TotalRequests++
+	b.latency.Add(entry)
+	stats.Timeline.Add(entry.Timestamp, entry.StatusClass)
+
+	if stats.First.IsZero() || entry.Timestamp.Before(stats.First) {
+		stats.First = entry.Timestamp
+	}
+	if entry.Timestamp.After(stats.Last) {
+		stats.Last = entry.Timestamp
+	}
+}
+
+// Stats returns the statistics so far
+func (b *LogStatsBuilder) Stats() *LogStats {
+	stats := *b.stats
+	stats.UniqueIPs = len(b.uniqueIPs)
+	stats.Latency = b.latency.Report()
 
 	// Calculate average bytes per request
 	if stats.TotalRequests > 0 {
-		stats.AvgBytesPerRequest = totalBytes / int64(stats.TotalRequests)
+		stats.AvgBytesPerRequest = stats.TotalBytes / int64(stats.TotalRequests)
 	}
-
-	return stats, nil
+	return &stats
 }
 
 // LogStats represents aggregated log statistics
 type LogStats struct {
 	TotalRequests      int
 	UniqueIPs          int
-	StatusCounts       map[string]int // "2xx", "3xx", "4xx", "5xx"
+	StatusCounts       map[string]int // "1xx" to "5xx"
 	MethodCounts       map[string]int // "GET", "POST", etc.
 	TopPaths           map[string]int
 	TotalBytes         int64
 	AvgBytesPerRequest int64
+	UnparsedLines      int                // Lines that didn't match their log_format
+	First, Last        time.Time          // Times of the oldest and newest requests
+	Latency            *LatencyReport     // Over every entry, when the format logs timings
+	ClientKinds        map[ClientKind]int // Requests by the kind of client sending them
+	Countries          map[string]int     // Requests by country code, "" when unknown; empty without GeoIP
+	Traffic            map[TrafficDimension]map[string]TrafficCount
+	Timeline           *StatusTimeline // Requests over time by status class
+}
+
+// BotRequests returns the requests sent by automated clients
+func (s *LogStats) BotRequests() int {
+	bots := 0
+	for kind, count := range s.ClientKinds {
+		if kind.IsBot() {
+			bots += count
+		}
+	}
+	return bots
 }
 
 // FormatLogEntry formats a log entry for display with colors and detailed information
 func FormatLogEntry(entry LogEntry) string {
+	return FormatLogEntryMatch(entry, nil)
+}
+
+// FormatLogEntryMatch formats a log entry like FormatLogEntry, highlighting
+// the text matched by a filter query
+func FormatLogEntryMatch(entry LogEntry, q *LogQuery) string {
+	if entry.ParseErr != nil {
+		return fmt.Sprintf("\033[90m? %s\033[0m", q.Highlight("text", truncateString(entry.Raw, 130)))
+	}
+
 	// Color codes based on status
 	var statusColor string
 	var statusIcon string
@@ -277,19 +361,27 @@ func FormatLogEntry(entry LogEntry) string {
 		}
 	}
 
+	// Country of the client, when a GeoIP database is loaded
+	country := ""
+	if GeoIPEnabled() {
+		country = fmt.Sprintf(" \033[90m%-2s\033[0m", truncateString(LookupGeo(entry.IP).Country, 2))
+	}
+
 	// Build formatted line with more information
-	line := fmt.Sprintf("%s%s\033[0m \033[90m%s\033[0m \033[37m%-15s\033[0m \033[36m%-6s\033[0m \033[97m%-35s\033[0m %s%3d\033[0m \033[90m%4s\033[0m \033[35m%-12s\033[0m \033[34m%-10s\033[0m",
+	// Fields are padded before highlighting so escape codes don't upset the columns
+	line := fmt.Sprintf("%s%s\033[0m \033[90m%s\033[0m \033[37m%s\033[0m%s \033[36m%s\033[0m \033[97m%s\033[0m %s%3d\033[0m \033[90m%4s\033[0m \033[35m%s\033[0m \033[34m%s\033[0m",
 		statusColor,
 		statusIcon,
 		timeStr,
-		entry.IP,
-		entry.Method,
-		truncateString(entry.Path, 35),
+		q.Highlight("ip", fmt.Sprintf("%-15s", entry.IP)),
+		country,
+		q.Highlight("method", fmt.Sprintf("%-6s", entry.Method)),
+		q.Highlight("path", fmt.Sprintf("%-35s", truncateString(entry.Path, 35))),
 		statusColor,
 		entry.StatusCode,
 		bytesStr,
-		truncateString(userAgent, 12),
-		truncateString(referer, 10),
+		q.Highlight("ua", fmt.Sprintf("%-12s", truncateString(userAgent, 12))),
+		q.Highlight("referer", fmt.Sprintf("%-10s", truncateString(referer, 10))),
 	)
 
 	return line
@@ -17,10 +17,17 @@ type Metrics struct {
 	NetworkOut     float64 // Total bytes out (cumulative)
 	NetworkInRate  float64 // MB/s
 	NetworkOutRate float64 // MB/s
-	RequestRate    float64
+	RequestRate    float64 // Filled in from the request counter or the tailed access logs
 	ActiveConns    int
 	TotalConns     int64
 	Timestamp      time.Time
+
+	Source         string      // Name of the MetricsSource the traffic metrics came from
+	Connections    *StubStatus // Connection states and counters; nil when only sockets were counted
+	ConnectionsErr error       // Why stub_status couldn't be polled when sockets were counted
+	Zones          []ServerZone
+	Upstreams      []Upstream
+	Caches         []CacheZone
 }
 
 // MetricsHistory stores historical metrics
@@ -89,18 +96,8 @@ func (s *Service) GetMetrics() (*Metrics, error) {
 		metrics.NetworkOut = netOut
 	}
 
-	// Get connection stats
-	activeConns, totalConns, err := s.getConnectionStats()
-	if err == nil {
-		metrics.ActiveConns = activeConns
-		metrics.TotalConns = totalConns
-	}
-
-	// Get request rate
-	rate, _, err := s.calculateRequestRate()
-	if err == nil {
-		metrics.RequestRate = rate
-	}
+	// Get connection and traffic stats from the richest source available
+	s.collectTraffic(metrics)
 
 	return metrics, nil
 }
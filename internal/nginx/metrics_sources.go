@@ -0,0 +1,172 @@
+package nginx
+
+import (
+	"sort"
+	"time"
+)
+
+// Names of the metrics sources, richest first
+const (
+	PlusAPISource    = "NGINX Plus API"
+	VTSSource        = "VTS"
+	StubStatusSource = "stub_status"
+	SocketsSource    = "sockets"
+)
+
+// MetricsSource is a place NGINX traffic metrics are read from. Richer
+// sources know more: connection states and counters, then per-server-zone
+// traffic, upstream peers and cache zones.
+type MetricsSource interface {
+	// Name identifies the source on the Metrics tab
+	Name() string
+	// Collect fills in what the source knows, leaving metrics untouched
+	// when it can't be read
+	Collect(metrics *Metrics) error
+}
+
+// MetricsSources returns every source, richest first
+func (s *Service) MetricsSources() []MetricsSource {
+	return []MetricsSource{plusSource{s}, vtsSource{s}, stubStatusSource{s}, socketsSource{s}}
+}
+
+// collectTraffic fills in connection and traffic metrics from the richest
+// source that can be read
+func (s *Service) collectTraffic(metrics *Metrics) {
+	for _, source := range s.MetricsSources() {
+		err := source.Collect(metrics)
+		if err == nil {
+			metrics.Source = source.Name()
+			return
+		}
+		if source.Name() == StubStatusSource {
+			metrics.ConnectionsErr = err
+		}
+	}
+}
+
+// ResponseCounts counts responses by status class, 1xx to 5xx
+type ResponseCounts [5]int64
+
+// Total returns the responses of every class
+func (r ResponseCounts) Total() int64 {
+	return r[0] + r[1] + r[2] + r[3] + r[4]
+}
+
+// ServerZone is the traffic of a server zone (a server_name for VTS, a
+// status_zone for the Plus API) since NGINX started
+type ServerZone struct {
+	Name       string
+	Requests   int64
+	Processing int64 // Requests being processed; Plus API only
+	Responses  ResponseCounts
+	Received   int64 // Bytes
+	Sent       int64 // Bytes
+}
+
+// Upstream is an upstream group and its peers
+type Upstream struct {
+	Name  string
+	Peers []UpstreamPeer
+}
+
+// Up returns how many of the group's peers are up
+func (u Upstream) Up() int {
+	up := 0
+	for _, peer := range u.Peers {
+		if peer.State == "up" {
+			up++
+		}
+	}
+	return up
+}
+
+// UpstreamPeer is one server of an upstream group
+type UpstreamPeer struct {
+	Server       string
+	State        string // "up", "down", or with the Plus API "unavail", "unhealthy", "checking" or "draining"
+	Backup       bool
+	Weight       int
+	Active       int64 // Open connections; Plus API only
+	Requests     int64
+	Responses    ResponseCounts
+	Fails        int64         // Failed attempts; Plus API only
+	ResponseTime time.Duration // Average time to the full response
+	HealthChecks int64         // Active health checks run; Plus API only
+	HealthFails  int64         // Active health checks failed; Plus API only
+}
+
+// CacheZone is the use of a proxy cache zone since NGINX started
+type CacheZone struct {
+	Name                              string
+	Hit, Stale, Updating, Revalidated int64 // Responses served from the cache
+	Miss, Expired, Bypass             int64 // Responses fetched from upstream
+	Size, MaxSize                     int64 // Bytes; MaxSize is 0 when unlimited
+}
+
+// HitRatio returns the share of cacheable responses served from the cache
+func (c CacheZone) HitRatio() float64 {
+	hits := c.Hit + c.Stale + c.Updating + c.Revalidated
+	total := hits + c.Miss + c.Expired + c.Bypass
+	if total == 0 {
+		return 0
+	}
+	return float64(hits) / float64(total)
+}
+
+// ZoneRates returns each server zone's requests per second between two samples
+func ZoneRates(current, prev *Metrics) map[string]float64 {
+	rates := make(map[string]float64)
+	if current == nil || prev == nil || prev.Source != current.Source || !current.Timestamp.After(prev.Timestamp) {
+		return rates
+	}
+	elapsed := current.Timestamp.Sub(prev.Timestamp).Seconds()
+	before := make(map[string]int64, len(prev.Zones))
+	for _, zone := range prev.Zones {
+		before[zone.Name] = zone.Requests
+	}
+	for _, zone := range current.Zones {
+		if requests, ok := before[zone.Name]; ok && zone.Requests >= requests {
+			rates[zone.Name] = float64(zone.Requests-requests) / elapsed
+		}
+	}
+	return rates
+}
+
+// sortTraffic orders zones, upstreams and caches by name
+func sortTraffic(zones []ServerZone, upstreams []Upstream, caches []CacheZone) {
+	sort.Slice(zones, func(i, j int) bool { return zones[i].Name < zones[j].Name })
+	sort.Slice(upstreams, func(i, j int) bool { return upstreams[i].Name < upstreams[j].Name })
+	sort.Slice(caches, func(i, j int) bool { return caches[i].Name < caches[j].Name })
+}
+
+// stubStatusSource reads connection states and counters from stub_status
+type stubStatusSource struct{ s *Service }
+
+func (stubStatusSource) Name() string { return StubStatusSource }
+
+func (src stubStatusSource) Collect(metrics *Metrics) error {
+	status, err := src.s.StubStatus()
+	if err != nil {
+		return err
+	}
+	metrics.Connections = status
+	metrics.ActiveConns = int(status.Active)
+	metrics.TotalConns = status.Accepts
+	return nil
+}
+
+// socketsSource counts established sockets on the listening ports, which
+// includes upstream and unrelated connections
+type socketsSource struct{ s *Service }
+
+func (socketsSource) Name() string { return SocketsSource }
+
+func (src socketsSource) Collect(metrics *Metrics) error {
+	active, total, err := src.s.getConnectionStats()
+	if err != nil {
+		return err
+	}
+	metrics.ActiveConns = active
+	metrics.TotalConns = total
+	return nil
+}
@@ -0,0 +1,168 @@
+package nginx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aitmiloud/ngxtui/internal/config"
+)
+
+// newStatusServer serves recorded status documents from testdata by path
+func newStatusServer(t *testing.T, files map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeFile(w, r, file)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// plusStatusFiles are the recorded Plus API documents, by request path
+var plusStatusFiles = map[string]string{
+	"/api/":                    "testdata/plus/versions.json",
+	"/api/9/connections":       "testdata/plus/connections.json",
+	"/api/9/http/requests":     "testdata/plus/http_requests.json",
+	"/api/9/http/server_zones": "testdata/plus/http_server_zones.json",
+	"/api/9/http/upstreams":    "testdata/plus/http_upstreams.json",
+	"/api/9/http/caches":       "testdata/plus/http_caches.json",
+}
+
+// checkTraffic compares what a source collected with what it should have
+func checkTraffic(t *testing.T, got *Metrics, want Metrics) {
+	t.Helper()
+	if got.Connections == nil {
+		t.Fatal("no connections collected")
+	}
+	connections := *got.Connections
+	connections.At = time.Time{}
+	if connections != *want.Connections {
+		t.Errorf("connections = %+v, want %+v", connections, *want.Connections)
+	}
+	if got.ActiveConns != want.ActiveConns || got.TotalConns != want.TotalConns {
+		t.Errorf("active %d total %d, want %d and %d", got.ActiveConns, got.TotalConns, want.ActiveConns, want.TotalConns)
+	}
+	if !reflect.DeepEqual(got.Zones, want.Zones) {
+		t.Errorf("zones = %+v\nwant %+v", got.Zones, want.Zones)
+	}
+	if !reflect.DeepEqual(got.Upstreams, want.Upstreams) {
+		t.Errorf("upstreams = %+v\nwant %+v", got.Upstreams, want.Upstreams)
+	}
+	if !reflect.DeepEqual(got.Caches, want.Caches) {
+		t.Errorf("caches = %+v\nwant %+v", got.Caches, want.Caches)
+	}
+}
+
+func TestVTSSource(t *testing.T) {
+	srv := newStatusServer(t, map[string]string{"/status/format/json": "testdata/vts.json"})
+	s := New().WithMetricsConfig(config.MetricsConfig{VTSURL: srv.URL + "/status/format/json"})
+
+	var metrics Metrics
+	if err := (vtsSource{s}).Collect(&metrics); err != nil {
+		t.Fatal(err)
+	}
+	checkTraffic(t, &metrics, Metrics{
+		ActiveConns: 12,
+		TotalConns:  5000,
+		Connections: &StubStatus{Active: 12, Reading: 1, Writing: 3, Waiting: 8, Accepts: 5000, Handled: 4998, Requests: 20000},
+		Zones: []ServerZone{
+			{Name: "api.example.com", Requests: 200, Responses: ResponseCounts{0, 180, 0, 20, 0}, Received: 40000, Sent: 80000},
+			{Name: "example.com", Requests: 1500, Responses: ResponseCounts{0, 1400, 50, 40, 10}, Received: 300000, Sent: 9000000},
+		},
+		Upstreams: []Upstream{
+			{Name: "(no upstream block)", Peers: []UpstreamPeer{
+				{Server: "127.0.0.1:9000", State: "up", Requests: 200, Responses: ResponseCounts{0, 180, 0, 20, 0}, ResponseTime: 29 * time.Millisecond},
+			}},
+			{Name: "backend", Peers: []UpstreamPeer{
+				{Server: "10.0.0.1:8080", State: "up", Weight: 5, Requests: 900, Responses: ResponseCounts{0, 880, 0, 15, 5}, ResponseTime: 42 * time.Millisecond},
+				{Server: "10.0.0.2:8080", State: "down", Backup: true, Weight: 1},
+			}},
+		},
+		Caches: []CacheZone{
+			{Name: "static", Hit: 300, Miss: 100, Expired: 5, Size: 52428800, MaxSize: 1073741824},
+		},
+	})
+	if ratio := metrics.Caches[0].HitRatio(); ratio < 0.7407 || ratio > 0.7408 {
+		t.Errorf("hit ratio = %f, want 300/405", ratio)
+	}
+}
+
+func TestPlusSource(t *testing.T) {
+	srv := newStatusServer(t, plusStatusFiles)
+	s := New().WithMetricsConfig(config.MetricsConfig{PlusAPIURL: srv.URL + "/api"})
+
+	var metrics Metrics
+	if err := (plusSource{s}).Collect(&metrics); err != nil {
+		t.Fatal(err)
+	}
+	checkTraffic(t, &metrics, Metrics{
+		ActiveConns: 122,
+		TotalConns:  4968119,
+		Connections: &StubStatus{Active: 122, Waiting: 117, Accepts: 4968119, Handled: 4968119, Requests: 10624511},
+		Zones: []ServerZone{
+			{Name: "hg.nginx.org", Requests: 175276, Processing: 1, Responses: ResponseCounts{0, 162948, 10117, 2125, 86}, Received: 48720163, Sent: 3328236940},
+			{Name: "trac.nginx.org", Requests: 598834, Responses: ResponseCounts{0, 367318, 211409, 19966, 133}, Received: 217616045, Sent: 12418738810},
+		},
+		Upstreams: []Upstream{
+			{Name: "trac-backend", Peers: []UpstreamPeer{
+				{Server: "10.0.0.1:8080", State: "up", Weight: 1, Requests: 114230, Responses: ResponseCounts{0, 112440, 1200, 550, 40},
+					ResponseTime: 48 * time.Millisecond, HealthChecks: 26284},
+				{Server: "10.0.0.2:8080", State: "unhealthy", Backup: true, Weight: 1, Fails: 3, HealthChecks: 26284, HealthFails: 26284},
+			}},
+		},
+		Caches: []CacheZone{
+			{Name: "http_cache", Hit: 254032, Miss: 1619201, Expired: 45859, Bypass: 200187, Size: 530915328, MaxSize: 536870912},
+		},
+	})
+}
+
+func TestCollectTrafficPrefersPlusAPI(t *testing.T) {
+	files := map[string]string{"/status/format/json": "testdata/vts.json"}
+	for path, file := range plusStatusFiles {
+		files[path] = file
+	}
+	srv := newStatusServer(t, files)
+	s := New().WithMetricsConfig(config.MetricsConfig{
+		VTSURL:     srv.URL + "/status/format/json",
+		PlusAPIURL: srv.URL + "/api",
+	})
+
+	var metrics Metrics
+	s.collectTraffic(&metrics)
+	if metrics.Source != PlusAPISource {
+		t.Errorf("source = %q, want %q", metrics.Source, PlusAPISource)
+	}
+}
+
+func TestPlusSourceErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"no api", map[string]string{}, "404"},
+		{"missing endpoint", map[string]string{"/api/": "testdata/plus/versions.json"}, "404"},
+		{"not json", map[string]string{"/api/": "testdata/vts.json"}, "cannot unmarshal"},
+	}
+	for _, tt := range tests {
+		srv := newStatusServer(t, tt.files)
+		s := New().WithMetricsConfig(config.MetricsConfig{PlusAPIURL: srv.URL + "/api"})
+		var metrics Metrics
+		err := (plusSource{s}).Collect(&metrics)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want it to mention %q", tt.name, err, tt.want)
+		}
+		if metrics.Connections != nil || metrics.Zones != nil {
+			t.Errorf("%s: metrics were filled in on error", tt.name)
+		}
+	}
+}
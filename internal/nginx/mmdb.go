@@ -0,0 +1,279 @@
+package nginx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"os"
+)
+
+// mmdbMetadataMarker precedes the metadata at the end of a MaxMind DB file
+var mmdbMetadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// mmdbReader looks addresses up in a MaxMind DB file, the format GeoLite2,
+// DB-IP and IPinfo databases are published in. The file is read into memory
+// once; see https://maxmind.github.io/MaxMind-DB/ for the layout.
+type mmdbReader struct {
+	tree         []byte
+	data         mmdbDecoder
+	nodeCount    uint
+	recordSize   uint
+	ipVersion    uint
+	ipv4Start    uint // Node reached after the 96 zero bits of an IPv4-mapped address
+	databaseType string
+}
+
+// openMMDB reads a MaxMind DB file
+func openMMDB(path string) (*mmdbReader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	marker := bytes.LastIndex(buf, mmdbMetadataMarker)
+	if marker < 0 {
+		return nil, fmt.Errorf("%s: not a MaxMind DB file", path)
+	}
+	meta, _, err := mmdbDecoder{buf: buf[marker+len(mmdbMetadataMarker):]}.decode(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid metadata: %w", path, err)
+	}
+	fields, _ := meta.(map[string]any)
+	r := &mmdbReader{
+		nodeCount:  mmdbUint(fields["node_count"]),
+		recordSize: mmdbUint(fields["record_size"]),
+		ipVersion:  mmdbUint(fields["ip_version"]),
+	}
+	r.databaseType, _ = fields["database_type"].(string)
+	if r.recordSize != 24 && r.recordSize != 28 && r.recordSize != 32 {
+		return nil, fmt.Errorf("%s: unsupported record size %d", path, r.recordSize)
+	}
+	if r.ipVersion != 4 && r.ipVersion != 6 {
+		return nil, fmt.Errorf("%s: unsupported IP version %d", path, r.ipVersion)
+	}
+
+	// The search tree is followed by 16 zero bytes, then the data section
+	treeSize := r.nodeCount * r.recordSize / 4
+	if treeSize+16 > uint(marker) {
+		return nil, fmt.Errorf("%s: search tree is larger than the file", path)
+	}
+	r.tree = buf[:treeSize]
+	r.data = mmdbDecoder{buf: buf[treeSize+16 : marker]}
+
+	if r.ipVersion == 6 {
+		for i := 0; i < 96 && r.ipv4Start < r.nodeCount; i++ {
+			r.ipv4Start = r.record(r.ipv4Start, 0)
+		}
+	}
+	return r, nil
+}
+
+// record returns the left (bit 0) or right (bit 1) record of a node
+func (r *mmdbReader) record(node, bit uint) uint {
+	b := r.tree
+	switch r.recordSize {
+	case 24:
+		off := node*6 + bit*3
+		return uint(b[off])<<16 | uint(b[off+1])<<8 | uint(b[off+2])
+	case 28:
+		// The middle byte holds the top four bits of both records
+		off := node * 7
+		if bit == 0 {
+			return uint(b[off+3]&0xf0)<<20 | uint(b[off])<<16 | uint(b[off+1])<<8 | uint(b[off+2])
+		}
+		return uint(b[off+3]&0x0f)<<24 | uint(b[off+4])<<16 | uint(b[off+5])<<8 | uint(b[off+6])
+	default:
+		off := node*8 + bit*4
+		return uint(binary.BigEndian.Uint32(b[off:]))
+	}
+}
+
+// lookup returns the record for an address, or nil when the database has none
+func (r *mmdbReader) lookup(ip net.IP) (any, error) {
+	bits, node := ip.To4(), r.ipv4Start
+	if bits == nil {
+		if r.ipVersion == 4 {
+			return nil, nil
+		}
+		bits, node = ip.To16(), 0
+	}
+	if bits == nil {
+		return nil, nil
+	}
+
+	for i := 0; i < len(bits)*8 && node < r.nodeCount; i++ {
+		node = r.record(node, uint(bits[i/8]>>(7-i%8))&1)
+	}
+	switch {
+	case node == r.nodeCount:
+		return nil, nil
+	case node < r.nodeCount:
+		return nil, fmt.Errorf("search tree deeper than the address")
+	}
+	value, _, err := r.data.decode(node-r.nodeCount-16, 0)
+	return value, err
+}
+
+// mmdbDecoder decodes the typed values of a MaxMind DB data section
+type mmdbDecoder struct {
+	buf []byte
+}
+
+// mmdbMaxDepth bounds nesting so a corrupt file can't recurse forever
+const mmdbMaxDepth = 32
+
+// decode returns the value at off and the offset just after it
+func (d mmdbDecoder) decode(off uint, depth int) (any, uint, error) {
+	if depth > mmdbMaxDepth {
+		return nil, 0, fmt.Errorf("data nested too deeply")
+	}
+	ctrl, err := d.bytes(off, 1)
+	if err != nil {
+		return nil, 0, err
+	}
+	off++
+	kind := uint(ctrl[0] >> 5)
+
+	if kind == 1 {
+		// Pointers are relative to the data section and resume after themselves
+		n := uint(ctrl[0]>>3&3) + 1
+		b, err := d.bytes(off, n)
+		if err != nil {
+			return nil, 0, err
+		}
+		v := uint(ctrl[0] & 7)
+		var target uint
+		switch n {
+		case 1:
+			target = v<<8 | uint(b[0])
+		case 2:
+			target = (v<<16 | uint(b[0])<<8 | uint(b[1])) + 2048
+		case 3:
+			target = (v<<24 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])) + 526336
+		default:
+			target = uint(binary.BigEndian.Uint32(b))
+		}
+		value, _, err := d.decode(target, depth+1)
+		return value, off + n, err
+	}
+
+	if kind == 0 {
+		ext, err := d.bytes(off, 1)
+		if err != nil {
+			return nil, 0, err
+		}
+		kind = 7 + uint(ext[0])
+		off++
+	}
+
+	size := uint(ctrl[0] & 0x1f)
+	if size >= 29 {
+		n := size - 28
+		b, err := d.bytes(off, n)
+		if err != nil {
+			return nil, 0, err
+		}
+		off += n
+		switch n {
+		case 1:
+			size = 29 + uint(b[0])
+		case 2:
+			size = 285 + (uint(b[0])<<8 | uint(b[1]))
+		default:
+			size = 65821 + (uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2]))
+		}
+	}
+
+	switch kind {
+	case 2: // UTF-8 string
+		b, err := d.bytes(off, size)
+		return string(b), off + size, err
+	case 3: // Double
+		b, err := d.bytes(off, 8)
+		if err != nil || size != 8 {
+			return nil, 0, fmt.Errorf("invalid double")
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), off + 8, nil
+	case 4: // Bytes
+		b, err := d.bytes(off, size)
+		return bytes.Clone(b), off + size, err
+	case 5, 6, 9: // uint16, uint32, uint64
+		b, err := d.bytes(off, size)
+		if err != nil || size > 8 {
+			return nil, 0, fmt.Errorf("invalid unsigned integer")
+		}
+		var v uint64
+		for _, c := range b {
+			v = v<<8 | uint64(c)
+		}
+		return v, off + size, nil
+	case 8: // int32
+		b, err := d.bytes(off, size)
+		if err != nil || size > 4 {
+			return nil, 0, fmt.Errorf("invalid integer")
+		}
+		var v uint32
+		for _, c := range b {
+			v = v<<8 | uint32(c)
+		}
+		return int64(int32(v)), off + size, nil
+	case 10: // uint128
+		b, err := d.bytes(off, size)
+		return new(big.Int).SetBytes(b), off + size, err
+	case 7: // Map
+		m := make(map[string]any, size)
+		for range size {
+			key, next, err := d.decode(off, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("map key is not a string")
+			}
+			value, next, err := d.decode(next, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[name] = value
+			off = next
+		}
+		return m, off, nil
+	case 11: // Array
+		a := make([]any, 0, min(size, 1024))
+		for range size {
+			value, next, err := d.decode(off, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			a = append(a, value)
+			off = next
+		}
+		return a, off, nil
+	case 14: // Boolean, held in the size
+		return size != 0, off, nil
+	case 15: // Float
+		b, err := d.bytes(off, 4)
+		if err != nil || size != 4 {
+			return nil, 0, fmt.Errorf("invalid float")
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), off + 4, nil
+	}
+	return nil, 0, fmt.Errorf("unsupported data type %d", kind)
+}
+
+// bytes returns n bytes at off, or an error when they run past the section
+func (d mmdbDecoder) bytes(off, n uint) ([]byte, error) {
+	if off > uint(len(d.buf)) || n > uint(len(d.buf))-off {
+		return nil, fmt.Errorf("data section truncated at offset %d", off)
+	}
+	return d.buf[off : off+n], nil
+}
+
+// mmdbUint converts a decoded unsigned integer
+func mmdbUint(v any) uint {
+	n, _ := v.(uint64)
+	return uint(n)
+}
@@ -0,0 +1,186 @@
+package nginx
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// plusResponses counts responses by status class
+type plusResponses struct {
+	R1xx int64 `json:"1xx"`
+	R2xx int64 `json:"2xx"`
+	R3xx int64 `json:"3xx"`
+	R4xx int64 `json:"4xx"`
+	R5xx int64 `json:"5xx"`
+}
+
+// counts returns the responses by status class
+func (r plusResponses) counts() ResponseCounts {
+	return ResponseCounts{r.R1xx, r.R2xx, r.R3xx, r.R4xx, r.R5xx}
+}
+
+type plusConnections struct {
+	Accepted int64 `json:"accepted"`
+	Dropped  int64 `json:"dropped"`
+	Active   int64 `json:"active"`
+	Idle     int64 `json:"idle"`
+}
+
+type plusRequests struct {
+	Total int64 `json:"total"`
+}
+
+type plusServerZone struct {
+	Processing int64         `json:"processing"`
+	Requests   int64         `json:"requests"`
+	Responses  plusResponses `json:"responses"`
+	Received   int64         `json:"received"`
+	Sent       int64         `json:"sent"`
+}
+
+type plusUpstream struct {
+	Peers []plusPeer `json:"peers"`
+}
+
+type plusPeer struct {
+	Server       string        `json:"server"`
+	State        string        `json:"state"`
+	Backup       bool          `json:"backup"`
+	Weight       int           `json:"weight"`
+	Active       int64         `json:"active"`
+	Requests     int64         `json:"requests"`
+	Responses    plusResponses `json:"responses"`
+	Fails        int64         `json:"fails"`
+	ResponseTime int64         `json:"response_time"` // Milliseconds
+	HealthChecks struct {
+		Checks int64 `json:"checks"`
+		Fails  int64 `json:"fails"`
+	} `json:"health_checks"`
+}
+
+// plusCacheResponses is one cache status of a cache zone
+type plusCacheResponses struct {
+	Responses int64 `json:"responses"`
+}
+
+type plusCache struct {
+	Size        int64              `json:"size"`
+	MaxSize     int64              `json:"max_size"`
+	Hit         plusCacheResponses `json:"hit"`
+	Stale       plusCacheResponses `json:"stale"`
+	Updating    plusCacheResponses `json:"updating"`
+	Revalidated plusCacheResponses `json:"revalidated"`
+	Miss        plusCacheResponses `json:"miss"`
+	Expired     plusCacheResponses `json:"expired"`
+	Bypass      plusCacheResponses `json:"bypass"`
+}
+
+// plusSource reads connections, server zones, upstreams and caches from the
+// NGINX Plus API
+type plusSource struct{ s *Service }
+
+func (plusSource) Name() string { return PlusAPISource }
+
+func (src plusSource) Collect(metrics *Metrics) error {
+	endpoint, err := src.s.findStatusEndpoint("api", src.s.metricsConfig.PlusAPIURL)
+	if err != nil {
+		return err
+	}
+	base := strings.TrimSuffix(endpoint.URL, "/")
+
+	// The API answers its base with the versions it speaks
+	var versions []int
+	endpoint.URL = base + "/"
+	if err := fetchStatusJSON(endpoint, &versions); err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		return fmt.Errorf("%s lists no API versions", base)
+	}
+	version := versions[0]
+	for _, v := range versions[1:] {
+		version = max(version, v)
+	}
+	get := func(path string, v any) error {
+		endpoint.URL = fmt.Sprintf("%s/%d/%s", base, version, path)
+		return fetchStatusJSON(endpoint, v)
+	}
+
+	var connections plusConnections
+	var requests plusRequests
+	var serverZones map[string]plusServerZone
+	var upstreamGroups map[string]plusUpstream
+	var cacheZones map[string]plusCache
+	for path, v := range map[string]any{
+		"connections":       &connections,
+		"http/requests":     &requests,
+		"http/server_zones": &serverZones,
+		"http/upstreams":    &upstreamGroups,
+		"http/caches":       &cacheZones,
+	} {
+		if err := get(path, v); err != nil {
+			return err
+		}
+	}
+
+	var zones []ServerZone
+	for name, zone := range serverZones {
+		zones = append(zones, ServerZone{
+			Name:       name,
+			Requests:   zone.Requests,
+			Processing: zone.Processing,
+			Responses:  zone.Responses.counts(),
+			Received:   zone.Received,
+			Sent:       zone.Sent,
+		})
+	}
+
+	var upstreams []Upstream
+	for name, group := range upstreamGroups {
+		upstream := Upstream{Name: name}
+		for _, peer := range group.Peers {
+			upstream.Peers = append(upstream.Peers, UpstreamPeer{
+				Server:       peer.Server,
+				State:        peer.State,
+				Backup:       peer.Backup,
+				Weight:       peer.Weight,
+				Active:       peer.Active,
+				Requests:     peer.Requests,
+				Responses:    peer.Responses.counts(),
+				Fails:        peer.Fails,
+				ResponseTime: time.Duration(peer.ResponseTime) * time.Millisecond,
+				HealthChecks: peer.HealthChecks.Checks,
+				HealthFails:  peer.HealthChecks.Fails,
+			})
+		}
+		upstreams = append(upstreams, upstream)
+	}
+
+	var caches []CacheZone
+	for name, cache := range cacheZones {
+		caches = append(caches, CacheZone{
+			Name: name,
+			Hit:  cache.Hit.Responses, Stale: cache.Stale.Responses,
+			Updating: cache.Updating.Responses, Revalidated: cache.Revalidated.Responses,
+			Miss: cache.Miss.Responses, Expired: cache.Expired.Responses, Bypass: cache.Bypass.Responses,
+			Size: cache.Size, MaxSize: cache.MaxSize,
+		})
+	}
+	sortTraffic(zones, upstreams, caches)
+
+	// The API counts idle keepalive connections apart from active ones and
+	// doesn't split the active ones into reading and writing
+	metrics.Connections = &StubStatus{
+		Active:   connections.Active + connections.Idle,
+		Waiting:  connections.Idle,
+		Accepts:  connections.Accepted,
+		Handled:  connections.Accepted - connections.Dropped,
+		Requests: requests.Total,
+		At:       time.Now(),
+	}
+	metrics.ActiveConns = int(connections.Active + connections.Idle)
+	metrics.TotalConns = connections.Accepted
+	metrics.Zones, metrics.Upstreams, metrics.Caches = zones, upstreams, caches
+	return nil
+}
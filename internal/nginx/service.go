@@ -1,13 +1,16 @@
 package nginx
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/aitmiloud/ngxtui/internal/config"
 	"github.com/aitmiloud/ngxtui/internal/model"
 	crossplane "github.com/nginxinc/nginx-go-crossplane"
 )
@@ -15,12 +18,16 @@ import (
 const (
 	sitesAvailableDir = "/etc/nginx/sites-available"
 	sitesEnabledDir   = "/etc/nginx/sites-enabled"
-	nginxConfPath     = "/etc/nginx/nginx.conf"
 )
 
+// nginxConfPath is the main configuration file; tests point it at a temporary one
+var nginxConfPath = "/etc/nginx/nginx.conf"
+
 // Service handles NGINX operations using crossplane for real config parsing
 type Service struct {
-	payload *crossplane.Payload
+	payload       *crossplane.Payload
+	logConfig     config.LogConfig
+	metricsConfig config.MetricsConfig
 }
 
 // New creates a new NGINX service
@@ -28,12 +35,46 @@ func New() *Service {
 	return &Service{}
 }
 
+// WithLogConfig sets how access logs are parsed and returns the service
+func (s *Service) WithLogConfig(cfg config.LogConfig) *Service {
+	s.logConfig = cfg
+	return s
+}
+
+// WithMetricsConfig sets where metrics are read from and returns the service
+func (s *Service) WithMetricsConfig(cfg config.MetricsConfig) *Service {
+	s.metricsConfig = cfg
+	return s
+}
+
 // parseConfig parses the NGINX configuration using crossplane
 func (s *Service) parseConfig() error {
-	payload, err := crossplane.Parse(nginxConfPath, &crossplane.ParseOptions{
+	options := &crossplane.ParseOptions{
 		SingleFile:         false,
 		StopParsingOnError: false,
-	})
+	}
+
+	// Read the configuration from inside the container for Docker NGINX
+	if IsDockerAvailable() {
+		if containerID, err := getCachedContainerID(); err == nil {
+			options.Open = func(path string) (io.ReadCloser, error) {
+				data, err := readNginxFile(path)
+				if err != nil {
+					return nil, err
+				}
+				return io.NopCloser(bytes.NewReader(data)), nil
+			}
+			options.Glob = func(pattern string) ([]string, error) {
+				output, err := exec.Command("docker", "exec", containerID, "sh", "-c", "ls -d "+pattern+" 2>/dev/null").Output()
+				if err != nil {
+					return nil, nil
+				}
+				return strings.Fields(string(output)), nil
+			}
+		}
+	}
+
+	payload, err := crossplane.Parse(nginxConfPath, options)
 	if err != nil {
 		return fmt.Errorf("failed to parse nginx config: %w", err)
 	}
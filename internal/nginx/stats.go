@@ -1,7 +1,6 @@
 package nginx
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"os/exec"
@@ -16,21 +15,22 @@ import (
 // Stats represents NGINX statistics
 type Stats struct {
 	ActiveConnections int
-	RequestRate       float64
-	TotalRequests     int64
 	Uptime            time.Duration
 	WorkerProcesses   int
+	Connections       *StubStatus // Connection states and counters; nil when only sockets were counted
+	Source            string      // Name of the MetricsSource the connections came from
 }
 
 // GetStats retrieves real NGINX statistics
 func (s *Service) GetStats() (*Stats, error) {
 	stats := &Stats{}
 
-	// Get active connections from nginx status
-	connections, err := s.getActiveConnections()
-	if err == nil {
-		stats.ActiveConnections = connections
-	}
+	// Get active connections from the richest source available
+	traffic := &Metrics{}
+	s.collectTraffic(traffic)
+	stats.Connections = traffic.Connections
+	stats.ActiveConnections = traffic.ActiveConns
+	stats.Source = traffic.Source
 
 	// Get worker processes
 	workers, err := s.getWorkerProcesses()
@@ -44,49 +44,9 @@ func (s *Service) GetStats() (*Stats, error) {
 		stats.Uptime = uptime
 	}
 
-	// Calculate request rate from access logs
-	rate, total, err := s.calculateRequestRate()
-	if err == nil {
-		stats.RequestRate = rate
-		stats.TotalRequests = total
-	}
-
 	return stats, nil
 }
 
-// getActiveConnections gets the number of active connections
-func (s *Service) getActiveConnections() (int, error) {
-	// Get all ports NGINX is listening on
-	ports, err := s.GetListeningPorts()
-	if err != nil || len(ports) == 0 {
-		// Fallback to port 80 if detection fails
-		ports = []string{"80"}
-	}
-
-	// Build grep pattern for all ports: ":80\|:443\|:8080"
-	var portPattern string
-	for i, port := range ports {
-		if i > 0 {
-			portPattern += "\\|"
-		}
-		portPattern += ":" + port
-	}
-
-	// Count connections on all detected ports
-	cmd := exec.Command("sh", "-c", fmt.Sprintf("ss -tn | grep -E '(%s)' | wc -l", portPattern))
-	output, err := cmd.Output()
-	if err != nil {
-		return 0, err
-	}
-
-	count, err := strconv.Atoi(strings.TrimSpace(string(output)))
-	if err != nil {
-		return 0, err
-	}
-
-	return count, nil
-}
-
 // getWorkerProcesses counts nginx worker processes
 func (s *Service) getWorkerProcesses() (int, error) {
 	cmd := exec.Command("sh", "-c", "ps aux | grep 'nginx: worker process' | grep -v grep | wc -l")
@@ -155,77 +115,46 @@ func parseElapsedTime(etime string) (time.Duration, error) {
 	return time.Duration(totalSeconds) * time.Second, nil
 }
 
-// calculateRequestRate calculates requests per second from access log
-func (s *Service) calculateRequestRate() (float64, int64, error) {
-	// Check if Docker NGINX (with caching)
-	if IsDockerAvailable() {
-		containerID, err := getCachedContainerID()
-		if err == nil {
-			return CalculateDockerRequestRate(containerID)
-		}
-	}
-
-	// Native NGINX
-	logPath := "/var/log/nginx/access.log"
+// GetConfigErrors validates nginx configuration and returns errors
+func (s *Service) GetConfigErrors() ([]string, error) {
+	cmd := exec.Command("nginx", "-t")
+	output, err := cmd.CombinedOutput()
 
-	file, err := os.Open(logPath)
 	if err != nil {
-		return 0, 0, err
-	}
-	defer file.Close()
-
-	var totalRequests int64
-	var recentRequests int
-	now := time.Now()
-	oneMinuteAgo := now.Add(-1 * time.Minute)
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		totalRequests++
-		line := scanner.Text()
-
-		// Try to parse timestamp from log line
-		// Typical format: 127.0.0.1 - - [05/Nov/2024:10:24:57 +0100] ...
-		if strings.Contains(line, "[") && strings.Contains(line, "]") {
-			start := strings.Index(line, "[") + 1
-			end := strings.Index(line, "]")
-			if start > 0 && end > start {
-				timestampStr := line[start:end]
-				// Parse nginx timestamp format
-				timestamp, err := time.Parse("02/Jan/2006:15:04:05 -0700", timestampStr)
-				if err == nil && timestamp.After(oneMinuteAgo) {
-					recentRequests++
-				}
-			}
-		}
+		return configErrorLines(string(output)), fmt.Errorf("configuration has errors")
 	}
 
-	// Calculate rate (requests per second over last minute)
-	rate := float64(recentRequests) / 60.0
-
-	return rate, totalRequests, scanner.Err()
+	return nil, nil
 }
 
-// GetConfigErrors validates nginx configuration and returns errors
-func (s *Service) GetConfigErrors() ([]string, error) {
-	cmd := exec.Command("nginx", "-t")
-	output, err := cmd.CombinedOutput()
-
-	outputStr := string(output)
+// configErrorLines picks the error messages out of nginx -t output
+func configErrorLines(output string) []string {
 	var errors []string
-
-	if err != nil {
-		// Parse error messages from output
-		lines := strings.Split(outputStr, "\n")
-		for _, line := range lines {
-			if strings.Contains(line, "error") || strings.Contains(line, "failed") {
-				errors = append(errors, strings.TrimSpace(line))
-			}
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		if strings.Contains(line, "error") || strings.Contains(line, "failed") || strings.Contains(line, "emerg") {
+			errors = append(errors, strings.TrimSpace(line))
 		}
-		return errors, fmt.Errorf("configuration has errors")
 	}
+	return errors
+}
 
-	return nil, nil
+// Health is a snapshot of the configuration test and host resources
+type Health struct {
+	ConfigErr    error    // Set when the configuration test failed
+	ConfigErrors []string // Error lines reported by the configuration test
+	System       *SystemMetrics
+}
+
+// GetHealth runs the configuration test and reads host metrics
+func (s *Service) GetHealth() *Health {
+	health := &Health{}
+	if err := s.TestConfig(); err != nil {
+		health.ConfigErr = err
+		health.ConfigErrors = configErrorLines(err.Error())
+	}
+	health.System, _ = s.GetSystemMetrics()
+	return health
 }
 
 // GetServerNames extracts all server names from configurations
@@ -0,0 +1,166 @@
+package nginx
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	crossplane "github.com/nginxinc/nginx-go-crossplane"
+)
+
+// StatusEndpoint is where a status page or API is served
+type StatusEndpoint struct {
+	URL  string
+	Host string // Host header selecting the server block; empty for the default one
+}
+
+// statusClient polls status pages. They are served locally, often on a
+// server with a self-signed certificate, so certificates aren't verified.
+var statusClient = &http.Client{
+	Timeout:   2 * time.Second,
+	Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+}
+
+// fetchStatus GETs a status page and hands its body to parse
+func fetchStatus[T any](endpoint StatusEndpoint, parse func(io.Reader) (T, error)) (T, error) {
+	var zero T
+	req, err := http.NewRequest(http.MethodGet, endpoint.URL, nil)
+	if err != nil {
+		return zero, err
+	}
+	if endpoint.Host != "" {
+		req.Host = endpoint.Host
+	}
+	resp, err := statusClient.Do(req)
+	if err != nil {
+		return zero, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusForbidden:
+		return zero, fmt.Errorf("%s answered %s (allow 127.0.0.1 in its location)", endpoint.URL, resp.Status)
+	default:
+		return zero, fmt.Errorf("%s answered %s", endpoint.URL, resp.Status)
+	}
+	value, err := parse(resp.Body)
+	if err != nil {
+		return zero, fmt.Errorf("%s: %w", endpoint.URL, err)
+	}
+	return value, nil
+}
+
+// fetchStatusJSON GETs a JSON status document into v
+func fetchStatusJSON(endpoint StatusEndpoint, v any) error {
+	_, err := fetchStatus(endpoint, func(r io.Reader) (struct{}, error) {
+		return struct{}{}, json.NewDecoder(r).Decode(v)
+	})
+	return err
+}
+
+// statusEndpoints keeps the status locations found in the configuration by
+// directive, so polling doesn't parse the configuration every time
+var statusEndpoints = struct {
+	mu    sync.Mutex
+	found map[string]cachedStatusEndpoint
+}{found: make(map[string]cachedStatusEndpoint)}
+
+// cachedStatusEndpoint is a status location found (or not) in the configuration
+type cachedStatusEndpoint struct {
+	endpoint  StatusEndpoint
+	err       error
+	lastCheck time.Time
+}
+
+// statusEndpointTTL is how long a found (or missing) location is trusted
+const statusEndpointTTL = time.Minute
+
+// findStatusEndpoint returns the configured URL, or the first location
+// holding the directive in the NGINX configuration
+func (s *Service) findStatusEndpoint(directive, configured string) (StatusEndpoint, error) {
+	if configured != "" {
+		return StatusEndpoint{URL: configured}, nil
+	}
+
+	statusEndpoints.mu.Lock()
+	defer statusEndpoints.mu.Unlock()
+	if cached, ok := statusEndpoints.found[directive]; ok && time.Since(cached.lastCheck) < statusEndpointTTL {
+		return cached.endpoint, cached.err
+	}
+	cached := cachedStatusEndpoint{lastCheck: time.Now()}
+	cached.endpoint, cached.err = s.locateStatusEndpoint(directive)
+	statusEndpoints.found[directive] = cached
+	return cached.endpoint, cached.err
+}
+
+// invalidateStatusEndpoints makes the next poll look for the locations again
+func invalidateStatusEndpoints() {
+	statusEndpoints.mu.Lock()
+	statusEndpoints.found = make(map[string]cachedStatusEndpoint)
+	statusEndpoints.mu.Unlock()
+}
+
+// locateStatusEndpoint looks for a location holding the directive in the server blocks
+func (s *Service) locateStatusEndpoint(directive string) (StatusEndpoint, error) {
+	blocks, err := s.ServerBlocks()
+	if err != nil {
+		return StatusEndpoint{}, err
+	}
+	for _, block := range blocks {
+		if path, ok := findStatusLocation(block.Directives, directive); ok {
+			return statusEndpoint(block, path), nil
+		}
+	}
+	return StatusEndpoint{}, fmt.Errorf("no %s location in the configuration", directive)
+}
+
+// findStatusLocation returns the path of the first location, nested ones
+// included, holding the directive. Regular expression and named locations
+// have no path to request.
+func findStatusLocation(directives crossplane.Directives, directive string) (string, bool) {
+	for _, d := range directives {
+		if d.Directive != "location" || len(d.Args) == 0 {
+			continue
+		}
+		path := d.Args[len(d.Args)-1]
+		plain := len(d.Args) == 1 || d.Args[0] == "=" || d.Args[0] == "^~"
+		if plain && !strings.HasPrefix(path, "@") {
+			for _, inner := range d.Block {
+				if inner.Directive == directive {
+					return path, true
+				}
+			}
+		}
+		if path, ok := findStatusLocation(d.Block, directive); ok {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// statusEndpoint builds the URL of a path on a server block, over its first
+// listener that isn't a Unix socket
+func statusEndpoint(block ServerBlock, path string) StatusEndpoint {
+	scheme, address := "http", dialAddress("80")
+	for _, d := range block.Directives {
+		if d.Directive != "listen" || len(d.Args) == 0 || strings.HasPrefix(d.Args[0], "unix:") {
+			continue
+		}
+		address = dialAddress(d.Args[0])
+		for _, arg := range d.Args[1:] {
+			if arg == "ssl" {
+				scheme = "https"
+			}
+		}
+		break
+	}
+	return StatusEndpoint{
+		URL:  scheme + "://" + address + path,
+		Host: probeServerName(block.ServerNames),
+	}
+}
@@ -0,0 +1,137 @@
+package nginx
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aitmiloud/ngxtui/internal/config"
+)
+
+// StubStatus is a reading of the stub_status page: connection states and
+// counters since NGINX started
+type StubStatus struct {
+	Active   int64 // Open client connections, Waiting included
+	Reading  int64 // Connections reading the request header
+	Writing  int64 // Connections writing the response
+	Waiting  int64 // Idle keepalive connections
+	Accepts  int64 // Connections accepted
+	Handled  int64 // Connections handled; fewer than Accepts when limits were hit
+	Requests int64 // Client requests
+	At       time.Time
+}
+
+// Dropped returns the connections accepted but not handled
+func (s *StubStatus) Dropped() int64 {
+	return s.Accepts - s.Handled
+}
+
+// RequestRate returns requests per second since an earlier reading. It is
+// false without one, or when the counters went back because NGINX restarted.
+func (s *StubStatus) RequestRate(prev *StubStatus) (float64, bool) {
+	if s == nil || prev == nil || !s.At.After(prev.At) || s.Requests < prev.Requests {
+		return 0, false
+	}
+	return float64(s.Requests-prev.Requests) / s.At.Sub(prev.At).Seconds(), true
+}
+
+// ParseStubStatus parses a stub_status page:
+//
+//	Active connections: 291
+//	server accepts handled requests
+//	 16630948 16630948 31070465
+//	Reading: 6 Writing: 179 Waiting: 106
+func ParseStubStatus(r io.Reader) (*StubStatus, error) {
+	status := &StubStatus{At: time.Now()}
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) < 4 || !strings.HasPrefix(lines[0], "Active connections:") {
+		return nil, fmt.Errorf("not a stub_status page")
+	}
+
+	var err error
+	if status.Active, err = strconv.ParseInt(strings.TrimSpace(strings.TrimPrefix(lines[0], "Active connections:")), 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid active connections: %w", err)
+	}
+	counters := strings.Fields(lines[2])
+	if len(counters) != 3 {
+		return nil, fmt.Errorf("invalid counters %q", lines[2])
+	}
+	for i, dst := range []*int64{&status.Accepts, &status.Handled, &status.Requests} {
+		if *dst, err = strconv.ParseInt(counters[i], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid counters %q", lines[2])
+		}
+	}
+	states := strings.Fields(lines[3])
+	if len(states) != 6 || states[0] != "Reading:" || states[2] != "Writing:" || states[4] != "Waiting:" {
+		return nil, fmt.Errorf("invalid connection states %q", lines[3])
+	}
+	for i, dst := range []*int64{&status.Reading, &status.Writing, &status.Waiting} {
+		if *dst, err = strconv.ParseInt(states[2*i+1], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid connection states %q", lines[3])
+		}
+	}
+	return status, nil
+}
+
+// FetchStubStatus reads a stub_status page
+func FetchStubStatus(endpoint StatusEndpoint) (*StubStatus, error) {
+	return fetchStatus(endpoint, ParseStubStatus)
+}
+
+// StubStatus polls the stub_status page at metrics.stub_status_url, or the
+// location found in the configuration
+func (s *Service) StubStatus() (*StubStatus, error) {
+	endpoint, err := s.findStatusEndpoint("stub_status", s.metricsConfig.StubStatusURL)
+	if err != nil {
+		return nil, err
+	}
+	return FetchStubStatus(endpoint)
+}
+
+// stubStatusPath is the location the managed stub_status server answers on
+const stubStatusPath = "/nginx_status"
+
+// EnableStubStatus sets up a server answering stub_status on the configured
+// local address through a managed include and returns its endpoint
+func (s *Service) EnableStubStatus(cfg config.MetricsConfig) (StatusEndpoint, error) {
+	if err := requireNativeNginx("Setting up stub_status"); err != nil {
+		return StatusEndpoint{}, err
+	}
+	if _, err := os.Stat(cfg.StubStatusInclude); err == nil {
+		return StatusEndpoint{}, fmt.Errorf("%s already exists; is it included in the http block?", cfg.StubStatusInclude)
+	}
+
+	content := fmt.Sprintf(`# Managed by ngxtui - stub_status for its connection metrics.
+server {
+    listen %s;
+    server_name ngxtui-status;
+    access_log off;
+
+    location = %s {
+        stub_status;
+        allow 127.0.0.1;
+        allow ::1;
+        deny all;
+    }
+}
+`, cfg.StubStatusListen, stubStatusPath)
+
+	if err := s.applyManagedInclude(cfg.StubStatusInclude, content); err != nil {
+		return StatusEndpoint{}, fmt.Errorf("stub_status was not set up: %w", err)
+	}
+	invalidateStatusEndpoints()
+	return StatusEndpoint{URL: "http://" + dialAddress(cfg.StubStatusListen) + stubStatusPath}, nil
+}
@@ -0,0 +1,56 @@
+package nginx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseStubStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    string
+		want    StubStatus
+		wantErr bool
+	}{
+		{
+			name: "page",
+			page: "Active connections: 291 \nserver accepts handled requests\n 16630948 16630946 31070465 \nReading: 6 Writing: 179 Waiting: 106 \n",
+			want: StubStatus{Active: 291, Reading: 6, Writing: 179, Waiting: 106, Accepts: 16630948, Handled: 16630946, Requests: 31070465},
+		},
+		{
+			name: "blank lines and no trailing newline",
+			page: "\nActive connections: 1\n\nserver accepts handled requests\n 3 3 7\nReading: 0 Writing: 1 Waiting: 0",
+			want: StubStatus{Active: 1, Writing: 1, Accepts: 3, Handled: 3, Requests: 7},
+		},
+		{name: "html page", page: "<html><body>Welcome to nginx!</body></html>", wantErr: true},
+		{name: "truncated", page: "Active connections: 1\nserver accepts handled requests\n 3 3 7\n", wantErr: true},
+		{name: "bad active count", page: "Active connections: many\nserver accepts handled requests\n 3 3 7\nReading: 0 Writing: 1 Waiting: 0\n", wantErr: true},
+		{name: "missing counter", page: "Active connections: 1\nserver accepts handled requests\n 3 3\nReading: 0 Writing: 1 Waiting: 0\n", wantErr: true},
+		{name: "bad states", page: "Active connections: 1\nserver accepts handled requests\n 3 3 7\nReading: 0 Writing: 1\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		status, err := ParseStubStatus(strings.NewReader(tt.page))
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: got %+v, want an error", tt.name, status)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		got := *status
+		got.At = tt.want.At
+		if got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStubStatusDropped(t *testing.T) {
+	status := StubStatus{Accepts: 100, Handled: 97}
+	if got := status.Dropped(); got != 3 {
+		t.Errorf("Dropped() = %d, want 3", got)
+	}
+}
@@ -0,0 +1,397 @@
+package nginx
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tailPollInterval is how often followed logs are checked for new lines
+const tailPollInterval = 500 * time.Millisecond
+
+// tailChunkSize is how much is read at a time from a log
+const tailChunkSize = 64 * 1024
+
+// tailPollLimit is the most read from a followed log in one poll; a longer
+// backlog is read over the following polls
+const tailPollLimit = 16 * tailChunkSize
+
+// LogBatch is a group of entries read from one access or error log
+type LogBatch struct {
+	Path    string
+	Kind    LogKind
+	Entries []LogEntry      // Set for access logs
+	Errors  []ErrorLogEntry // Set for error logs
+	Err     error           // Set when the log could not be opened or read
+}
+
+// LogTailer follows access and error logs in the background. Each log starts
+// with its last lines, then delivers appended lines as they are written,
+// surviving logrotate's copytruncate and rename-and-recreate modes.
+type LogTailer struct {
+	logs      []AccessLog
+	errorLogs []ErrorLog
+	window    int
+	batches   chan LogBatch
+	done      chan struct{}
+	stop      sync.Once
+	wg        sync.WaitGroup
+}
+
+// tailTarget is a followed file and how its lines become a batch
+type tailTarget struct {
+	path  string
+	kind  LogKind
+	batch func(lines []string) LogBatch
+}
+
+// NewLogTailer creates a tailer for the logs that starts from the last window lines
+func NewLogTailer(logs []AccessLog, errorLogs []ErrorLog, window int) *LogTailer {
+	return &LogTailer{
+		logs:      logs,
+		errorLogs: errorLogs,
+		window:    window,
+		batches:   make(chan LogBatch, 16),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start begins following every log
+func (t *LogTailer) Start() {
+	containerID := ""
+	if IsDockerAvailable() {
+		containerID, _ = getCachedContainerID()
+	}
+
+	var targets []tailTarget
+	for _, log := range t.logs {
+		targets = append(targets, tailTarget{path: log.Path, kind: AccessLogKind, batch: func(lines []string) LogBatch {
+			return LogBatch{Path: log.Path, Kind: AccessLogKind, Entries: parseAccessLogLines(lines, log)}
+		}})
+	}
+	for _, log := range t.errorLogs {
+		targets = append(targets, tailTarget{path: log.Path, kind: ErrorLogKind, batch: func(lines []string) LogBatch {
+			return LogBatch{Path: log.Path, Kind: ErrorLogKind, Errors: parseErrorLogLines(lines, log)}
+		}})
+	}
+
+	for _, target := range targets {
+		t.wg.Add(1)
+		go func(target tailTarget) {
+			defer t.wg.Done()
+			if containerID != "" {
+				t.followDocker(containerID, target)
+			} else {
+				t.followFile(target)
+			}
+		}(target)
+	}
+}
+
+// Paths returns every followed file, access logs first
+func (t *LogTailer) Paths() []string {
+	var paths []string
+	for _, log := range t.logs {
+		paths = append(paths, log.Path)
+	}
+	for _, log := range t.errorLogs {
+		paths = append(paths, log.Path)
+	}
+	return paths
+}
+
+// Batches delivers new entries; it is closed once the tailer has stopped
+func (t *LogTailer) Batches() <-chan LogBatch {
+	return t.batches
+}
+
+// Stop stops following the logs and waits for the followers to exit
+func (t *LogTailer) Stop() {
+	t.stop.Do(func() {
+		close(t.done)
+		t.wg.Wait()
+		close(t.batches)
+	})
+}
+
+// send delivers a batch unless the tailer is stopping
+func (t *LogTailer) send(batch LogBatch) bool {
+	select {
+	case t.batches <- batch:
+		return true
+	case <-t.done:
+		return false
+	}
+}
+
+// sendLines parses lines for the target and delivers them
+func (t *LogTailer) sendLines(target tailTarget, lines []string) bool {
+	if len(lines) == 0 {
+		return true
+	}
+	return t.send(target.batch(lines))
+}
+
+// followFile follows a local log file by polling
+func (t *LogTailer) followFile(target tailTarget) {
+	ticker := time.NewTicker(tailPollInterval)
+	defer ticker.Stop()
+
+	var f *logFile
+	reportedErr := false
+	for {
+		if f == nil {
+			opened, lines, err := openLogFile(target.path, target.kind, t.window)
+			if err != nil {
+				// Report once, then keep trying in case the file appears
+				if !reportedErr {
+					reportedErr = t.send(LogBatch{Path: target.path, Kind: target.kind, Err: err})
+				}
+			} else {
+				f = opened
+				reportedErr = false
+				if !t.sendLines(target, lines) {
+					f.close()
+					return
+				}
+			}
+		} else {
+			lines, err := f.poll()
+			if !t.sendLines(target, lines) {
+				f.close()
+				return
+			}
+			if err != nil {
+				f.close()
+				f = nil
+				t.send(LogBatch{Path: target.path, Kind: target.kind, Err: err})
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-t.done:
+			if f != nil {
+				f.close()
+			}
+			return
+		}
+	}
+}
+
+// logFile is an open log being followed
+type logFile struct {
+	path    string
+	file    *os.File
+	info    os.FileInfo
+	offset  int64
+	partial []byte // Trailing text not yet terminated by a newline
+}
+
+// openLogFile opens a log and returns its last window lines
+func openLogFile(path string, kind LogKind, window int) (*logFile, []string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s: %w", kind, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	lines, err := readLastLines(file, info.Size(), window)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	f := &logFile{path: path, file: file, info: info, offset: info.Size()}
+
+	// A line still being written is completed by the next poll
+	last := make([]byte, 1)
+	if info.Size() > 0 && len(lines) > 0 {
+		if _, err := file.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			f.partial = []byte(lines[len(lines)-1])
+			lines = lines[:len(lines)-1]
+		}
+	}
+	return f, lines, nil
+}
+
+// poll returns the lines appended since the last poll, following rotation
+func (f *logFile) poll() ([]string, error) {
+	current, err := f.file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	// copytruncate: the file we hold shrank, start again from the top
+	if current.Size() < f.offset {
+		f.offset = 0
+		f.partial = nil
+	}
+	lines, err := f.readFrom(current.Size())
+	if err != nil || f.offset < current.Size() {
+		return lines, err
+	}
+
+	// rename-and-recreate: the path now names a different file. Once what
+	// was left in the old one has been read, continue with the new one.
+	if pathInfo, err := os.Stat(f.path); err == nil && !os.SameFile(f.info, pathInfo) {
+		file, err := os.Open(f.path)
+		if err != nil {
+			return lines, nil
+		}
+		f.file.Close()
+		f.file = file
+		f.info = pathInfo
+		f.offset = 0
+		f.partial = nil
+		more, err := f.readFrom(pathInfo.Size())
+		return append(lines, more...), err
+	}
+	return lines, nil
+}
+
+// readFrom reads complete lines from the current offset up to size, a
+// chunk at a time and at most tailPollLimit per call
+func (f *logFile) readFrom(size int64) ([]string, error) {
+	if limit := f.offset + tailPollLimit; size > limit {
+		size = limit
+	}
+	var lines []string
+	chunk := make([]byte, tailChunkSize)
+	for f.offset < size {
+		n, err := f.file.ReadAt(chunk[:min(int64(len(chunk)), size-f.offset)], f.offset)
+		if err != nil && err != io.EOF {
+			return lines, err
+		}
+		if n == 0 {
+			break
+		}
+		f.offset += int64(n)
+
+		data := append(f.partial, chunk[:n]...)
+		end := bytes.LastIndexByte(data, '\n')
+		if end < 0 {
+			f.partial = data
+			continue
+		}
+		lines = append(lines, strings.Split(string(data[:end]), "\n")...)
+		f.partial = append([]byte(nil), data[end+1:]...)
+	}
+	return lines, nil
+}
+
+// close closes the underlying file
+func (f *logFile) close() {
+	f.file.Close()
+}
+
+// readLastLines reads the last n lines before offset end by scanning backwards,
+// so only the tail of a large log is read
+func readLastLines(r io.ReaderAt, end int64, n int) ([]string, error) {
+	var buf []byte
+	pos := end
+	for pos > 0 && bytes.Count(buf, []byte{'\n'}) <= n {
+		size := int64(tailChunkSize)
+		if pos < size {
+			size = pos
+		}
+		pos -= size
+		chunk := make([]byte, size)
+		if _, err := r.ReadAt(chunk, pos); err != nil && err != io.EOF {
+			return nil, err
+		}
+		buf = append(chunk, buf...)
+	}
+
+	text := strings.TrimRight(string(buf), "\n")
+	if text == "" {
+		return nil, nil
+	}
+	lines := strings.Split(text, "\n")
+	// The first line may be cut off unless the start of the file was reached
+	if pos > 0 && len(lines) > 0 {
+		lines = lines[1:]
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
+
+// followDocker follows a log inside the NGINX container. tail -F and
+// docker logs -f already cope with rotation, so lines are read from their
+// output and delivered once per poll interval.
+func (t *LogTailer) followDocker(containerID string, target tailTarget) {
+	var cmd *exec.Cmd
+	var output io.ReadCloser
+	var err error
+	switch target.path {
+	case DefaultAccessLog:
+		// The official image links the default access log to stdout
+		cmd = exec.Command("docker", "logs", "-f", "--tail", fmt.Sprintf("%d", t.window), containerID)
+		output, err = cmd.StdoutPipe()
+	case DefaultErrorLog:
+		// ...and the default error log to stderr
+		cmd = exec.Command("docker", "logs", "-f", "--tail", fmt.Sprintf("%d", t.window), containerID)
+		output, err = cmd.StderrPipe()
+	default:
+		cmd = exec.Command("docker", "exec", containerID, "tail", "-n", fmt.Sprintf("%d", t.window), "-F", target.path)
+		output, err = cmd.StdoutPipe()
+	}
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		t.send(LogBatch{Path: target.path, Kind: target.kind, Err: fmt.Errorf("failed to follow log: %w", err)})
+		return
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	lines := make(chan string, 256)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(output)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-t.done:
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(tailPollInterval)
+	defer ticker.Stop()
+
+	var pending []string
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.sendLines(target, pending)
+				return
+			}
+			pending = append(pending, line)
+		case <-ticker.C:
+			if !t.sendLines(target, pending) {
+				return
+			}
+			pending = nil
+		case <-t.done:
+			return
+		}
+	}
+}
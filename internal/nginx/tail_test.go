@@ -0,0 +1,165 @@
+package nginx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// appendLog appends text to the file at path
+func appendLog(t *testing.T, path, text string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// pollLines polls f and fails the test on an error
+func pollLines(t *testing.T, f *logFile) []string {
+	t.Helper()
+	lines, err := f.poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lines
+}
+
+func TestLogFileFollow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	appendLog(t, path, "one\ntwo\nthree\nfour")
+
+	f, lines, err := openLogFile(path, AccessLogKind, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.close()
+	// The unterminated line is held back until it is complete
+	if want := []string{"two", "three"}; !slices.Equal(lines, want) {
+		t.Errorf("window = %q, want %q", lines, want)
+	}
+	if got := pollLines(t, f); got != nil {
+		t.Errorf("nothing appended, got %q", got)
+	}
+
+	appendLog(t, path, " and more\nfive\nsix")
+	if got, want := pollLines(t, f), []string{"four and more", "five"}; !slices.Equal(got, want) {
+		t.Errorf("appended = %q, want %q", got, want)
+	}
+	appendLog(t, path, "\n")
+	if got, want := pollLines(t, f), []string{"six"}; !slices.Equal(got, want) {
+		t.Errorf("completed = %q, want %q", got, want)
+	}
+}
+
+func TestLogFileCopyTruncate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	appendLog(t, path, "old one\nold two\n")
+
+	f, _, err := openLogFile(path, AccessLogKind, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.close()
+
+	// logrotate copied the file away and truncated it in place; NGINX
+	// carries on writing to the same file from the top
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	appendLog(t, path, "new\n")
+	if got, want := pollLines(t, f), []string{"new"}; !slices.Equal(got, want) {
+		t.Errorf("after truncation = %q, want %q", got, want)
+	}
+	appendLog(t, path, "newer\n")
+	if got, want := pollLines(t, f), []string{"newer"}; !slices.Equal(got, want) {
+		t.Errorf("after truncation = %q, want %q", got, want)
+	}
+}
+
+func TestLogFileRenameAndRecreate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	appendLog(t, path, "before\n")
+
+	f, _, err := openLogFile(path, AccessLogKind, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.close()
+
+	// Lines written to the old file before NGINX reopens its logs are read
+	// along with the start of the new one
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendLog(t, path+".1", "late\n")
+	appendLog(t, path, "fresh\n")
+	if got, want := pollLines(t, f), []string{"late", "fresh"}; !slices.Equal(got, want) {
+		t.Errorf("after rotation = %q, want %q", got, want)
+	}
+
+	appendLog(t, path+".1", "lost\n")
+	appendLog(t, path, "fresher\n")
+	if got, want := pollLines(t, f), []string{"fresher"}; !slices.Equal(got, want) {
+		t.Errorf("following the new file = %q, want %q", got, want)
+	}
+}
+
+func TestLogFilePollLimit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	appendLog(t, path, "")
+
+	f, _, err := openLogFile(path, AccessLogKind, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.close()
+
+	// A backlog bigger than a poll reads is spread over several polls, and
+	// lines that straddle a chunk come out whole
+	line := strings.Repeat("x", 999) + "\n"
+	count := 3 * tailPollLimit / len(line)
+	appendLog(t, path, strings.Repeat(line, count))
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendLog(t, path, "fresh\n")
+
+	var read []string
+	polls := 0
+	for len(read) < count+1 && polls < 10 {
+		read = append(read, pollLines(t, f)...)
+		polls++
+	}
+	if polls < 3 {
+		t.Errorf("backlog read in %d polls, want it bounded", polls)
+	}
+	if len(read) != count+1 || read[count] != "fresh" {
+		t.Fatalf("read %d lines, want %d and then the new file", len(read), count+1)
+	}
+	for i, got := range read[:count] {
+		if got != line[:len(line)-1] {
+			t.Fatalf("line %d has %d bytes, want %d", i, len(got), len(line)-1)
+		}
+	}
+}
+
+func TestOpenLogFileError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.log")
+	for _, kind := range []LogKind{AccessLogKind, ErrorLogKind} {
+		_, _, err := openLogFile(path, kind, 10)
+		want := fmt.Sprintf("failed to open %s: open %s", kind, path)
+		if err == nil || !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("%s: error %v, want it to start %q", kind, err, want)
+		}
+	}
+}
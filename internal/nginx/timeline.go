@@ -0,0 +1,150 @@
+package nginx
+
+import "time"
+
+// timelineSteps are the bucket lengths a timeline can use, each a multiple
+// of the one before so buckets merge exactly
+var timelineSteps = []time.Duration{
+	time.Second, 5 * time.Second, 15 * time.Second,
+	time.Minute, 5 * time.Minute, 15 * time.Minute,
+	time.Hour, 6 * time.Hour, 24 * time.Hour,
+}
+
+// timelineBarSteps are the bucket lengths a timeline can be shown with; only
+// whole multiples of its own step are used
+var timelineBarSteps = []time.Duration{
+	time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second, 15 * time.Second, 30 * time.Second,
+	time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+// timelineMaxBuckets bounds a timeline's memory; past it the buckets are
+// merged into longer ones
+const timelineMaxBuckets = 4096
+
+// timelineClasses are the status classes a timeline stacks, bottom first
+var timelineClasses = []string{"2xx", "3xx", "4xx", "5xx"}
+
+// StatusTimeline counts requests per time bucket and status class. Buckets
+// start at one second and grow as the covered time does.
+type StatusTimeline struct {
+	step    int               // Index into timelineSteps
+	buckets map[int64]*[4]int // Counts by class, by bucket number since the epoch
+}
+
+// NewStatusTimeline creates an empty timeline
+func NewStatusTimeline() *StatusTimeline {
+	return &StatusTimeline{buckets: make(map[int64]*[4]int)}
+}
+
+// Step returns the length of the timeline's buckets
+func (t *StatusTimeline) Step() time.Duration {
+	return timelineSteps[t.step]
+}
+
+// Add counts a request of a status class; other classes aren't stacked
+func (t *StatusTimeline) Add(at time.Time, statusClass string) {
+	class := -1
+	for i, c := range timelineClasses {
+		if c == statusClass {
+			class = i
+		}
+	}
+	if class < 0 || at.IsZero() {
+		return
+	}
+
+	n := at.Unix() / int64(t.Step()/time.Second)
+	counts := t.buckets[n]
+	if counts == nil {
+		counts = new([4]int)
+		t.buckets[n] = counts
+	}
+	counts[class]++
+
+	for len(t.buckets) > timelineMaxBuckets && t.step < len(timelineSteps)-1 {
+		t.coarsen()
+	}
+}
+
+// coarsen merges the buckets into ones of the next step
+func (t *StatusTimeline) coarsen() {
+	factor := int64(timelineSteps[t.step+1] / timelineSteps[t.step])
+	merged := make(map[int64]*[4]int)
+	for n, counts := range t.buckets {
+		into := merged[floorDiv(n, factor)]
+		if into == nil {
+			into = new([4]int)
+			merged[floorDiv(n, factor)] = into
+		}
+		for i := range counts {
+			into[i] += counts[i]
+		}
+	}
+	t.step++
+	t.buckets = merged
+}
+
+// TimelineBucket is one bar of a timeline: requests from Start up to End by
+// status class, 2xx to 5xx
+type TimelineBucket struct {
+	Start, End time.Time
+	Counts     [4]int
+}
+
+// Total returns the requests of every class in the bucket
+func (b TimelineBucket) Total() int {
+	return b.Counts[0] + b.Counts[1] + b.Counts[2] + b.Counts[3]
+}
+
+// Buckets splits the time from since to until into at most n buckets of a
+// whole step no shorter than the timeline's own, ending at until
+func (t *StatusTimeline) Buckets(since, until time.Time, n int) []TimelineBucket {
+	if t == nil || n <= 0 || !since.Before(until) {
+		return nil
+	}
+
+	// The shortest step that fits the span into n buckets
+	step := t.Step()
+	for _, s := range timelineBarSteps {
+		if s%t.Step() != 0 {
+			continue
+		}
+		step = s
+		if until.Sub(since) <= time.Duration(n)*s {
+			break
+		}
+	}
+
+	secs := int64(step / time.Second)
+	last := floorDiv(until.Add(-time.Nanosecond).Unix(), secs)
+	first := max(floorDiv(since.Unix(), secs), last-int64(n)+1)
+	buckets := make([]TimelineBucket, 0, last-first+1)
+	for i := first; i <= last; i++ {
+		buckets = append(buckets, TimelineBucket{
+			Start: time.Unix(i*secs, 0),
+			End:   time.Unix((i+1)*secs, 0),
+		})
+	}
+
+	own := int64(t.Step() / time.Second)
+	for k, counts := range t.buckets {
+		i := floorDiv(k*own, secs) - first
+		if i < 0 || i >= int64(len(buckets)) {
+			continue
+		}
+		for c := range counts {
+			buckets[i].Counts[c] += counts[c]
+		}
+	}
+	return buckets
+}
+
+// floorDiv divides rounding towards negative infinity
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
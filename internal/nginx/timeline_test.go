@@ -0,0 +1,149 @@
+package nginx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatusTimelineBuckets(t *testing.T) {
+	// An hour boundary, so every bar step divides it
+	base := time.Unix(1760097600, 0)
+	tl := NewStatusTimeline()
+	for _, req := range []struct {
+		sec   int
+		class string
+	}{
+		{-1, "2xx"}, // Before the range
+		{0, "2xx"},
+		{1, "2xx"},
+		{9, "5xx"},
+		{10, "4xx"},
+		{59, "3xx"},
+		{60, "2xx"}, // At its end, which is open
+		{30, "1xx"}, // Not stacked
+	} {
+		tl.Add(base.Add(time.Duration(req.sec)*time.Second), req.class)
+	}
+	tl.Add(time.Time{}, "2xx")
+
+	tests := []struct {
+		name         string
+		since, until time.Time
+		n            int
+		step         time.Duration
+		count        int
+		counts       map[int][4]int // By bucket index; the rest are empty
+	}{
+		{
+			name: "one second each", since: base, until: base.Add(time.Minute), n: 60,
+			step: time.Second, count: 60,
+			counts: map[int][4]int{0: {1, 0, 0, 0}, 1: {1, 0, 0, 0}, 9: {0, 0, 0, 1}, 10: {0, 0, 1, 0}, 59: {0, 1, 0, 0}},
+		},
+		{
+			name: "ten seconds each", since: base, until: base.Add(time.Minute), n: 6,
+			step: 10 * time.Second, count: 6,
+			counts: map[int][4]int{0: {2, 0, 0, 1}, 1: {0, 0, 1, 0}, 5: {0, 1, 0, 0}},
+		},
+		{
+			name: "fewer bars than asked", since: base, until: base.Add(time.Minute), n: 4,
+			step: 15 * time.Second, count: 4,
+			counts: map[int][4]int{0: {2, 0, 1, 1}, 3: {0, 1, 0, 0}},
+		},
+		{
+			// The first bar starts on a whole step before since
+			name: "unaligned since", since: base.Add(5 * time.Second), until: base.Add(time.Minute), n: 6,
+			step: 10 * time.Second, count: 6,
+			counts: map[int][4]int{0: {2, 0, 0, 1}, 1: {0, 0, 1, 0}, 5: {0, 1, 0, 0}},
+		},
+		{
+			// Past the longest step the bars end at until and the oldest are dropped
+			name: "longer than the steps", since: base.Add(-30 * 24 * time.Hour), until: base.Add(time.Hour), n: 10,
+			step: 24 * time.Hour, count: 10,
+			counts: map[int][4]int{9: {4, 1, 1, 1}},
+		},
+	}
+	for _, tt := range tests {
+		buckets := tl.Buckets(tt.since, tt.until, tt.n)
+		if len(buckets) != tt.count {
+			t.Errorf("%s: got %d buckets, want %d", tt.name, len(buckets), tt.count)
+			continue
+		}
+		for i, b := range buckets {
+			if b.End.Sub(b.Start) != tt.step || b.Start.Unix()%int64(tt.step/time.Second) != 0 {
+				t.Errorf("%s: bucket %d spans %v to %v, want a whole %v", tt.name, i, b.Start, b.End, tt.step)
+			}
+			if i > 0 && !b.Start.Equal(buckets[i-1].End) {
+				t.Errorf("%s: bucket %d doesn't follow the one before", tt.name, i)
+			}
+			if b.Counts != tt.counts[i] {
+				t.Errorf("%s: bucket %d = %v, want %v", tt.name, i, b.Counts, tt.counts[i])
+			}
+		}
+		if last := buckets[len(buckets)-1]; last.End.Before(tt.until) || !last.Start.Before(tt.until) {
+			t.Errorf("%s: last bucket %v to %v doesn't end the range at %v", tt.name, last.Start, last.End, tt.until)
+		}
+	}
+
+	var none *StatusTimeline
+	if none.Buckets(base, base.Add(time.Minute), 10) != nil ||
+		tl.Buckets(base, base.Add(time.Minute), 0) != nil ||
+		tl.Buckets(base, base, 10) != nil {
+		t.Error("empty range or no bars gave buckets")
+	}
+}
+
+func TestStatusTimelineCoarsen(t *testing.T) {
+	base := time.Unix(1760097600, 0)
+	tl := NewStatusTimeline()
+	for i := range timelineMaxBuckets {
+		tl.Add(base.Add(time.Duration(i)*time.Second), "2xx")
+	}
+	if tl.Step() != time.Second {
+		t.Fatalf("coarsened at %d buckets, step %v", timelineMaxBuckets, tl.Step())
+	}
+
+	// One bucket more merges them into the next step
+	tl.Add(base.Add(timelineMaxBuckets*time.Second), "5xx")
+	if tl.Step() != 5*time.Second {
+		t.Fatalf("step = %v, want 5s", tl.Step())
+	}
+	if len(tl.buckets) > timelineMaxBuckets {
+		t.Errorf("%d buckets kept", len(tl.buckets))
+	}
+
+	var total [4]int
+	for _, b := range tl.Buckets(base, base.Add(2*time.Hour), 1000) {
+		for c := range b.Counts {
+			total[c] += b.Counts[c]
+		}
+	}
+	if total != [4]int{timelineMaxBuckets, 0, 0, 1} {
+		t.Errorf("merged counts = %v, want every request kept", total)
+	}
+
+	// Bars can't be shorter than the timeline's step
+	buckets := tl.Buckets(base, base.Add(time.Minute), 60)
+	if len(buckets) != 12 || buckets[0].Counts[0] != 5 {
+		t.Errorf("got %d buckets, first %v; want 12 of 5s", len(buckets), buckets[0].Counts)
+	}
+}
+
+func TestFloorDiv(t *testing.T) {
+	tests := []struct {
+		a, b, want int64
+	}{
+		{0, 5, 0},
+		{4, 5, 0},
+		{5, 5, 1},
+		{6, 5, 1},
+		{-1, 5, -1},
+		{-5, 5, -1},
+		{-6, 5, -2},
+		{6, -5, -2},
+	}
+	for _, tt := range tests {
+		if got := floorDiv(tt.a, tt.b); got != tt.want {
+			t.Errorf("floorDiv(%d, %d) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,649 @@
+package nginx
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLSProfile is one of the Mozilla server side TLS recommendations
+// (https://wiki.mozilla.org/Security/Server_Side_TLS)
+type TLSProfile struct {
+	Name         string
+	Protocols    []string // ssl_protocols names
+	Ciphers      []string // OpenSSL names of the TLSv1.2 and older cipher suites
+	CertTypes    []string // Accepted certificate key types; "RSA" means RSA of at least MinRSABits
+	MinRSABits   int
+	HSTSMaxAge   int
+	OCSPStapling bool
+	// SessionTickets is whether session tickets may stay enabled; without
+	// ticket key rotation they undermine forward secrecy
+	SessionTickets bool
+}
+
+// MozillaModern is for services whose clients all support TLS 1.3
+var MozillaModern = TLSProfile{
+	Name:         "modern",
+	Protocols:    []string{"TLSv1.3"},
+	CertTypes:    []string{"ECDSA P-256"},
+	HSTSMaxAge:   63072000,
+	OCSPStapling: true,
+}
+
+// MozillaIntermediate is the general purpose recommendation
+var MozillaIntermediate = TLSProfile{
+	Name:      "intermediate",
+	Protocols: []string{"TLSv1.2", "TLSv1.3"},
+	Ciphers: []string{
+		"ECDHE-ECDSA-AES128-GCM-SHA256", "ECDHE-RSA-AES128-GCM-SHA256",
+		"ECDHE-ECDSA-AES256-GCM-SHA384", "ECDHE-RSA-AES256-GCM-SHA384",
+		"ECDHE-ECDSA-CHACHA20-POLY1305", "ECDHE-RSA-CHACHA20-POLY1305",
+		"DHE-RSA-AES128-GCM-SHA256", "DHE-RSA-AES256-GCM-SHA384",
+		"DHE-RSA-CHACHA20-POLY1305",
+	},
+	CertTypes:    []string{"ECDSA P-256", "RSA"},
+	MinRSABits:   2048,
+	HSTSMaxAge:   63072000,
+	OCSPStapling: true,
+}
+
+// MozillaOld is for services that must support very old clients
+var MozillaOld = TLSProfile{
+	Name:      "old",
+	Protocols: []string{"TLSv1", "TLSv1.1", "TLSv1.2", "TLSv1.3"},
+	Ciphers: []string{
+		"ECDHE-ECDSA-AES128-GCM-SHA256", "ECDHE-RSA-AES128-GCM-SHA256",
+		"ECDHE-ECDSA-AES256-GCM-SHA384", "ECDHE-RSA-AES256-GCM-SHA384",
+		"ECDHE-ECDSA-CHACHA20-POLY1305", "ECDHE-RSA-CHACHA20-POLY1305",
+		"DHE-RSA-AES128-GCM-SHA256", "DHE-RSA-AES256-GCM-SHA384",
+		"DHE-RSA-CHACHA20-POLY1305",
+		"ECDHE-ECDSA-AES128-SHA256", "ECDHE-RSA-AES128-SHA256",
+		"ECDHE-ECDSA-AES128-SHA", "ECDHE-RSA-AES128-SHA",
+		"ECDHE-ECDSA-AES256-SHA384", "ECDHE-RSA-AES256-SHA384",
+		"ECDHE-ECDSA-AES256-SHA", "ECDHE-RSA-AES256-SHA",
+		"DHE-RSA-AES128-SHA256", "DHE-RSA-AES256-SHA256",
+		"AES128-GCM-SHA256", "AES256-GCM-SHA384",
+		"AES128-SHA256", "AES256-SHA256",
+		"AES128-SHA", "AES256-SHA",
+		"DES-CBC3-SHA",
+	},
+	CertTypes:    []string{"RSA"},
+	MinRSABits:   2048,
+	HSTSMaxAge:   63072000,
+	OCSPStapling: true,
+}
+
+// TLSProfiles lists the profiles from strictest to most permissive
+var TLSProfiles = []TLSProfile{MozillaModern, MozillaIntermediate, MozillaOld}
+
+// CipherString returns the profile's ciphers in ssl_ciphers form
+func (p TLSProfile) CipherString() string {
+	return strings.Join(p.Ciphers, ":")
+}
+
+// TLSCheckStatus is the outcome of a single profile check
+type TLSCheckStatus int
+
+const (
+	TLSCheckPass TLSCheckStatus = iota
+	TLSCheckFail
+	TLSCheckUnknown
+)
+
+// TLSCheck is one aspect of a server block scored against a profile
+type TLSCheck struct {
+	Name   string
+	Status TLSCheckStatus
+	Detail string
+}
+
+// TLSProfileScore is a server block scored against one profile
+type TLSProfileScore struct {
+	Profile string
+	Checks  []TLSCheck
+	Passed  bool // True when no check failed
+}
+
+// TLSReport describes the TLS setup of an SSL server block
+type TLSReport struct {
+	Site        string
+	File        string
+	ServerNames []string
+	Address     string // Local address the handshake was made against
+	SNI         string
+
+	// From the parsed directives
+	ConfiguredProtocols []string
+	ConfiguredCiphers   string
+	StaplingEnabled     bool
+	SessionTickets      bool
+	KeyTypes            []string // Key types of the configured certificates
+
+	// From the handshake
+	HandshakeErr     error
+	Protocols        []string // Protocol versions the server accepted
+	Ciphers          []string // OpenSSL names of accepted TLSv1.2 and older suites
+	NegotiatedCipher string   // Suite picked when the client offers everything
+	OCSPStapled      bool
+	HSTS             string // Strict-Transport-Security header, from the response or add_header
+
+	Scores []TLSProfileScore
+	Grade  string // Strictest profile fully met, or "none"
+}
+
+// tlsProbeTimeout bounds each connection made while grading
+const tlsProbeTimeout = 3 * time.Second
+
+// defaultSSLProtocols is what NGINX enables when ssl_protocols is not set
+// (versions before 1.27.3 also enable TLSv1 and TLSv1.1)
+var defaultSSLProtocols = []string{"TLSv1", "TLSv1.1", "TLSv1.2", "TLSv1.3"}
+
+// defaultSSLCiphers is the NGINX ssl_ciphers default
+const defaultSSLCiphers = "HIGH:!aNULL:!MD5"
+
+// tlsVersions maps ssl_protocols names to crypto/tls versions, oldest first
+var tlsVersions = []struct {
+	name    string
+	version uint16
+}{
+	{"TLSv1", tls.VersionTLS10},
+	{"TLSv1.1", tls.VersionTLS11},
+	{"TLSv1.2", tls.VersionTLS12},
+	{"TLSv1.3", tls.VersionTLS13},
+}
+
+// openSSLCipherNames maps the suites crypto/tls can offer to their OpenSSL names
+var openSSLCipherNames = map[uint16]string{
+	tls.TLS_RSA_WITH_RC4_128_SHA:                      "RC4-SHA",
+	tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA:                 "DES-CBC3-SHA",
+	tls.TLS_RSA_WITH_AES_128_CBC_SHA:                  "AES128-SHA",
+	tls.TLS_RSA_WITH_AES_256_CBC_SHA:                  "AES256-SHA",
+	tls.TLS_RSA_WITH_AES_128_CBC_SHA256:               "AES128-SHA256",
+	tls.TLS_RSA_WITH_AES_128_GCM_SHA256:               "AES128-GCM-SHA256",
+	tls.TLS_RSA_WITH_AES_256_GCM_SHA384:               "AES256-GCM-SHA384",
+	tls.TLS_ECDHE_ECDSA_WITH_RC4_128_SHA:              "ECDHE-ECDSA-RC4-SHA",
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA:          "ECDHE-ECDSA-AES128-SHA",
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA:          "ECDHE-ECDSA-AES256-SHA",
+	tls.TLS_ECDHE_RSA_WITH_RC4_128_SHA:                "ECDHE-RSA-RC4-SHA",
+	tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA:           "ECDHE-RSA-DES-CBC3-SHA",
+	tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA:            "ECDHE-RSA-AES128-SHA",
+	tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA:            "ECDHE-RSA-AES256-SHA",
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256:       "ECDHE-ECDSA-AES128-SHA256",
+	tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256:         "ECDHE-RSA-AES128-SHA256",
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256:         "ECDHE-RSA-AES128-GCM-SHA256",
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256:       "ECDHE-ECDSA-AES128-GCM-SHA256",
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384:         "ECDHE-RSA-AES256-GCM-SHA384",
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384:       "ECDHE-ECDSA-AES256-GCM-SHA384",
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256:   "ECDHE-RSA-CHACHA20-POLY1305",
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256: "ECDHE-ECDSA-CHACHA20-POLY1305",
+	tls.TLS_AES_128_GCM_SHA256:                        "TLS_AES_128_GCM_SHA256",
+	tls.TLS_AES_256_GCM_SHA384:                        "TLS_AES_256_GCM_SHA384",
+	tls.TLS_CHACHA20_POLY1305_SHA256:                  "TLS_CHACHA20_POLY1305_SHA256",
+}
+
+var hstsMaxAgeRe = regexp.MustCompile(`(?i)max-age\s*=\s*"?(\d+)`)
+
+// GetTLSReports grades every server block that listens with ssl
+func (s *Service) GetTLSReports() ([]TLSReport, error) {
+	blocks, err := s.ServerBlocks()
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []TLSReport
+	for _, block := range blocks {
+		address, ok := sslListenAddress(block)
+		if !ok {
+			continue
+		}
+		report := TLSReport{
+			Site:        block.Site,
+			File:        block.File,
+			ServerNames: block.ServerNames,
+			Address:     address,
+			SNI:         probeServerName(block.ServerNames),
+		}
+		readTLSDirectives(&report, block)
+		probeTLS(&report)
+		scoreTLS(&report)
+		reports = append(reports, report)
+	}
+
+	sort.SliceStable(reports, func(i, j int) bool {
+		return reports[i].Site < reports[j].Site
+	})
+	return reports, nil
+}
+
+// sslListenAddress returns the local address of the block's first ssl listener
+func sslListenAddress(block ServerBlock) (string, bool) {
+	for _, d := range block.Directives {
+		if d.Directive != "listen" || len(d.Args) == 0 {
+			continue
+		}
+		isSSL := false
+		for _, arg := range d.Args[1:] {
+			if arg == "ssl" {
+				isSSL = true
+			}
+		}
+		if !isSSL || strings.HasPrefix(d.Args[0], "unix:") {
+			continue
+		}
+		return dialAddress(d.Args[0]), true
+	}
+	return "", false
+}
+
+// dialAddress turns a listen address into one that can be dialled locally
+func dialAddress(listen string) string {
+	if _, err := strconv.Atoi(listen); err == nil {
+		return net.JoinHostPort("127.0.0.1", listen)
+	}
+
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		// An address without a port listens on 80
+		host, port = strings.Trim(listen, "[]"), "80"
+	}
+	switch host {
+	case "", "*", "0.0.0.0":
+		host = "127.0.0.1"
+	case "::":
+		host = "::1"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// probeServerName picks a server_name to send as SNI
+func probeServerName(names []string) string {
+	for _, name := range names {
+		switch {
+		case name == "" || name == "_" || strings.HasPrefix(name, "~") || strings.Contains(name, "$"):
+			continue
+		case strings.HasPrefix(name, "*."):
+			return "www" + name[1:]
+		case strings.HasPrefix(name, "."):
+			return name[1:]
+		case strings.HasSuffix(name, ".*"):
+			continue
+		default:
+			return name
+		}
+	}
+	return ""
+}
+
+// readTLSDirectives fills in the report from the block's configuration
+func readTLSDirectives(report *TLSReport, block ServerBlock) {
+	report.ConfiguredProtocols = block.Lookup("ssl_protocols")
+	if report.ConfiguredProtocols == nil {
+		report.ConfiguredProtocols = defaultSSLProtocols
+	}
+
+	report.ConfiguredCiphers = defaultSSLCiphers
+	if args := block.Lookup("ssl_ciphers"); len(args) > 0 {
+		report.ConfiguredCiphers = args[0]
+	}
+
+	if args := block.Lookup("ssl_stapling"); len(args) > 0 {
+		report.StaplingEnabled = args[0] == "on"
+	}
+
+	report.SessionTickets = true
+	if args := block.Lookup("ssl_session_tickets"); len(args) > 0 {
+		report.SessionTickets = args[0] == "on"
+	}
+
+	for _, d := range block.LookupAll("add_header") {
+		if len(d.Args) >= 2 && strings.EqualFold(d.Args[0], "Strict-Transport-Security") {
+			report.HSTS = d.Args[1]
+		}
+	}
+
+	for _, d := range block.LookupAll("ssl_certificate") {
+		if len(d.Args) == 0 || strings.Contains(d.Args[0], "$") {
+			continue
+		}
+		data, err := readNginxFile(resolveConfigPath(d.Args[0]))
+		if err != nil {
+			continue
+		}
+		if chain, err := decodeCertificateChain(data); err == nil {
+			report.KeyTypes = append(report.KeyTypes, describeKeyType(chain[0]))
+		}
+	}
+}
+
+// HasOCSPResponder reports whether the certificate at path names an OCSP
+// responder, without which ssl_stapling has nothing to staple. Certificates
+// that can't be read are treated as having none.
+func HasOCSPResponder(certPath string) bool {
+	data, err := readNginxFile(certPath)
+	if err != nil {
+		return false
+	}
+	chain, err := decodeCertificateChain(data)
+	return err == nil && len(chain[0].OCSPServer) > 0
+}
+
+// probeTLS handshakes with the listener to see what it actually accepts
+func probeTLS(report *TLSReport) {
+	var allSuites []uint16
+	for id := range openSSLCipherNames {
+		allSuites = append(allSuites, id)
+	}
+
+	// A full handshake offering everything, followed by a request for the headers
+	conn, err := tlsDial(report, &tls.Config{
+		MinVersion:   tls.VersionTLS10,
+		CipherSuites: allSuites,
+	})
+	if err != nil {
+		report.HandshakeErr = err
+		return
+	}
+	state := conn.ConnectionState()
+	report.NegotiatedCipher = openSSLCipherNames[state.CipherSuite]
+	report.OCSPStapled = len(state.OCSPResponse) > 0
+	if len(state.PeerCertificates) > 0 && len(report.KeyTypes) == 0 {
+		report.KeyTypes = []string{describeKeyType(state.PeerCertificates[0])}
+	}
+	if hsts := fetchHSTS(conn, report.SNI); hsts != "" {
+		report.HSTS = hsts
+	}
+	conn.Close()
+
+	// One handshake per protocol version
+	for _, v := range tlsVersions {
+		conn, err := tlsDial(report, &tls.Config{
+			MinVersion:   v.version,
+			MaxVersion:   v.version,
+			CipherSuites: allSuites,
+		})
+		if err != nil {
+			continue
+		}
+		conn.Close()
+		report.Protocols = append(report.Protocols, v.name)
+
+		if v.version == tls.VersionTLS13 {
+			// TLS 1.3 suites can't be restricted from the client side
+			continue
+		}
+
+		// One handshake per cipher suite the client knows for this version
+		for id, name := range openSSLCipherNames {
+			if slices.Contains(report.Ciphers, name) || !suiteSupports(id, v.version) {
+				continue
+			}
+			conn, err := tlsDial(report, &tls.Config{
+				MinVersion:   v.version,
+				MaxVersion:   v.version,
+				CipherSuites: []uint16{id},
+			})
+			if err != nil {
+				continue
+			}
+			conn.Close()
+			report.Ciphers = append(report.Ciphers, name)
+		}
+	}
+	sort.Strings(report.Ciphers)
+}
+
+// tlsDial makes one handshake with the report's listener
+func tlsDial(report *TLSReport, cfg *tls.Config) (*tls.Conn, error) {
+	// Grading looks at the configuration, not whether the certificate is trusted
+	cfg.InsecureSkipVerify = true
+	cfg.ServerName = report.SNI
+	dialer := &net.Dialer{Timeout: tlsProbeTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", report.Address, cfg)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(tlsProbeTimeout))
+	return conn, nil
+}
+
+// fetchHSTS sends a HEAD request over conn and returns the HSTS header
+func fetchHSTS(conn *tls.Conn, host string) string {
+	if host == "" {
+		host = "localhost"
+	}
+	if _, err := fmt.Fprintf(conn, "HEAD / HTTP/1.1\r\nHost: %s\r\nConnection: close\r\n\r\n", host); err != nil {
+		return ""
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		return ""
+	}
+	resp.Body.Close()
+	return resp.Header.Get("Strict-Transport-Security")
+}
+
+// suiteSupports reports whether crypto/tls can use the suite with the version
+func suiteSupports(id, version uint16) bool {
+	for _, list := range [][]*tls.CipherSuite{tls.CipherSuites(), tls.InsecureCipherSuites()} {
+		for _, suite := range list {
+			if suite.ID != id {
+				continue
+			}
+			for _, v := range suite.SupportedVersions {
+				if v == version {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// scoreTLS scores the report against every profile and sets the grade
+func scoreTLS(report *TLSReport) {
+	report.Grade = "none"
+	for _, profile := range TLSProfiles {
+		score := TLSProfileScore{
+			Profile: profile.Name,
+			Checks: []TLSCheck{
+				checkProtocols(report, profile),
+				checkCiphers(report, profile),
+				checkCertTypes(report, profile),
+				checkStapling(report, profile),
+				checkHSTS(report, profile),
+				checkSessionTickets(report, profile),
+			},
+			Passed: true,
+		}
+		for _, check := range score.Checks {
+			if check.Status == TLSCheckFail {
+				score.Passed = false
+			}
+		}
+		if score.Passed && report.Grade == "none" {
+			report.Grade = profile.Name
+		}
+		report.Scores = append(report.Scores, score)
+	}
+}
+
+func checkProtocols(report *TLSReport, profile TLSProfile) TLSCheck {
+	check := TLSCheck{Name: "Protocols"}
+
+	protocols := report.Protocols
+	source := "accepted"
+	if report.HandshakeErr != nil {
+		protocols = report.ConfiguredProtocols
+		source = "configured"
+	} else {
+		// crypto/tls can't speak SSL, so trust the configuration for those
+		for _, p := range report.ConfiguredProtocols {
+			if strings.HasPrefix(p, "SSL") {
+				protocols = append(protocols, p)
+			}
+		}
+	}
+	if len(protocols) == 0 {
+		check.Status = TLSCheckFail
+		check.Detail = "no protocol " + source
+		return check
+	}
+
+	var extra []string
+	for _, p := range protocols {
+		if !slices.Contains(profile.Protocols, p) {
+			extra = append(extra, p)
+		}
+	}
+	if len(extra) > 0 {
+		check.Status = TLSCheckFail
+		check.Detail = fmt.Sprintf("%s but not allowed: %s", source, strings.Join(extra, " "))
+		return check
+	}
+	check.Detail = source + ": " + strings.Join(protocols, " ")
+	return check
+}
+
+func checkCiphers(report *TLSReport, profile TLSProfile) TLSCheck {
+	check := TLSCheck{Name: "Ciphers"}
+
+	ciphers := report.Ciphers
+	source := "accepted"
+	if report.HandshakeErr != nil {
+		// Without a handshake only an explicit list can be evaluated
+		explicit, ok := explicitCipherList(report.ConfiguredCiphers)
+		if !ok {
+			check.Status = TLSCheckUnknown
+			check.Detail = "cipher string " + report.ConfiguredCiphers + " needs a handshake to evaluate"
+			return check
+		}
+		ciphers = explicit
+		source = "configured"
+	}
+
+	var extra []string
+	for _, c := range ciphers {
+		if !slices.Contains(profile.Ciphers, c) {
+			extra = append(extra, c)
+		}
+	}
+	if len(extra) > 0 {
+		check.Status = TLSCheckFail
+		check.Detail = fmt.Sprintf("%s but not allowed: %s", source, strings.Join(extra, " "))
+		return check
+	}
+	check.Detail = fmt.Sprintf("%d TLSv1.2 and older suites %s, all allowed", len(ciphers), source)
+	return check
+}
+
+// explicitCipherList splits an ssl_ciphers value that names suites directly
+func explicitCipherList(value string) ([]string, bool) {
+	var ciphers []string
+	for _, c := range strings.Split(value, ":") {
+		// Keywords like HIGH or ALL and modifiers like !MD5 select sets of suites
+		named := strings.Contains(c, "-") || strings.HasPrefix(c, "TLS_")
+		if !named || strings.ContainsAny(c, "!+@") || strings.HasPrefix(c, "-") {
+			return nil, false
+		}
+		ciphers = append(ciphers, c)
+	}
+	return ciphers, true
+}
+
+func checkCertTypes(report *TLSReport, profile TLSProfile) TLSCheck {
+	check := TLSCheck{Name: "Certificate"}
+	if len(report.KeyTypes) == 0 {
+		check.Status = TLSCheckUnknown
+		check.Detail = "certificate could not be read"
+		return check
+	}
+
+	for _, keyType := range report.KeyTypes {
+		if !certTypeAllowed(keyType, profile) {
+			check.Status = TLSCheckFail
+			check.Detail = fmt.Sprintf("%s, expected %s", keyType, strings.Join(profile.CertTypes, " or "))
+			return check
+		}
+	}
+	check.Detail = strings.Join(report.KeyTypes, ", ")
+	return check
+}
+
+// certTypeAllowed reports whether a describeKeyType value satisfies the profile
+func certTypeAllowed(keyType string, profile TLSProfile) bool {
+	for _, allowed := range profile.CertTypes {
+		if allowed == "RSA" {
+			var bits int
+			if _, err := fmt.Sscanf(keyType, "RSA %d", &bits); err == nil && bits >= profile.MinRSABits {
+				return true
+			}
+			continue
+		}
+		if keyType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func checkStapling(report *TLSReport, profile TLSProfile) TLSCheck {
+	check := TLSCheck{Name: "OCSP stapling"}
+	switch {
+	case !profile.OCSPStapling || report.OCSPStapled:
+		check.Detail = "stapled"
+		if !report.OCSPStapled {
+			check.Detail = "not required"
+		}
+	case report.StaplingEnabled:
+		// NGINX fetches the response lazily, so the first handshakes go without
+		check.Status = TLSCheckUnknown
+		check.Detail = "enabled, but no response was stapled"
+	default:
+		check.Status = TLSCheckFail
+		check.Detail = "ssl_stapling is off"
+	}
+	return check
+}
+
+func checkHSTS(report *TLSReport, profile TLSProfile) TLSCheck {
+	check := TLSCheck{Name: "HSTS"}
+	if report.HSTS == "" {
+		check.Status = TLSCheckFail
+		check.Detail = "no Strict-Transport-Security header"
+		return check
+	}
+
+	maxAge := 0
+	if match := hstsMaxAgeRe.FindStringSubmatch(report.HSTS); match != nil {
+		maxAge, _ = strconv.Atoi(match[1])
+	}
+	if maxAge < profile.HSTSMaxAge {
+		check.Status = TLSCheckFail
+		check.Detail = fmt.Sprintf("max-age %d, expected at least %d", maxAge, profile.HSTSMaxAge)
+		return check
+	}
+	check.Detail = fmt.Sprintf("max-age %d", maxAge)
+	return check
+}
+
+func checkSessionTickets(report *TLSReport, profile TLSProfile) TLSCheck {
+	check := TLSCheck{Name: "Session tickets"}
+	if report.SessionTickets && !profile.SessionTickets {
+		check.Status = TLSCheckFail
+		check.Detail = "ssl_session_tickets is on"
+		return check
+	}
+	check.Detail = "off"
+	if report.SessionTickets {
+		check.Detail = "on"
+	}
+	return check
+}
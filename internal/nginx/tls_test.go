@@ -0,0 +1,186 @@
+package nginx
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+// testTLSServer starts an HTTPS server with a fixed TLS configuration
+// around a self-signed ECDSA certificate
+func testTLSServer(t *testing.T, configure func(*tls.Config)) *httptest.Server {
+	t.Helper()
+	leaf, key := testLeaf(t, "shop.example")
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Strict-Transport-Security", "max-age=63072000")
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{leaf.Raw},
+			PrivateKey:  key,
+			// The handshake only checks that something was stapled
+			OCSPStaple: []byte("ocsp"),
+		}},
+		SessionTicketsDisabled: true,
+	}
+	// Probing offers suites the server refuses on purpose
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	configure(server.TLS)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGradeTLS(t *testing.T) {
+	gcm := []uint16{
+		tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	}
+	tests := []struct {
+		name          string
+		configure     func(*tls.Config)
+		wantProtocols []string
+		wantCiphers   []string
+		wantGrade     string
+	}{
+		{"TLS 1.3 only", func(c *tls.Config) {
+			c.MinVersion = tls.VersionTLS13
+		}, []string{"TLSv1.3"}, nil, "modern"},
+		{"TLS 1.2 with AEAD suites", func(c *tls.Config) {
+			c.MinVersion = tls.VersionTLS12
+			c.CipherSuites = gcm
+		}, []string{"TLSv1.2", "TLSv1.3"}, []string{"ECDHE-ECDSA-AES128-GCM-SHA256", "ECDHE-ECDSA-AES256-GCM-SHA384", "ECDHE-ECDSA-CHACHA20-POLY1305"}, "intermediate"},
+		// Old clients need an RSA certificate, so TLS 1.0 gets no grade at all
+		{"TLS 1.0 with CBC", func(c *tls.Config) {
+			c.MinVersion = tls.VersionTLS10
+			c.CipherSuites = append([]uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA}, gcm...)
+		}, []string{"TLSv1", "TLSv1.1", "TLSv1.2", "TLSv1.3"}, []string{"ECDHE-ECDSA-AES128-GCM-SHA256", "ECDHE-ECDSA-AES128-SHA", "ECDHE-ECDSA-AES256-GCM-SHA384", "ECDHE-ECDSA-CHACHA20-POLY1305"}, "none"},
+	}
+	for _, tt := range tests {
+		server := testTLSServer(t, tt.configure)
+		report := TLSReport{
+			Address:             server.Listener.Addr().String(),
+			SNI:                 "shop.example",
+			ConfiguredProtocols: defaultSSLProtocols,
+			ConfiguredCiphers:   defaultSSLCiphers,
+			StaplingEnabled:     true,
+		}
+		probeTLS(&report)
+		scoreTLS(&report)
+
+		if report.HandshakeErr != nil {
+			t.Fatalf("%s: %v", tt.name, report.HandshakeErr)
+		}
+		if !slices.Equal(report.Protocols, tt.wantProtocols) {
+			t.Errorf("%s: protocols %q, want %q", tt.name, report.Protocols, tt.wantProtocols)
+		}
+		if !slices.Equal(report.Ciphers, tt.wantCiphers) {
+			t.Errorf("%s: ciphers %q, want %q", tt.name, report.Ciphers, tt.wantCiphers)
+		}
+		if !report.OCSPStapled || report.HSTS != "max-age=63072000" || !slices.Equal(report.KeyTypes, []string{"ECDSA P-256"}) {
+			t.Errorf("%s: stapled %v, HSTS %q, key types %q", tt.name, report.OCSPStapled, report.HSTS, report.KeyTypes)
+		}
+		if report.Grade != tt.wantGrade {
+			t.Errorf("%s: grade %s, want %s; scores %+v", tt.name, report.Grade, tt.wantGrade, report.Scores)
+		}
+	}
+}
+
+func TestGradeTLSWithoutHandshake(t *testing.T) {
+	// Nothing listens here, so only the configuration can be scored
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	address := server.Listener.Addr().String()
+	server.Close()
+
+	report := TLSReport{
+		Address:             address,
+		ConfiguredProtocols: []string{"TLSv1.2", "TLSv1.3"},
+		ConfiguredCiphers:   defaultSSLCiphers,
+		SessionTickets:      true,
+	}
+	probeTLS(&report)
+	scoreTLS(&report)
+	if report.HandshakeErr == nil {
+		t.Fatal("handshake with a closed listener succeeded")
+	}
+	if report.Grade != "none" {
+		t.Errorf("grade %s, want none", report.Grade)
+	}
+	for _, score := range report.Scores {
+		if score.Profile != "intermediate" {
+			continue
+		}
+		checks := map[string]TLSCheckStatus{}
+		for _, check := range score.Checks {
+			checks[check.Name] = check.Status
+		}
+		want := map[string]TLSCheckStatus{
+			"Protocols":       TLSCheckPass,
+			"Ciphers":         TLSCheckUnknown,
+			"Certificate":     TLSCheckUnknown,
+			"OCSP stapling":   TLSCheckFail,
+			"HSTS":            TLSCheckFail,
+			"Session tickets": TLSCheckFail,
+		}
+		for name, status := range want {
+			if checks[name] != status {
+				t.Errorf("%s check = %d, want %d", name, checks[name], status)
+			}
+		}
+	}
+}
+
+func TestHasOCSPResponder(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile := func(name string, ocsp []string) string {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "shop.example"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			OCSPServer:   ocsp,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return writeTempFile(t, name, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+	}
+
+	// Generated certificates, including the ACME placeholder, name no responder
+	placeholder := writeTempFile(t, "placeholder.crt", "")
+	if err := EnsurePlaceholderCertificate(placeholder+".pem", placeholder+".key", []string{"shop.example"}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{certFile("issued.crt", []string{"http://ocsp.example"}), true},
+		{certFile("no-ocsp.crt", nil), false},
+		{placeholder + ".pem", false},
+		{placeholder, false},
+		{placeholder + ".missing", false},
+	}
+	for _, tt := range tests {
+		if got := HasOCSPResponder(tt.path); got != tt.want {
+			t.Errorf("HasOCSPResponder(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
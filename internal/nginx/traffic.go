@@ -0,0 +1,219 @@
+package nginx
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TrafficDimension is a request attribute traffic can be broken down by
+type TrafficDimension int
+
+const (
+	Sites TrafficDimension = iota
+	ClientIPs
+	RequestPaths
+	UserAgents
+	Referers
+	StatusCodes
+	Methods
+	ClientTypes
+)
+
+// TrafficDimensions lists every dimension in display order
+var TrafficDimensions = []TrafficDimension{Sites, ClientIPs, RequestPaths, UserAgents, Referers, StatusCodes, Methods, ClientTypes}
+
+// String returns the dimension's display name
+func (d TrafficDimension) String() string {
+	switch d {
+	case Sites:
+		return "Sites"
+	case ClientIPs:
+		return "Client IPs"
+	case RequestPaths:
+		return "Paths"
+	case UserAgents:
+		return "User agents"
+	case Referers:
+		return "Referers"
+	case StatusCodes:
+		return "Status codes"
+	case Methods:
+		return "Methods"
+	case ClientTypes:
+		return "Clients"
+	}
+	return "Unknown"
+}
+
+// Key returns the entry's value for the dimension
+func (d TrafficDimension) Key(e LogEntry) string {
+	switch d {
+	case Sites:
+		return e.Site
+	case ClientIPs:
+		return e.IP
+	case RequestPaths:
+		return e.Path
+	case UserAgents:
+		return e.UserAgent
+	case Referers:
+		return e.Referer
+	case StatusCodes:
+		return strconv.Itoa(e.StatusCode)
+	case Methods:
+		return e.Method
+	case ClientTypes:
+		return ClassifyUserAgent(e.UserAgent).String()
+	}
+	return ""
+}
+
+// Query returns a filter expression matching the requests counted under key
+func (d TrafficDimension) Query(key string) string {
+	switch d {
+	case Sites:
+		return "site:" + exactQueryValue(key)
+	case ClientIPs:
+		return "ip:" + exactQueryValue(key)
+	case StatusCodes:
+		return "status:" + key
+	case RequestPaths:
+		return "path:" + exactQueryValue(key)
+	case UserAgents:
+		return "ua:" + exactQueryValue(key)
+	case Referers:
+		return "referer:" + exactQueryValue(key)
+	case Methods:
+		return "method:" + exactQueryValue(key)
+	case ClientTypes:
+		return "client:" + key
+	}
+	return ""
+}
+
+// exactQueryValue returns a query value matching exactly s: an anchored
+// regular expression, quoted when it holds spaces. Double quotes can't be
+// written inside a query value and match any character instead.
+func exactQueryValue(s string) string {
+	value := "~^" + strings.ReplaceAll(regexp.QuoteMeta(s), `"`, ".") + "$"
+	if strings.ContainsAny(value, " \t") {
+		value = `"` + value + `"`
+	}
+	return value
+}
+
+// TrafficCount is the traffic counted under one key
+type TrafficCount struct {
+	Requests     int
+	Bytes        int64
+	ClientErrors int // 4xx responses
+	ServerErrors int // 5xx responses
+}
+
+// add counts one request
+func (c *TrafficCount) add(e LogEntry) {
+	c.Requests++
+	c.Bytes += int64(e.BytesSent)
+	switch e.StatusCode / 100 {
+	case 4:
+		c.ClientErrors++
+	case 5:
+		c.ServerErrors++
+	}
+}
+
+// TrafficSort is an order for traffic rows
+type TrafficSort int
+
+const (
+	ByRequests TrafficSort = iota
+	ByBytes
+	ByServerErrors
+	ByClientErrors
+	ByKey
+)
+
+// TrafficSorts lists every order in the sequence the sort key cycles through
+var TrafficSorts = []TrafficSort{ByRequests, ByBytes, ByServerErrors, ByClientErrors, ByKey}
+
+// String returns the order's display name
+func (o TrafficSort) String() string {
+	switch o {
+	case ByRequests:
+		return "requests"
+	case ByBytes:
+		return "bytes"
+	case ByServerErrors:
+		return "5xx"
+	case ByClientErrors:
+		return "4xx"
+	case ByKey:
+		return "name"
+	}
+	return "unknown"
+}
+
+// SortTrafficRows orders rows, busiest first for every order but ByKey. Ties
+// fall back to requests, then to the key.
+func SortTrafficRows(rows []TrafficRow, order TrafficSort) {
+	value := func(row TrafficRow) int64 {
+		switch order {
+		case ByBytes:
+			return row.Bytes
+		case ByServerErrors:
+			return int64(row.ServerErrors)
+		case ByClientErrors:
+			return int64(row.ClientErrors)
+		}
+		return int64(row.Requests)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if order != ByKey {
+			if vi, vj := value(rows[i]), value(rows[j]); vi != vj {
+				return vi > vj
+			}
+			if rows[i].Requests != rows[j].Requests {
+				return rows[i].Requests > rows[j].Requests
+			}
+		}
+		return rows[i].Key < rows[j].Key
+	})
+}
+
+// TrafficRow is one key of a traffic breakdown
+type TrafficRow struct {
+	Key string
+	TrafficCount
+}
+
+// Top returns the first n keys of a dimension in the given order, or every
+// key when n is 0
+func (s *LogStats) Top(d TrafficDimension, order TrafficSort, n int) []TrafficRow {
+	counts := s.Traffic[d]
+	rows := make([]TrafficRow, 0, len(counts))
+	for key, count := range counts {
+		rows = append(rows, TrafficRow{Key: key, TrafficCount: count})
+	}
+	SortTrafficRows(rows, order)
+	if n > 0 && len(rows) > n {
+		rows = rows[:n]
+	}
+	return rows
+}
+
+// addTraffic counts an entry under its key in every dimension
+func addTraffic(traffic map[TrafficDimension]map[string]TrafficCount, e LogEntry) {
+	for _, d := range TrafficDimensions {
+		counts := traffic[d]
+		if counts == nil {
+			counts = make(map[string]TrafficCount)
+			traffic[d] = counts
+		}
+		key := d.Key(e)
+		count := counts[key]
+		count.add(e)
+		counts[key] = count
+	}
+}
@@ -0,0 +1,140 @@
+package nginx
+
+import (
+	"strings"
+	"time"
+)
+
+// vtsStatus is the JSON document of nginx-module-vts
+// (vhost_traffic_status_display at <location>/format/json)
+type vtsStatus struct {
+	Connections struct {
+		Active, Reading, Writing, Waiting int64
+		Accepted, Handled, Requests       int64
+	} `json:"connections"`
+	ServerZones   map[string]vtsZone      `json:"serverZones"`
+	UpstreamZones map[string][]vtsPeer    `json:"upstreamZones"`
+	CacheZones    map[string]vtsCacheZone `json:"cacheZones"`
+}
+
+// vtsResponses counts responses by status class and by cache status
+type vtsResponses struct {
+	R1xx int64 `json:"1xx"`
+	R2xx int64 `json:"2xx"`
+	R3xx int64 `json:"3xx"`
+	R4xx int64 `json:"4xx"`
+	R5xx int64 `json:"5xx"`
+
+	Hit, Stale, Updating, Revalidated int64
+	Miss, Expired, Bypass             int64
+}
+
+// counts returns the responses by status class
+func (r vtsResponses) counts() ResponseCounts {
+	return ResponseCounts{r.R1xx, r.R2xx, r.R3xx, r.R4xx, r.R5xx}
+}
+
+type vtsZone struct {
+	RequestCounter    int64 `json:"requestCounter"`
+	InBytes, OutBytes int64
+	Responses         vtsResponses `json:"responses"`
+}
+
+type vtsPeer struct {
+	Server         string       `json:"server"`
+	RequestCounter int64        `json:"requestCounter"`
+	Responses      vtsResponses `json:"responses"`
+	ResponseMsec   int64        `json:"responseMsec"`
+	Weight         int          `json:"weight"`
+	Backup         bool         `json:"backup"`
+	Down           bool         `json:"down"`
+}
+
+type vtsCacheZone struct {
+	MaxSize   int64        `json:"maxSize"`
+	UsedSize  int64        `json:"usedSize"`
+	Responses vtsResponses `json:"responses"`
+}
+
+// vtsSource reads per-server-zone, upstream and cache traffic from
+// nginx-module-vts
+type vtsSource struct{ s *Service }
+
+func (vtsSource) Name() string { return VTSSource }
+
+func (src vtsSource) Collect(metrics *Metrics) error {
+	endpoint, err := src.s.findStatusEndpoint("vhost_traffic_status_display", src.s.metricsConfig.VTSURL)
+	if err != nil {
+		return err
+	}
+	if src.s.metricsConfig.VTSURL == "" {
+		endpoint.URL = strings.TrimSuffix(endpoint.URL, "/") + "/format/json"
+	}
+	var status vtsStatus
+	if err := fetchStatusJSON(endpoint, &status); err != nil {
+		return err
+	}
+
+	var zones []ServerZone
+	for name, zone := range status.ServerZones {
+		if name == "*" {
+			// The total over every zone
+			continue
+		}
+		zones = append(zones, ServerZone{
+			Name:      name,
+			Requests:  zone.RequestCounter,
+			Responses: zone.Responses.counts(),
+			Received:  zone.InBytes,
+			Sent:      zone.OutBytes,
+		})
+	}
+
+	var upstreams []Upstream
+	for name, peers := range status.UpstreamZones {
+		if name == "::nogroups" {
+			// proxy_pass straight to an address, outside any upstream block
+			name = "(no upstream block)"
+		}
+		upstream := Upstream{Name: name}
+		for _, peer := range peers {
+			state := "up"
+			if peer.Down {
+				state = "down"
+			}
+			upstream.Peers = append(upstream.Peers, UpstreamPeer{
+				Server:       peer.Server,
+				State:        state,
+				Backup:       peer.Backup,
+				Weight:       peer.Weight,
+				Requests:     peer.RequestCounter,
+				Responses:    peer.Responses.counts(),
+				ResponseTime: time.Duration(peer.ResponseMsec) * time.Millisecond,
+			})
+		}
+		upstreams = append(upstreams, upstream)
+	}
+
+	var caches []CacheZone
+	for name, cache := range status.CacheZones {
+		r := cache.Responses
+		caches = append(caches, CacheZone{
+			Name: name,
+			Hit:  r.Hit, Stale: r.Stale, Updating: r.Updating, Revalidated: r.Revalidated,
+			Miss: r.Miss, Expired: r.Expired, Bypass: r.Bypass,
+			Size: cache.UsedSize, MaxSize: cache.MaxSize,
+		})
+	}
+	sortTraffic(zones, upstreams, caches)
+
+	c := status.Connections
+	metrics.Connections = &StubStatus{
+		Active: c.Active, Reading: c.Reading, Writing: c.Writing, Waiting: c.Waiting,
+		Accepts: c.Accepted, Handled: c.Handled, Requests: c.Requests,
+		At: time.Now(),
+	}
+	metrics.ActiveConns = int(c.Active)
+	metrics.TotalConns = c.Accepted
+	metrics.Zones, metrics.Upstreams, metrics.Caches = zones, upstreams, caches
+	return nil
+}
@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+)
+
+// anomalySummaryRows is how many anomalies the Stats tab lists
+const anomalySummaryRows = 5
+
+// AnomalySite names the site an anomaly was flagged on
+func AnomalySite(site string) string {
+	return valueOr(site, "shared logs")
+}
+
+// anomalyColor is the ANSI colour of an anomaly kind
+func anomalyColor(kind nginx.AnomalyKind) string {
+	switch kind {
+	case nginx.ErrorSpike:
+		return "31"
+	case nginx.TrafficDrop:
+		return "35"
+	}
+	return "33"
+}
+
+// anomalyWhen describes when an anomaly happened, e.g. "14:05:10 → 14:07:40"
+func anomalyWhen(a nginx.Anomaly, now time.Time) string {
+	if a.Ongoing() {
+		return fmt.Sprintf("%s → ongoing (%s)", a.Since.Format("15:04:05"), formatDuration(now.Sub(a.Since)))
+	}
+	return fmt.Sprintf("%s → %s", a.Since.Format("15:04:05"), a.Until.Format("15:04:05"))
+}
+
+// renderAnomalyRow renders one anomaly as a table row
+func renderAnomalyRow(a nginx.Anomaly, selected bool, now time.Time) string {
+	marker := "\033[90m○\033[0m"
+	if a.Ongoing() {
+		marker = fmt.Sprintf("\033[%sm●\033[0m", anomalyColor(a.Kind))
+	}
+	return fmt.Sprintf("%s%s \033[%sm%-18s\033[0m \033[1;97m%-24s\033[0m %-32s \033[90m%s\033[0m",
+		trafficCursor(selected), marker, anomalyColor(a.Kind), a.Kind,
+		truncate(AnomalySite(a.Site), 24), truncate(a.Detail(), 32), anomalyWhen(a, now))
+}
+
+// RenderNotifications renders the notifications panel of flagged anomalies
+func (r *Renderer) RenderNotifications(m *model.Model, width, height int) string {
+	title := "\033[1;36m🔔 NOTIFICATIONS\033[0m \033[90m· anomalies against each site's baseline\033[0m\n"
+	keys := "\n  \033[90m↑↓ select · enter show the requests · esc close\033[0m"
+
+	detector, _ := m.Anomalies.(*nginx.AnomalyDetector)
+	events := detector.Events()
+	if len(events) == 0 {
+		return title + "\n  " + renderAnomalyState(detector) + keys
+	}
+
+	headers := fmt.Sprintf("    \033[1;90m%-18s %-24s %-32s %s\033[0m\n", "ANOMALY", "SITE", "DEVIATION", "WHEN")
+	divider := "\033[90m" + strings.Repeat("─", min(width-2, 110)) + "\033[0m\n"
+
+	rows := max(height-6, 3)
+	start := 0
+	if m.NotificationCursor >= rows {
+		start = m.NotificationCursor - rows + 1
+	}
+	now := time.Now()
+	var lines []string
+	for i := start; i < min(len(events), start+rows); i++ {
+		lines = append(lines, renderAnomalyRow(events[i], i == m.NotificationCursor, now))
+	}
+	return title + "  " + renderAnomalyState(detector) + "\n\n" + headers + divider + strings.Join(lines, "\n") + keys
+}
+
+// renderAnomalyState summarises how many sites are watched
+func renderAnomalyState(detector *nginx.AnomalyDetector) string {
+	watched, ready := detector.Sites()
+	sites := "sites"
+	if watched == 1 {
+		sites = "site"
+	}
+	switch {
+	case watched == 0:
+		return "\033[90mWaiting for access log traffic to learn baselines from\033[0m"
+	case ready < watched:
+		return fmt.Sprintf("\033[32m●\033[0m watching \033[97m%d\033[0m %s \033[90m(%d still learning their baseline)\033[0m", watched, sites, watched-ready)
+	}
+	return fmt.Sprintf("\033[32m●\033[0m watching \033[97m%d\033[0m %s", watched, sites)
+}
+
+// RenderAnomalySummary renders the ongoing and latest anomalies for the Stats tab
+func (r *Renderer) RenderAnomalySummary(m *model.Model) string {
+	detector, _ := m.Anomalies.(*nginx.AnomalyDetector)
+	events := detector.Events()
+	ongoing := len(detector.Ongoing())
+
+	title := fmt.Sprintf("\033[1;36m▸ ANOMALIES\033[0m \033[90m· %d ongoing (! for all)\033[0m\n", ongoing)
+	if len(events) == 0 {
+		return title + "  " + renderAnomalyState(detector)
+	}
+	now := time.Now()
+	var lines []string
+	for _, event := range events[:min(len(events), anomalySummaryRows)] {
+		lines = append(lines, renderAnomalyRow(event, false, now))
+	}
+	return title + strings.Join(lines, "\n")
+}
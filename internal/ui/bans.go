@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+)
+
+// RenderBansView renders the clients banned by the ban rules
+func (r *Renderer) RenderBansView(m *model.Model, width, height int) string {
+	title := "\033[1;36m⛔ BANNED CLIENTS\033[0m\n"
+
+	banner, _ := m.Banner.(*nginx.Banner)
+	if banner == nil {
+		return title + "\n  \033[33m⚠ Ban rules could not be loaded; check the bans section of the configuration\033[0m"
+	}
+
+	state := "\033[32m●\033[0m automatic banning on"
+	if !banner.Enabled() {
+		state = "\033[90m○ automatic banning off (set bans.enabled and add bans.rules in the configuration)\033[0m"
+	}
+	switch {
+	case m.BansApplying:
+		state += "   \033[90mapplying...\033[0m"
+	case m.BansErr != nil:
+		state += fmt.Sprintf("   \033[33m⚠ %v\033[0m", m.BansErr)
+	}
+
+	bans := banner.Active()
+	if len(bans) == 0 {
+		return title + "  " + state + "\n\n  \033[90mNo active bans\033[0m"
+	}
+	summary := fmt.Sprintf("  %s   \033[97m%d\033[0m banned\n\n", state, len(bans))
+
+	headers := fmt.Sprintf("  \033[1;90m%-39s %-24s %-20s %-10s %-10s\033[0m\n", "CLIENT", "RULE", "REASON", "BANNED", "EXPIRES IN")
+	divider := "\033[90m" + strings.Repeat("─", min(width-2, 110)) + "\033[0m\n"
+
+	tableRows := max(height-6, 3)
+	start := 0
+	if m.BanCursor >= tableRows {
+		start = m.BanCursor - tableRows + 1
+	}
+
+	now := time.Now()
+	var rows []string
+	for i := start; i < min(len(bans), start+tableRows); i++ {
+		ban := bans[i]
+		client := ban.IP
+		if nginx.GeoIPEnabled() {
+			if geo := nginx.LookupGeo(ban.IP); geo.Country != "" {
+				client += " (" + geo.Country + ")"
+			}
+		}
+		// Bans NGINX hasn't taken yet are retried with the next change
+		expires := formatDuration(ban.Expires.Sub(now))
+		switch {
+		case banner.Applied(ban.IP):
+		case m.BansApplying:
+			expires = "\033[90mapplying\033[0m"
+		default:
+			expires = "\033[33mnot applied\033[0m"
+		}
+		rows = append(rows, fmt.Sprintf("%s\033[1;97m%-39s\033[0m \033[33m%-24s\033[0m %-20s \033[90m%-10s\033[0m %s",
+			trafficCursor(i == m.BanCursor),
+			truncate(client, 39), truncate(ban.Rule, 24), truncate(ban.Reason, 20),
+			ban.Since.Format("15:04:05"), expires))
+	}
+	return title + summary + headers + divider + strings.Join(rows, "\n")
+}
@@ -0,0 +1,173 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+)
+
+// RenderCertificatesView renders the TLS certificate inventory
+func (r *Renderer) RenderCertificatesView(m *model.Model, width, height int) string {
+	title := "\033[1;36m🔒 TLS CERTIFICATES\033[0m\n"
+
+	warnDays := 30
+	if m.Config != nil {
+		warnDays = m.Config.CertExpiryWarnDays
+	}
+
+	if m.CertificatesErr != nil {
+		return title + fmt.Sprintf("\n  \033[33m⚠ Unable to load certificates: %v\033[0m", m.CertificatesErr)
+	}
+
+	certs, ok := m.Certificates.([]nginx.Certificate)
+	if !ok {
+		return title + "\n  \033[90mLoading certificates...\033[0m"
+	}
+	if len(certs) == 0 {
+		return title + "\n  \033[90mNo ssl_certificate directives found in the configuration\033[0m"
+	}
+
+	// Summary line
+	var expired, expiring, problems int
+	for _, c := range certs {
+		switch {
+		case c.Err != nil:
+			problems++
+		case c.Expired():
+			expired++
+		case c.ExpiresWithin(warnDays):
+			expiring++
+		}
+		if c.Err == nil && (!c.ChainComplete || !c.KeyMatch || len(c.UncoveredNames) > 0) {
+			problems++
+		}
+	}
+	summary := fmt.Sprintf("  \033[97m%d\033[0m certificates   \033[31m✗\033[0m %d expired   \033[33m⚠\033[0m %d expiring within %d days   \033[35m●\033[0m %d with problems\n\n",
+		len(certs), expired, expiring, warnDays, problems)
+
+	headers := fmt.Sprintf("  \033[1;90m%-22s %-28s %-22s %-10s %5s  %-11s %-6s %-5s %-5s\033[0m\n",
+		"SITE", "SUBJECT", "ISSUER", "EXPIRES", "DAYS", "KEY", "CHAIN", "MATCH", "NAMES")
+	divider := "\033[90m" + strings.Repeat("─", min(width-2, 130)) + "\033[0m\n"
+
+	// Leave room for the detail panel below the table
+	tableRows := height - 16
+	if tableRows < 3 {
+		tableRows = 3
+	}
+	start := 0
+	if m.CertCursor >= tableRows {
+		start = m.CertCursor - tableRows + 1
+	}
+	end := min(len(certs), start+tableRows)
+
+	var rows []string
+	for i := start; i < end; i++ {
+		rows = append(rows, formatCertificateRow(certs[i], i == m.CertCursor, warnDays))
+	}
+
+	detail := ""
+	if m.CertCursor < len(certs) {
+		detail = "\n\n" + formatCertificateDetail(certs[m.CertCursor])
+	}
+
+	return title + summary + headers + divider + strings.Join(rows, "\n") + detail
+}
+
+// formatCertificateRow formats a single certificate as a table row
+func formatCertificateRow(c nginx.Certificate, selected bool, warnDays int) string {
+	cursor := "  "
+	if selected {
+		cursor = "\033[1;36m▸\033[0m "
+	}
+
+	if c.Err != nil {
+		return fmt.Sprintf("%s\033[31m%-22s %s\033[0m", cursor, truncate(c.Site, 22), truncate(c.Err.Error(), 100))
+	}
+
+	// Colour by expiry
+	color := "\033[32m"
+	switch {
+	case c.Expired():
+		color = "\033[31m"
+	case c.ExpiresWithin(warnDays):
+		color = "\033[33m"
+	}
+
+	return fmt.Sprintf("%s\033[97m%-22s\033[0m %-28s \033[90m%-22s\033[0m %s%-10s %5d\033[0m  %-11s %s %s %s",
+		cursor,
+		truncate(c.Site, 22),
+		truncate(c.Subject, 28),
+		truncate(c.Issuer, 22),
+		color,
+		c.NotAfter.Format("2006-01-02"),
+		c.DaysLeft,
+		c.KeyType,
+		checkMark(c.ChainComplete, 6),
+		checkMark(c.KeyMatch, 5),
+		checkMark(len(c.UncoveredNames) == 0, 5),
+	)
+}
+
+// formatCertificateDetail formats the details of the selected certificate
+func formatCertificateDetail(c nginx.Certificate) string {
+	lines := []string{
+		"\033[1;36m▸ DETAILS\033[0m",
+		fmt.Sprintf("  Certificate : %s", c.CertPath),
+		fmt.Sprintf("  Key         : %s", valueOr(c.KeyPath, "-")),
+		fmt.Sprintf("  Server names: %s", valueOr(strings.Join(c.ServerNames, " "), "-")),
+	}
+	if c.Err != nil {
+		lines = append(lines, fmt.Sprintf("  \033[31mError       : %v\033[0m", c.Err))
+		return strings.Join(lines, "\n")
+	}
+
+	lines = append(lines,
+		fmt.Sprintf("  SANs        : %s", valueOr(strings.Join(c.SANs, " "), "-")),
+		fmt.Sprintf("  Valid       : %s → %s", c.NotBefore.Format("2006-01-02"), c.NotAfter.Format("2006-01-02")),
+		fmt.Sprintf("  Chain       : %d certificate(s) in file", c.ChainLength),
+	)
+	if c.ChainError != "" {
+		lines = append(lines, fmt.Sprintf("  \033[33m⚠ Chain     : %s\033[0m", c.ChainError))
+	}
+	if c.KeyError != "" {
+		lines = append(lines, fmt.Sprintf("  \033[31m✗ Key       : %s\033[0m", c.KeyError))
+	} else if c.KeyPath != "" && !c.KeyMatch {
+		lines = append(lines, "  \033[31m✗ Key       : private key does not match certificate\033[0m")
+	}
+	if len(c.UncoveredNames) > 0 {
+		lines = append(lines, fmt.Sprintf("  \033[33m⚠ Not covered: %s\033[0m", strings.Join(c.UncoveredNames, " ")))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// checkMark renders a coloured ✓ or ✗ padded to width
+func checkMark(ok bool, width int) string {
+	pad := strings.Repeat(" ", max(width-1, 0))
+	if ok {
+		return "\033[32m✓\033[0m" + pad
+	}
+	return "\033[31m✗\033[0m" + pad
+}
+
+// valueOr returns fallback when value is empty
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// truncate shortens s to maxLen runes with an ellipsis
+func truncate(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	if maxLen <= 3 {
+		return string(runes[:maxLen])
+	}
+	return string(runes[:maxLen-3]) + "..."
+}
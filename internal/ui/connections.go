@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+)
+
+// connectionStates describes the states of the open connections. The Plus
+// API only tells idle ones apart.
+func connectionStates(status *nginx.StubStatus, source, sep string) string {
+	if source == nginx.PlusAPISource {
+		return fmt.Sprintf("%d idle", status.Waiting)
+	}
+	return fmt.Sprintf("%d reading%s%d writing%s%d waiting", status.Reading, sep, status.Writing, sep, status.Waiting)
+}
+
+// renderConnections renders the connection counters of the last metrics
+// sample and the source they came from, or how to get them
+func renderConnections(m *model.Model) string {
+	metrics, _ := m.LastMetrics.(*nginx.Metrics)
+	switch {
+	case metrics != nil && metrics.Connections != nil:
+		status := metrics.Connections
+		line := fmt.Sprintf("🔌 \033[1;97m%d\033[0m active  \033[90m(\033[0m%s\033[90m)\033[0m   "+
+			"accepts \033[1;97m%d\033[0m  handled \033[1;97m%d\033[0m  requests \033[1;97m%d\033[0m",
+			status.Active, connectionStates(status, metrics.Source, "  "), status.Accepts, status.Handled, status.Requests)
+		if dropped := status.Dropped(); dropped > 0 {
+			line += fmt.Sprintf("  \033[31m%d dropped\033[0m", dropped)
+		}
+		return line + "  \033[90m· " + metrics.Source + "\033[0m"
+	case m.StubStatusBusy:
+		return "🔌 \033[90mSetting up stub_status...\033[0m"
+	case metrics != nil && metrics.ConnectionsErr != nil:
+		return fmt.Sprintf("🔌 \033[33mConnections are counted from sockets (%v); c sets up a local stub_status\033[0m", metrics.ConnectionsErr)
+	}
+	return "🔌 \033[90mPolling status pages...\033[0m"
+}
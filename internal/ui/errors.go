@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+)
+
+// RenderErrorLogView renders the parsed error log lines, or their groups
+func (r *Renderer) RenderErrorLogView(m *model.Model, width, height int) string {
+	title := "\033[1;36m🚨 ERROR LOG\033[0m\n"
+
+	entries, _ := m.ErrorLogEntries.([]nginx.ErrorLogEntry)
+	if len(entries) == 0 {
+		if m.LogTailer == nil {
+			return title + "\n  \033[90mLoading error logs...\033[0m"
+		}
+		return title + "\n  \033[90mNo error log lines\033[0m"
+	}
+
+	minLevel := nginx.ErrorLevel(m.ErrorLogLevel)
+	visible := nginx.FilterErrorLogEntries(entries, minLevel)
+
+	// Summary line, one count per severity band
+	counts := map[string]int{}
+	for _, entry := range entries {
+		counts[levelBand(entry.Level)]++
+	}
+	mode := "every line"
+	if m.ErrorLogGrouped {
+		mode = "grouped by message"
+	}
+	summary := fmt.Sprintf("  \033[97m%d\033[0m lines   %s %d crit+   %s %d error   %s %d warn   %s %d info   \033[90mshowing %s and above, %s\033[0m\n\n",
+		len(entries),
+		levelColor(nginx.LevelCrit)+"●\033[0m", counts["crit"],
+		levelColor(nginx.LevelError)+"●\033[0m", counts["error"],
+		levelColor(nginx.LevelWarn)+"●\033[0m", counts["warn"],
+		levelColor(nginx.LevelInfo)+"●\033[0m", counts["info"],
+		minLevel, mode)
+
+	divider := "\033[90m" + strings.Repeat("─", min(width-2, 130)) + "\033[0m\n"
+
+	// Leave room for the detail panel below the table
+	tableRows := max(height-18, 3)
+	start := 0
+	if m.ErrorLogCursor >= tableRows {
+		start = m.ErrorLogCursor - tableRows + 1
+	}
+
+	var headers string
+	var rows []string
+	var detail string
+	if m.ErrorLogGrouped {
+		groups := nginx.GroupErrorLogEntries(visible)
+		headers = fmt.Sprintf("  \033[1;90m%-6s %-6s %-8s %-8s %-7s %s\033[0m\n", "COUNT", "LEVEL", "FIRST", "LAST", "CLIENTS", "MESSAGE")
+		for i := start; i < min(len(groups), start+tableRows); i++ {
+			rows = append(rows, formatErrorGroupRow(groups[i], i == m.ErrorLogCursor, width))
+		}
+		if m.ErrorLogCursor < len(groups) {
+			group := groups[m.ErrorLogCursor]
+			detail = fmt.Sprintf("\n\n  \033[1;97mRepeated %d times\033[0m \033[90mbetween %s and %s by %d clients; latest:\033[0m\n",
+				group.Count, group.First.Format("15:04:05"), group.Last.Format("15:04:05"), group.Clients) +
+				formatErrorDetail(group.Latest)
+		}
+	} else {
+		headers = fmt.Sprintf("  \033[1;90m%-8s %-6s %-18s %-15s %s\033[0m\n", "TIME", "LEVEL", "SITE", "CLIENT", "MESSAGE")
+		for i := start; i < min(len(visible), start+tableRows); i++ {
+			rows = append(rows, formatErrorRow(visible[i], i == m.ErrorLogCursor, width))
+		}
+		if m.ErrorLogCursor < len(visible) {
+			detail = "\n\n" + formatErrorDetail(visible[m.ErrorLogCursor])
+		}
+	}
+	if len(rows) == 0 {
+		rows = []string{fmt.Sprintf("  \033[90mNo lines at %s or above\033[0m", minLevel)}
+	}
+
+	return title + summary + headers + divider + strings.Join(rows, "\n") + detail
+}
+
+// formatErrorRow formats an error log entry as a table row
+func formatErrorRow(entry nginx.ErrorLogEntry, selected bool, width int) string {
+	cursor := "  "
+	if selected {
+		cursor = "\033[1;36m▸\033[0m "
+	}
+	messageWidth := max(width-56, 20)
+	if entry.ParseErr != nil {
+		return fmt.Sprintf("%s\033[90m? %s\033[0m", cursor, truncate(entry.Raw, messageWidth+50))
+	}
+	return fmt.Sprintf("%s\033[90m%-8s\033[0m %s%-6s\033[0m %-18s %-15s %s",
+		cursor,
+		entry.Timestamp.Format("15:04:05"),
+		levelColor(entry.Level), entry.Level,
+		truncate(valueOr(entry.Site, "-"), 18),
+		truncate(valueOr(entry.Client, "-"), 15),
+		truncate(entry.Message, messageWidth))
+}
+
+// formatErrorGroupRow formats a group of repeated messages as a table row
+func formatErrorGroupRow(group nginx.ErrorGroup, selected bool, width int) string {
+	cursor := "  "
+	if selected {
+		cursor = "\033[1;36m▸\033[0m "
+	}
+	message := group.Latest.Message
+	if group.Latest.ParseErr != nil {
+		message = group.Latest.Raw
+	}
+	return fmt.Sprintf("%s\033[97m%6d\033[0m %s%-6s\033[0m \033[90m%-8s %-8s\033[0m %7d %s",
+		cursor,
+		group.Count,
+		levelColor(group.Level), group.Level,
+		group.First.Format("15:04:05"),
+		group.Last.Format("15:04:05"),
+		group.Clients,
+		truncate(message, max(width-50, 20)))
+}
+
+// formatErrorDetail renders every field of an error log entry
+func formatErrorDetail(entry nginx.ErrorLogEntry) string {
+	if entry.ParseErr != nil {
+		return fmt.Sprintf("  \033[90m%s\n  %s\033[0m", entry.Raw, entry.Log)
+	}
+
+	connection := "-"
+	if entry.Connection > 0 {
+		connection = fmt.Sprintf("*%d", entry.Connection)
+	}
+	field := func(name, value string) string {
+		return fmt.Sprintf("  \033[90m%-11s\033[0m %s\n", name, valueOr(value, "-"))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("  %s%s\033[0m \033[97m%s\033[0m\n", levelColor(entry.Level), strings.ToUpper(entry.Level.String()), entry.Message))
+	sb.WriteString(field("Time", entry.Timestamp.Format("2006-01-02 15:04:05")))
+	sb.WriteString(field("Process", fmt.Sprintf("pid %d, tid %d, connection %s", entry.PID, entry.TID, connection)))
+	sb.WriteString(field("Client", entry.Client))
+	sb.WriteString(field("Server", entry.Server))
+	sb.WriteString(field("Request", entry.Request))
+	sb.WriteString(field("Upstream", entry.Upstream))
+	sb.WriteString(field("Host", entry.Host))
+	sb.WriteString(field("Log", entry.Log))
+	if entry.Client != "" {
+		sb.WriteString("\n  \033[90menter: show the matching access log request\033[0m")
+	}
+	return sb.String()
+}
+
+// levelBand puts a level into one of the summary's bands
+func levelBand(level nginx.ErrorLevel) string {
+	switch {
+	case level >= nginx.LevelCrit:
+		return "crit"
+	case level == nginx.LevelError:
+		return "error"
+	case level == nginx.LevelWarn:
+		return "warn"
+	default:
+		return "info"
+	}
+}
+
+// levelColor returns the ANSI colour for an error log level
+func levelColor(level nginx.ErrorLevel) string {
+	switch {
+	case level >= nginx.LevelCrit:
+		return "\033[1;31m"
+	case level == nginx.LevelError:
+		return "\033[31m"
+	case level == nginx.LevelWarn:
+		return "\033[33m"
+	case level == nginx.LevelNotice, level == nginx.LevelInfo:
+		return "\033[37m"
+	default:
+		return "\033[90m"
+	}
+}
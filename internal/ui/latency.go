@@ -0,0 +1,198 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NimbleMarkets/ntcharts/canvas/runes"
+	"github.com/NimbleMarkets/ntcharts/linechart/streamlinechart"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+	"github.com/aitmiloud/ngxtui/internal/styles"
+)
+
+// latencyBreakdownRows is how many sites, prefixes and upstreams the Stats tab lists
+const latencyBreakdownRows = 3
+
+// latencyTimingHint explains why no latency is shown
+const latencyTimingHint = "  \033[90mNo timings logged. Add $request_time and $upstream_response_time\n  to the log_format to measure latency.\033[0m"
+
+// RenderLatencySummary renders latency percentiles over the live windows, or
+// over the selected time range
+func (r *Renderer) RenderLatencySummary(m *model.Model) string {
+	timeRange := nginx.SelectedTimeRange(m.LogRange, time.Now())
+	title := fmt.Sprintf("\033[1;36m▸ LATENCY\033[0m \033[90m· %s\033[0m\n", timeRange.Label)
+	header := fmt.Sprintf("  \033[1;90m%-16s %7s %7s %7s %7s %7s %9s\033[0m\n", "WINDOW", "REQS", "P50", "P90", "P99", "MAX", "UPSTR P99")
+
+	// Whether the format logs timings at all is told from every entry read
+	stats, _ := m.LogStats.(*nginx.LogStats)
+	if !timeRange.Live() {
+		stats, _ = m.RangeStats.(*nginx.LogStats)
+		if m.RangeStatsPending {
+			return title + "  \033[90mReading rotated and compressed logs...\033[0m"
+		}
+	}
+	switch {
+	case stats == nil || stats.TotalRequests == 0:
+		return title + "  \033[90mNo requests in this range\033[0m"
+	case !stats.Latency.Timed():
+		return title + latencyTimingHint
+	}
+
+	var rows []string
+	if timeRange.Live() {
+		reports, _ := m.Latency.([]*nginx.LatencyReport)
+		for _, report := range reports {
+			rows = append(rows, formatLatencyRow("Last "+formatWindow(report.Window), report.Overall, 16))
+		}
+	} else {
+		rows = append(rows, formatLatencyRow(timeRange.Label, stats.Latency.Overall, 16))
+	}
+	return title + header + strings.Join(rows, "\n")
+}
+
+// RenderLatencyBreakdown renders the busiest sites, path prefixes and
+// upstreams with their latency
+func (r *Renderer) RenderLatencyBreakdown(m *model.Model) string {
+	timeRange := nginx.SelectedTimeRange(m.LogRange, time.Now())
+
+	var report *nginx.LatencyReport
+	label := timeRange.Label
+	if timeRange.Live() {
+		if reports, _ := m.Latency.([]*nginx.LatencyReport); len(reports) > 1 {
+			report = reports[1]
+			label = "last " + formatWindow(report.Window)
+		}
+	} else if stats, _ := m.RangeStats.(*nginx.LogStats); stats != nil && !m.RangeStatsPending {
+		report = stats.Latency
+	}
+	if !report.Timed() {
+		return ""
+	}
+
+	title := fmt.Sprintf("\033[1;36m▸ LATENCY BREAKDOWN\033[0m \033[90m· busiest, %s\033[0m\n", label)
+	header := fmt.Sprintf("  \033[1;90m%-9s %-28s %7s %7s %7s %7s %7s %9s\033[0m\n", "BY", "NAME", "REQS", "P50", "P90", "P99", "MAX", "UPSTR P99")
+	var lines []string
+	for _, group := range []struct {
+		kind string
+		rows []nginx.LatencyRow
+	}{
+		{"site", report.Sites},
+		{"prefix", report.Prefixes},
+		{"upstream", report.Upstreams},
+	} {
+		for _, row := range group.rows[:min(len(group.rows), latencyBreakdownRows)] {
+			lines = append(lines, fmt.Sprintf("  \033[90m%-9s\033[0m %s", group.kind, formatLatencyRow(row.Name, row, 28)))
+		}
+	}
+	return title + header + strings.Join(lines, "\n")
+}
+
+// formatLatencyRow formats one row's request percentiles and upstream p99
+func formatLatencyRow(name string, row nginx.LatencyRow, nameWidth int) string {
+	request := row.Request
+	count := max(request.Count, row.Upstream.Count)
+	return fmt.Sprintf("%-*s \033[1;97m%7d\033[0m %s %s %s %s %s",
+		nameWidth, truncate(name, nameWidth),
+		count,
+		colorLatency(request.P50, request.Count, 7),
+		colorLatency(request.P90, request.Count, 7),
+		colorLatency(request.P99, request.Count, 7),
+		colorLatency(request.Max, request.Count, 7),
+		colorLatency(row.Upstream.P99, row.Upstream.Count, 9))
+}
+
+// colorLatency right-aligns a latency, coloured by how slow it is
+func colorLatency(d time.Duration, count, width int) string {
+	if count == 0 {
+		return fmt.Sprintf("\033[90m%*s\033[0m", width, "-")
+	}
+	color := "\033[32m"
+	switch {
+	case d >= time.Second:
+		color = "\033[31m"
+	case d >= 300*time.Millisecond:
+		color = "\033[33m"
+	}
+	return fmt.Sprintf("%s%*s\033[0m", color, width, formatLatency(d))
+}
+
+// formatLatency formats a latency in milliseconds below a second, in seconds above
+func formatLatency(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.2fs", d.Seconds())
+}
+
+// formatWindow formats a sliding window length, e.g. "5m"
+func formatWindow(window time.Duration) string {
+	return fmt.Sprintf("%dm", int(window.Minutes()))
+}
+
+// RenderLatencyChart renders p50, p90 and p99 request latency over the
+// shortest live window as one chart
+func (r *Renderer) RenderLatencyChart(m *model.Model, width, height int) string {
+	color := styles.AccentDanger
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(color).
+		MarginBottom(1)
+	titleText := titleStyle.Render(fmt.Sprintf("⏱  Request Latency (%s window)", formatWindow(nginx.LatencyWindows[0])))
+
+	panel := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(color).
+		Padding(1, 2).
+		Width(width).
+		MarginBottom(1)
+
+	reports, _ := m.Latency.([]*nginx.LatencyReport)
+	if len(m.LatencyP99History) == 0 || len(reports) == 0 {
+		return panel.Render(lipgloss.JoinVertical(lipgloss.Left, titleText, styles.MutedText.Render("No data available")))
+	}
+	if stats, _ := m.LogStats.(*nginx.LogStats); stats != nil && stats.TotalRequests > 0 && !stats.Latency.Timed() {
+		return panel.Render(lipgloss.JoinVertical(lipgloss.Left, titleText, latencyTimingHint))
+	}
+	current := reports[0]
+
+	chartWidth := max(width-6, 20)
+	chartHeight := max(height-8, 5)
+
+	series := []struct {
+		name    string
+		data    []float64
+		color   lipgloss.Color
+		current time.Duration
+	}{
+		{"p99", m.LatencyP99History, styles.AccentDanger, current.Overall.Request.P99},
+		{"p90", m.LatencyP90History, styles.AccentWarning, current.Overall.Request.P90},
+		{"p50", m.LatencyP50History, styles.AccentSuccess, current.Overall.Request.P50},
+	}
+
+	slc := streamlinechart.New(chartWidth, chartHeight)
+	var names, legend []string
+	for _, s := range series {
+		slc.SetDataSetStyles(s.name, runes.ArcLineStyle, lipgloss.NewStyle().Foreground(s.color))
+		for _, v := range s.data {
+			slc.PushDataSet(s.name, v)
+		}
+		names = append(names, s.name)
+		legend = append(legend, lipgloss.NewStyle().Foreground(s.color).Bold(true).Render(
+			fmt.Sprintf("● %s %s", s.name, formatLatency(s.current))))
+	}
+	// p50 is drawn last so it stays visible where the lines meet
+	slc.DrawDataSets(names)
+
+	legendRow := lipgloss.NewStyle().MarginTop(1).Render(strings.Join(legend, "   "))
+	statsText := lipgloss.NewStyle().
+		Foreground(styles.TextSecondary).
+		MarginTop(1).
+		Render(fmt.Sprintf("Max: %s  Upstream p99: %s  Requests: %d",
+			formatLatency(current.Overall.Request.Max), formatLatency(current.Overall.Upstream.P99), current.Overall.Request.Count))
+
+	return panel.Render(lipgloss.JoinVertical(lipgloss.Left, titleText, slc.View(), legendRow, statsText))
+}
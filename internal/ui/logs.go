@@ -0,0 +1,315 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+)
+
+// renderLogSourceTitle renders the Logs tab title for the shown source
+func renderLogSourceTitle(source nginx.LogSource) string {
+	kind := "REAL-TIME ACCESS LOGS"
+	if source.Kind == nginx.ErrorLogKind {
+		kind = "ERROR LOGS"
+	}
+	title := fmt.Sprintf("\033[1;36m📋 %s\033[0m \033[90m·\033[0m \033[97m%s\033[0m", kind, source.Label)
+	if len(source.Paths) > 0 {
+		title += fmt.Sprintf(" \033[90m(%s)\033[0m", strings.Join(source.Paths, ", "))
+	}
+	if source.Shared {
+		title += " \033[33mshared with other sites\033[0m"
+	}
+	return title + "\n"
+}
+
+// renderLogSourcePicker renders the list of log sources to choose from
+func renderLogSourcePicker(sources []nginx.LogSource, cursor int) string {
+	if len(sources) == 0 {
+		return "\n\033[90mResolving logs...\033[0m"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n\033[1;90mSELECT LOGS\033[0m   \033[90m↑↓ move · enter show · esc cancel\033[0m\n\n")
+	for i, src := range sources {
+		kind := "access"
+		if src.Kind == nginx.ErrorLogKind {
+			kind = "error "
+		}
+		files := "every file on the host"
+		if len(src.Paths) > 0 {
+			files = strings.Join(src.Paths, ", ")
+		}
+		line := fmt.Sprintf("%-40s %s  %s", truncate(src.Label, 40), kind, files)
+		if i == cursor {
+			sb.WriteString("\033[1;36m▸ " + line + "\033[0m\n")
+		} else {
+			sb.WriteString("  " + line + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// renderLogRangePicker renders the time ranges to choose from
+func renderLogRangePicker(cursor int) string {
+	now := time.Now()
+	var sb strings.Builder
+	sb.WriteString("\n\033[1;90mTIME RANGE\033[0m   \033[90m↑↓ move · enter apply · esc cancel\033[0m\n\n")
+	for i, tr := range nginx.TimeRanges(now) {
+		span := "entries kept in memory, followed as they are written"
+		if !tr.Live() {
+			until := "now"
+			if !tr.Until.IsZero() {
+				until = tr.Until.Format("Mon Jan 2 15:04")
+			}
+			span = fmt.Sprintf("%s → %s, including rotated and compressed files", tr.Since.Format("Mon Jan 2 15:04"), until)
+		}
+		line := fmt.Sprintf("%-18s \033[90m%s\033[0m", tr.Label, span)
+		if i == cursor {
+			sb.WriteString("\033[1;36m▸ " + line + "\n")
+		} else {
+			sb.WriteString("  " + line + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// ShownLogEntries returns the access log entries the Logs tab lists, oldest
+// first and before they are cut to the screen, and whether a filter query or
+// time range selected them. A paused view lists the entries it was paused on.
+func ShownLogEntries(m *model.Model) ([]nginx.LogEntry, bool) {
+	sources, _ := m.LogSources.([]nginx.LogSource)
+	source := nginx.SelectedLogSource(sources, m.LogSource)
+
+	entries, _ := m.LogEntries.([]nginx.LogEntry)
+	query, _ := m.LogQuery.(*nginx.LogQuery)
+	filtered := !query.Empty() || !nginx.SelectedTimeRange(m.LogRange, time.Now()).Live()
+	if m.LogPaused {
+		frozen, _ := m.LogFrozen.([]nginx.LogEntry)
+		return frozen, filtered
+	}
+	if filtered {
+		entries, _ = m.LogMatches.([]nginx.LogEntry)
+	}
+	return sourceEntries(entries, source), filtered
+}
+
+// renderLogScrollState renders whether the Logs tab follows the tail, is
+// scrolled back or paused, or the jump-to-time input while it has focus
+func renderLogScrollState(m *model.Model, selected, total int) string {
+	if m.LogJumping {
+		return "  " + m.LogJumpInput.View()
+	}
+	position := ""
+	if total > 0 {
+		position = fmt.Sprintf(" \033[90m· entry %d of %d\033[0m", selected+1, total)
+	}
+	switch {
+	case m.LogPaused:
+		return fmt.Sprintf("  \033[1;33m⏸ PAUSED\033[0m%s \033[90m· %d new · p resume\033[0m", position, m.LogPausedNew)
+	case m.LogFollow:
+		return "  \033[32m● FOLLOWING\033[0m \033[90m· newest at the bottom · p pause\033[0m"
+	default:
+		return fmt.Sprintf("  \033[36m◆ SCROLLED BACK\033[0m%s \033[90m· F follow · p pause\033[0m", position)
+	}
+}
+
+// renderExportPicker renders the export format choices
+func renderExportPicker(cursor int, what string) string {
+	var sb strings.Builder
+	sb.WriteString("\n\033[1;90mEXPORT\033[0m   \033[90m" + what + " · ↑↓ move · enter export · esc cancel\033[0m\n\n")
+	for i, f := range nginx.ExportFormats {
+		var about string
+		switch f {
+		case nginx.ExportCSV:
+			about = "comma-separated rows with a header, for spreadsheets"
+		case nginx.ExportJSON:
+			about = "a single indented JSON document"
+		case nginx.ExportNDJSON:
+			about = "one JSON object per line, for jq and log pipelines"
+		}
+		line := fmt.Sprintf("%-8s \033[90m%s\033[0m", f, about)
+		if i == cursor {
+			sb.WriteString("\033[1;36m▸ " + line + "\n")
+		} else {
+			sb.WriteString("  " + line + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// sourceEntries keeps the entries written to the source's files
+func sourceEntries(entries []nginx.LogEntry, source nginx.LogSource) []nginx.LogEntry {
+	if len(source.Paths) == 0 {
+		return entries
+	}
+	var kept []nginx.LogEntry
+	for _, entry := range entries {
+		if source.Includes(entry.Log) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// renderErrorLogLines renders the newest error log lines of the source
+func renderErrorLogLines(m *model.Model, source nginx.LogSource) string {
+	// Account for: title (1), divider (1), menu bar (3), padding (2)
+	availableLines := min(max(m.Height-8, 10), 100)
+
+	entries, _ := m.ErrorLogEntries.([]nginx.ErrorLogEntry)
+	var lines []string
+	for _, entry := range entries {
+		if source.Includes(entry.Log) {
+			lines = append(lines, formatErrorLogLine(entry))
+		}
+	}
+	if len(lines) > availableLines {
+		lines = lines[len(lines)-availableLines:]
+	}
+
+	divider := "\033[90m" + strings.Repeat("─", 130) + "\033[0m\n"
+	if len(lines) == 0 {
+		if m.LogTailer == nil {
+			return divider + "\033[90mLoading error logs...\033[0m"
+		}
+		return divider + "\033[90mNo error log lines\033[0m"
+	}
+	return divider + strings.Join(lines, "\n")
+}
+
+// formatErrorLogLine colours an error log line by its level
+func formatErrorLogLine(entry nginx.ErrorLogEntry) string {
+	return levelColor(entry.Level) + truncate(entry.Raw, 130) + "\033[0m"
+}
+
+// LogEntryScheme returns the scheme a request was made over, from the
+// log_format when it records it or else from whether its site serves SSL
+func LogEntryScheme(m *model.Model, entry nginx.LogEntry) string {
+	fallback := "http"
+	for _, site := range m.Sites {
+		if site.Name == entry.Site && site.SSL {
+			fallback = "https"
+		}
+	}
+	return entry.Scheme(fallback)
+}
+
+// renderLogDetail renders every field of an access log entry, the raw line,
+// the error log lines written while it was handled and a curl command that
+// repeats it
+func renderLogDetail(m *model.Model, entry *nginx.LogEntry, width int) string {
+	title := "\033[1;36m▸ REQUEST DETAIL\033[0m   \033[90my copy curl · esc back\033[0m\n\n"
+	wrapWidth := max(width-16, 40)
+	field := func(name, value string) string {
+		lines := wrapText(valueOr(value, "-"), wrapWidth)
+		return fmt.Sprintf("  \033[90m%-12s\033[0m %s\n", name, strings.Join(lines, "\n"+strings.Repeat(" ", 15)))
+	}
+
+	if entry.ParseErr != nil {
+		return title + field("Log", entry.Log) + field("Format", entry.Format) +
+			field("Error", entry.ParseErr.Error()) + field("Raw", entry.Raw)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(title)
+
+	ago := time.Since(entry.Timestamp).Truncate(time.Second)
+	sb.WriteString(field("Time", fmt.Sprintf("%s (%s ago)", entry.Timestamp.Format("2006-01-02 15:04:05 -0700"), ago)))
+
+	client := entry.IP
+	if geo := nginx.LookupGeo(entry.IP); geo.Country != "" || geo.ASOrg != "" {
+		client += " · " + strings.TrimSpace(geo.Country+" "+geo.CountryName)
+		if as := geo.ASLabel(); as != "" {
+			client += " · " + as
+		}
+	}
+	client += " · " + nginx.ClassifyUserAgent(entry.UserAgent).String()
+	sb.WriteString(field("Client", client))
+	sb.WriteString(field("Request", entry.Method+" "+entry.Path))
+	sb.WriteString(field("Host", entry.Host))
+	sb.WriteString(field("Status", fmt.Sprintf("%d (%s)", entry.StatusCode, entry.StatusClass)))
+	sb.WriteString(field("Bytes", fmt.Sprintf("%d (%s)", entry.BytesSent, formatByteCount(int64(entry.BytesSent)))))
+	var timing []string
+	if entry.RequestTime > 0 {
+		timing = append(timing, "request "+formatLatency(entry.RequestTime))
+	}
+	if entry.UpstreamTime > 0 {
+		timing = append(timing, "upstream "+formatLatency(entry.UpstreamTime))
+	}
+	if len(timing) > 0 {
+		sb.WriteString(field("Timing", strings.Join(timing, " · ")))
+	}
+	sb.WriteString(field("Referer", entry.Referer))
+	sb.WriteString(field("User agent", entry.UserAgent))
+
+	site := entry.Site
+	for _, s := range m.Sites {
+		if s.Name == entry.Site {
+			state := "disabled"
+			if s.Enabled {
+				state = "enabled"
+			}
+			site += fmt.Sprintf(" (%s, port %s", state, valueOr(s.Port, "-"))
+			if s.SSL {
+				site += ", SSL"
+			}
+			site += ")"
+		}
+	}
+	sb.WriteString(field("Site", site))
+	sb.WriteString(field("Log", fmt.Sprintf("%s (log_format %s)", entry.Log, valueOr(entry.Format, "combined"))))
+
+	sb.WriteString("\n\033[1;36m▸ RAW LINE\033[0m\n")
+	for _, line := range wrapText(entry.Raw, max(width-4, 40)) {
+		sb.WriteString("  \033[90m" + line + "\033[0m\n")
+	}
+
+	errorEntries, _ := m.ErrorLogEntries.([]nginx.ErrorLogEntry)
+	related := entry.RelatedErrors(errorEntries)
+	sb.WriteString(fmt.Sprintf("\n\033[1;36m▸ ERROR LOG\033[0m \033[90m(%d)\033[0m\n", len(related)))
+	if len(related) == 0 {
+		sb.WriteString("  \033[90mNo error log lines from this client and URI while the request was handled\033[0m\n")
+	}
+	for _, e := range related {
+		sb.WriteString(fmt.Sprintf("  \033[90m%s\033[0m %s%-6s\033[0m %s\n",
+			e.Timestamp.Format("15:04:05"), levelColor(e.Level), e.Level, truncate(e.Message, max(width-22, 30))))
+	}
+
+	sb.WriteString("\n\033[1;36m▸ CURL\033[0m\n")
+	for _, line := range wrapText(entry.CurlCommand(LogEntryScheme(m, *entry)), max(width-4, 40)) {
+		sb.WriteString("  \033[97m" + line + "\033[0m\n")
+	}
+
+	// Every variable of the log_format, including the ones shown above
+	if len(entry.Vars) > 0 {
+		names := make([]string, 0, len(entry.Vars))
+		for name := range entry.Vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var vars []string
+		for _, name := range names {
+			vars = append(vars, fmt.Sprintf("$%s=%q", name, entry.Vars[name]))
+		}
+		sb.WriteString("\n\033[1;36m▸ LOG_FORMAT VARIABLES\033[0m\n")
+		for _, line := range wrapText(strings.Join(vars, " "), max(width-4, 40)) {
+			sb.WriteString("  \033[90m" + line + "\033[0m\n")
+		}
+	}
+	return sb.String()
+}
+
+// wrapText breaks text into lines of at most width characters
+func wrapText(text string, width int) []string {
+	runes := []rune(text)
+	var lines []string
+	for len(runes) > width {
+		lines = append(lines, string(runes[:width]))
+		runes = runes[width:]
+	}
+	return append(lines, string(runes))
+}
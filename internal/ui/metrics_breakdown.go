@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+)
+
+// peerStateColor is the ANSI colour of an upstream peer state
+func peerStateColor(state string) string {
+	switch state {
+	case "up":
+		return "32"
+	case "checking", "draining":
+		return "33"
+	}
+	return "31"
+}
+
+// errorShare renders the share of 5xx responses, red when there are any
+func errorShare(responses nginx.ResponseCounts) string {
+	total := responses.Total()
+	if total == 0 || responses[4] == 0 {
+		return fmt.Sprintf("\033[90m%6.1f%%\033[0m", 0.0)
+	}
+	return fmt.Sprintf("\033[31m%6.1f%%\033[0m", float64(responses[4])/float64(total)*100)
+}
+
+// renderServerZones renders the traffic of each server zone
+func renderServerZones(metrics, prev *nginx.Metrics) []string {
+	lines := []string{
+		"\033[1;36m▸ SERVER ZONES\033[0m",
+		fmt.Sprintf("  \033[1;90m%-32s %8s %12s %7s %7s %10s %10s\033[0m", "ZONE", "REQ/S", "REQUESTS", "4XX", "5XX", "RECEIVED", "SENT"),
+	}
+	if len(metrics.Zones) == 0 {
+		return append(lines, "  \033[90mNo server zones\033[0m")
+	}
+	rates := nginx.ZoneRates(metrics, prev)
+	for _, zone := range metrics.Zones {
+		rate := "-"
+		if r, ok := rates[zone.Name]; ok {
+			rate = fmt.Sprintf("%.1f", r)
+		}
+		clientErrors := 0.0
+		if total := zone.Responses.Total(); total > 0 {
+			clientErrors = float64(zone.Responses[3]) / float64(total) * 100
+		}
+		lines = append(lines, fmt.Sprintf("  \033[97m%-32s\033[0m %8s %12d %6.1f%% %s %10s %10s",
+			truncate(zone.Name, 32), rate, zone.Requests, clientErrors, errorShare(zone.Responses),
+			formatByteCount(zone.Received), formatByteCount(zone.Sent)))
+	}
+	return lines
+}
+
+// renderUpstreams renders each upstream group and the health of its peers
+func renderUpstreams(metrics *nginx.Metrics) []string {
+	lines := []string{
+		"\033[1;36m▸ UPSTREAMS\033[0m",
+		fmt.Sprintf("  \033[1;90m  %-30s %-13s %6s %12s %7s %9s %7s %s\033[0m", "PEER", "STATE", "WEIGHT", "REQUESTS", "5XX", "RESPONSE", "FAILS", "HEALTH CHECKS"),
+	}
+	if len(metrics.Upstreams) == 0 {
+		return append(lines, "  \033[90mNo upstream groups\033[0m")
+	}
+	for _, upstream := range metrics.Upstreams {
+		color := "32"
+		if up := upstream.Up(); up == 0 {
+			color = "31"
+		} else if up < len(upstream.Peers) {
+			color = "33"
+		}
+		lines = append(lines, fmt.Sprintf("  \033[1;97m%s\033[0m \033[%sm%d/%d up\033[0m", upstream.Name, color, upstream.Up(), len(upstream.Peers)))
+		for _, peer := range upstream.Peers {
+			state := peer.State
+			if peer.Backup {
+				state += " (b)"
+			}
+			health := "\033[90m-\033[0m"
+			if peer.HealthChecks > 0 {
+				health = fmt.Sprintf("%d run, %d failed", peer.HealthChecks, peer.HealthFails)
+			}
+			lines = append(lines, fmt.Sprintf("  \033[%sm●\033[0m %-30s \033[%sm%-13s\033[0m %6d %12d %s %9s %7d %s",
+				peerStateColor(peer.State), truncate(peer.Server, 30), peerStateColor(peer.State), state,
+				peer.Weight, peer.Requests, errorShare(peer.Responses),
+				peer.ResponseTime.Round(time.Millisecond), peer.Fails, health))
+		}
+	}
+	return lines
+}
+
+// renderCacheZones renders the hit ratio and size of each cache zone
+func renderCacheZones(metrics *nginx.Metrics) []string {
+	lines := []string{
+		"\033[1;36m▸ CACHES\033[0m",
+		fmt.Sprintf("  \033[1;90m%-32s %9s %12s %12s %21s\033[0m", "ZONE", "HIT RATIO", "HITS", "MISSES", "SIZE"),
+	}
+	if len(metrics.Caches) == 0 {
+		return append(lines, "  \033[90mNo cache zones\033[0m")
+	}
+	for _, cache := range metrics.Caches {
+		size := formatByteCount(cache.Size)
+		if cache.MaxSize > 0 {
+			size += " / " + formatByteCount(cache.MaxSize)
+		}
+		ratio := cache.HitRatio() * 100
+		color := "32"
+		if ratio < 50 {
+			color = "33"
+		}
+		lines = append(lines, fmt.Sprintf("  \033[97m%-32s\033[0m \033[%sm%8.1f%%\033[0m %12d %12d %21s",
+			truncate(cache.Name, 32), color, ratio,
+			cache.Hit+cache.Stale+cache.Updating+cache.Revalidated, cache.Miss+cache.Expired+cache.Bypass, size))
+	}
+	return lines
+}
+
+// RenderMetricsBreakdown renders the server zones, upstreams and caches of
+// the last metrics sample, when its source knows about them
+func (r *Renderer) RenderMetricsBreakdown(m *model.Model, width, height int) string {
+	metrics, _ := m.LastMetrics.(*nginx.Metrics)
+	if metrics == nil {
+		return "  \033[90mWaiting for the first metrics sample...\033[0m"
+	}
+	if metrics.Source != nginx.PlusAPISource && metrics.Source != nginx.VTSSource {
+		return "  \033[90mServer zones, upstreams and caches need nginx-module-vts (vhost_traffic_status_display)\n" +
+			"  or the NGINX Plus API (api) in a location ngxtui can reach; set metrics.vts_url or\n" +
+			"  metrics.plus_api_url in the config when it isn't found.\033[0m"
+	}
+
+	prev, _ := m.PrevMetrics.(*nginx.Metrics)
+	divider := "\033[90m" + strings.Repeat("─", max(min(width-2, 110), 0)) + "\033[0m"
+	var lines []string
+	lines = append(lines, renderServerZones(metrics, prev)...)
+	lines = append(lines, divider)
+	lines = append(lines, renderUpstreams(metrics)...)
+	lines = append(lines, divider)
+	lines = append(lines, renderCacheZones(metrics)...)
+	if len(lines) > height {
+		lines = append(lines[:max(height-1, 1)], "  \033[90m…\033[0m")
+	}
+	return strings.Join(lines, "\n")
+}
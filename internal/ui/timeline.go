@@ -0,0 +1,160 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NimbleMarkets/ntcharts/barchart"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+)
+
+// TimelineWindows are the spans the Logs tab timeline cycles through; 0
+// covers everything shown
+var TimelineWindows = []time.Duration{0, 5 * time.Minute, 15 * time.Minute, time.Hour, 6 * time.Hour, 24 * time.Hour}
+
+// timelineHeight is how many rows the timeline's bars take
+const timelineHeight = 6
+
+// timelineStyles colour the stacked status classes, 2xx at the bottom
+var timelineStyles = []lipgloss.Style{
+	lipgloss.NewStyle().Foreground(lipgloss.Color("2")),
+	lipgloss.NewStyle().Foreground(lipgloss.Color("6")),
+	lipgloss.NewStyle().Foreground(lipgloss.Color("3")),
+	lipgloss.NewStyle().Foreground(lipgloss.Color("1")),
+}
+
+// timelineBars returns how many buckets the timeline shows at a width
+func timelineBars(width int) int {
+	return max(width-4, 10)
+}
+
+// ShownTimeline returns the buckets of the Logs tab timeline, oldest first.
+// The unfiltered view uses the statistics, which cover the whole range; a
+// filter, a single site's log or a paused view count the listed entries.
+func ShownTimeline(m *model.Model, width int) []nginx.TimelineBucket {
+	query, _ := m.LogQuery.(*nginx.LogQuery)
+	sources, _ := m.LogSources.([]nginx.LogSource)
+	source := nginx.SelectedLogSource(sources, m.LogSource)
+	now := time.Now()
+	timeRange := nginx.SelectedTimeRange(m.LogRange, now)
+
+	var timeline *nginx.StatusTimeline
+	var first, last time.Time
+	if query.Empty() && len(source.Paths) == 0 && !m.LogPaused {
+		stats, _ := m.LogStats.(*nginx.LogStats)
+		if !timeRange.Live() {
+			stats, _ = m.RangeStats.(*nginx.LogStats)
+		}
+		if stats == nil {
+			return nil
+		}
+		timeline, first, last = stats.Timeline, stats.First, stats.Last
+		if stats.TotalRequests == 0 {
+			return nil
+		}
+	} else {
+		entries, _ := ShownLogEntries(m)
+		if len(entries) == 0 {
+			return nil
+		}
+		timeline = nginx.NewStatusTimeline()
+		for _, entry := range entries {
+			timeline.Add(entry.Timestamp, entry.StatusClass)
+		}
+		first, last = entries[0].Timestamp, entries[len(entries)-1].Timestamp
+	}
+
+	// The live view runs up to now; a paused one or a past range up to its end
+	until := now
+	switch {
+	case m.LogPaused:
+		until = last.Add(time.Second)
+	case !timeRange.Live() && !timeRange.Until.IsZero():
+		until = timeRange.Until
+	}
+	since := first
+	if window := TimelineWindows[m.TimelineWindow]; window > 0 {
+		since = until.Add(-window)
+	} else if !timeRange.Live() {
+		since = timeRange.Since
+	}
+	return timeline.Buckets(since, until, timelineBars(width))
+}
+
+// renderLogTimeline renders requests over time stacked by status class, with
+// the brushed range marked under the bars
+func renderLogTimeline(m *model.Model, width int) string {
+	window := "everything shown"
+	if w := TimelineWindows[m.TimelineWindow]; w > 0 {
+		window = "last " + formatSpan(w)
+	}
+	legend := "\033[32m■\033[0m 2xx \033[36m■\033[0m 3xx \033[33m■\033[0m 4xx \033[31m■\033[0m 5xx"
+	keys := "  \033[90m←→ move · space mark · enter filter the logs · z window · esc close\033[0m\n"
+
+	buckets := ShownTimeline(m, width)
+	if len(buckets) == 0 {
+		title := fmt.Sprintf("\033[1;36m▸ REQUESTS OVER TIME\033[0m \033[90m· %s\033[0m\n", window)
+		return title + "  \033[90mNo requests to chart yet\033[0m\n" + keys
+	}
+	step := formatSpan(buckets[0].End.Sub(buckets[0].Start))
+	title := fmt.Sprintf("\033[1;36m▸ REQUESTS OVER TIME\033[0m \033[90m· %s · one bar per %s ·\033[0m %s\n", window, step, legend)
+
+	chart := barchart.New(len(buckets), timelineHeight, barchart.WithNoAxis(), barchart.WithBarGap(0))
+	for _, bucket := range buckets {
+		values := make([]barchart.BarValue, len(bucket.Counts))
+		for i, count := range bucket.Counts {
+			values[i] = barchart.BarValue{Value: float64(count), Style: timelineStyles[i]}
+		}
+		chart.Push(barchart.BarData{Values: values})
+	}
+	chart.Draw()
+
+	// The brush: the marked range, or just the cursor's bucket
+	cursor := len(buckets) - 1 - min(m.TimelineCursor, len(buckets)-1)
+	lo, hi := cursor, cursor
+	if m.TimelineAnchor >= 0 {
+		anchor := len(buckets) - 1 - min(m.TimelineAnchor, len(buckets)-1)
+		lo, hi = min(cursor, anchor), max(cursor, anchor)
+	}
+	brush := strings.Repeat(" ", lo) + strings.Repeat("▔", hi-lo+1)
+
+	selected := nginx.TimelineBucket{Start: buckets[lo].Start, End: buckets[hi].End}
+	for _, bucket := range buckets[lo : hi+1] {
+		for i := range bucket.Counts {
+			selected.Counts[i] += bucket.Counts[i]
+		}
+	}
+	axis := buckets[0].Start.Format("Jan 2 15:04:05")
+	end := buckets[len(buckets)-1].End.Format("Jan 2 15:04:05")
+	axis += strings.Repeat(" ", max(len(buckets)-len(axis)-len(end), 1)) + end
+
+	summary := fmt.Sprintf("  \033[97m%s → %s\033[0m  %d requests: \033[32m%d\033[0m 2xx \033[36m%d\033[0m 3xx \033[33m%d\033[0m 4xx \033[31m%d\033[0m 5xx\n",
+		selected.Start.Format("15:04:05"), selected.End.Format("15:04:05"), selected.Total(),
+		selected.Counts[0], selected.Counts[1], selected.Counts[2], selected.Counts[3])
+
+	var sb strings.Builder
+	sb.WriteString(title)
+	for _, line := range strings.Split(chart.View(), "\n") {
+		sb.WriteString("  " + line + "\n")
+	}
+	sb.WriteString("  \033[1;36m" + brush + "\033[0m\n")
+	sb.WriteString("  \033[90m" + axis + "\033[0m\n")
+	sb.WriteString(summary)
+	sb.WriteString(keys)
+	return sb.String()
+}
+
+// formatSpan formats a whole number of hours, minutes or seconds, e.g. "15m"
+func formatSpan(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d >= time.Minute && d%time.Minute == 0:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%ds", int(d.Seconds()))
+}
@@ -0,0 +1,173 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+)
+
+// RenderTLSView renders the TLS grading of every SSL site
+func (r *Renderer) RenderTLSView(m *model.Model, width, height int) string {
+	title := "\033[1;36m🔏 TLS CONFIGURATION\033[0m\n"
+
+	if m.TLSReportsErr != nil {
+		return title + fmt.Sprintf("\n  \033[33m⚠ Unable to grade TLS configuration: %v\033[0m", m.TLSReportsErr)
+	}
+
+	reports, ok := m.TLSReports.([]nginx.TLSReport)
+	if !ok {
+		return title + "\n  \033[90mHandshaking with SSL listeners...\033[0m"
+	}
+	if len(reports) == 0 {
+		return title + "\n  \033[90mNo server blocks listen with ssl\033[0m"
+	}
+
+	// Summary line, one count per grade
+	counts := map[string]int{}
+	for _, report := range reports {
+		counts[report.Grade]++
+	}
+	summary := fmt.Sprintf("  \033[97m%d\033[0m SSL sites   %s %d modern   %s %d intermediate   %s %d old   %s %d none\n\n",
+		len(reports),
+		gradeColor("modern")+"●\033[0m", counts["modern"],
+		gradeColor("intermediate")+"●\033[0m", counts["intermediate"],
+		gradeColor("old")+"●\033[0m", counts["old"],
+		gradeColor("none")+"●\033[0m", counts["none"])
+
+	headers := fmt.Sprintf("  \033[1;90m%-22s %-26s %-22s %-13s %-30s %-4s %-4s %-4s\033[0m\n",
+		"SITE", "SERVER NAME", "LISTEN", "GRADE", "PROTOCOLS", "MOD", "INT", "OLD")
+	divider := "\033[90m" + strings.Repeat("─", min(width-2, 130)) + "\033[0m\n"
+
+	// Leave room for the detail panel below the table
+	tableRows := height - 24
+	if tableRows < 3 {
+		tableRows = 3
+	}
+	start := 0
+	if m.TLSCursor >= tableRows {
+		start = m.TLSCursor - tableRows + 1
+	}
+	end := min(len(reports), start+tableRows)
+
+	var rows []string
+	for i := start; i < end; i++ {
+		rows = append(rows, formatTLSRow(reports[i], i == m.TLSCursor))
+	}
+
+	detail := ""
+	if m.TLSCursor < len(reports) {
+		detail = "\n\n" + formatTLSDetail(reports[m.TLSCursor], width)
+	}
+
+	return title + summary + headers + divider + strings.Join(rows, "\n") + detail
+}
+
+// formatTLSRow formats a single SSL site as a table row
+func formatTLSRow(report nginx.TLSReport, selected bool) string {
+	cursor := "  "
+	if selected {
+		cursor = "\033[1;36m▸\033[0m "
+	}
+
+	protocols := strings.Join(report.Protocols, " ")
+	if report.HandshakeErr != nil {
+		protocols = "\033[33mno handshake\033[0m" + strings.Repeat(" ", 18)
+	} else {
+		protocols = fmt.Sprintf("%-30s", truncate(protocols, 30))
+	}
+
+	marks := make([]string, 0, len(report.Scores))
+	for _, score := range report.Scores {
+		marks = append(marks, checkMark(score.Passed, 4))
+	}
+
+	return fmt.Sprintf("%s\033[97m%-22s\033[0m %-26s \033[90m%-22s\033[0m %s%-13s\033[0m %s %s",
+		cursor,
+		truncate(report.Site, 22),
+		truncate(valueOr(strings.Join(report.ServerNames, " "), "-"), 26),
+		truncate(report.Address, 22),
+		gradeColor(report.Grade),
+		report.Grade,
+		protocols,
+		strings.Join(marks, " "),
+	)
+}
+
+// formatTLSDetail formats the findings for the selected SSL site
+func formatTLSDetail(report nginx.TLSReport, width int) string {
+	lines := []string{
+		"\033[1;36m▸ DETAILS\033[0m",
+		fmt.Sprintf("  File        : %s", report.File),
+		fmt.Sprintf("  Handshake   : %s (SNI %s)", report.Address, valueOr(report.SNI, "none")),
+	}
+	if report.HandshakeErr != nil {
+		lines = append(lines,
+			fmt.Sprintf("  \033[33m⚠ Handshake failed: %v\033[0m", report.HandshakeErr),
+			"  \033[90mScores below use the configured directives only\033[0m",
+		)
+	} else {
+		lines = append(lines,
+			fmt.Sprintf("  Protocols   : %s", strings.Join(report.Protocols, " ")),
+			fmt.Sprintf("  Negotiated  : %s", valueOr(report.NegotiatedCipher, "-")),
+			fmt.Sprintf("  Ciphers     : %s", truncate(valueOr(strings.Join(report.Ciphers, " "), "-"), max(width-18, 20))),
+		)
+	}
+	lines = append(lines,
+		fmt.Sprintf("  Configured  : %s / %s", strings.Join(report.ConfiguredProtocols, " "), truncate(report.ConfiguredCiphers, max(width-50, 20))),
+		fmt.Sprintf("  Certificate : %s", valueOr(strings.Join(report.KeyTypes, ", "), "-")),
+		fmt.Sprintf("  OCSP        : stapling %s, response %s", onOff(report.StaplingEnabled), yesNo(report.OCSPStapled)),
+		fmt.Sprintf("  HSTS        : %s", valueOr(report.HSTS, "-")),
+		fmt.Sprintf("  Tickets     : %s", onOff(report.SessionTickets)),
+	)
+
+	// Only list what keeps each profile from passing
+	for _, score := range report.Scores {
+		if score.Passed {
+			lines = append(lines, fmt.Sprintf("  \033[32m✓ %s\033[0m", score.Profile))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  \033[31m✗ %s\033[0m", score.Profile))
+		for _, check := range score.Checks {
+			switch check.Status {
+			case nginx.TLSCheckFail:
+				lines = append(lines, fmt.Sprintf("      \033[31m✗\033[0m %-16s %s", check.Name, check.Detail))
+			case nginx.TLSCheckUnknown:
+				lines = append(lines, fmt.Sprintf("      \033[33m?\033[0m %-16s %s", check.Name, check.Detail))
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// gradeColor returns the ANSI colour for a TLS grade
+func gradeColor(grade string) string {
+	switch grade {
+	case "modern":
+		return "\033[32m"
+	case "intermediate":
+		return "\033[36m"
+	case "old":
+		return "\033[33m"
+	default:
+		return "\033[31m"
+	}
+}
+
+// onOff renders a boolean the way NGINX directives do
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// yesNo renders a boolean as yes or no
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,171 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NimbleMarkets/ntcharts/sparkline"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/aitmiloud/ngxtui/internal/model"
+	"github.com/aitmiloud/ngxtui/internal/nginx"
+	"github.com/aitmiloud/ngxtui/internal/styles"
+)
+
+// trafficSparkWidth is the width of the per-row sparklines, one column per second
+const trafficSparkWidth = 30
+
+// RenderTrafficView renders the busiest keys of the selected dimension: live
+// request rates over the last minute, or totals over the selected time range
+func (r *Renderer) RenderTrafficView(m *model.Model, width, height int) string {
+	timeRange := nginx.SelectedTimeRange(m.LogRange, time.Now())
+	heading := "🔝 TRAFFIC BREAKDOWN"
+	if timeRange.Live() {
+		heading = "🔝 LIVE TOP"
+	}
+	title := fmt.Sprintf("\033[1;36m%s\033[0m \033[90m· %s\033[0m\n", heading, timeRange.Label)
+	if m.LogRangePicking {
+		return title + renderLogRangePicker(m.LogRangeCursor)
+	}
+	dimension := nginx.TrafficDimensions[m.TrafficDimension]
+	order := nginx.TrafficSorts[m.TrafficSort]
+	title += renderTrafficDimensions(dimension, order) + "\n\n"
+
+	// Leave room for the title, headers and footer
+	tableRows := max(height-8, 3)
+	start := 0
+	if m.TrafficCursor >= tableRows {
+		start = m.TrafficCursor - tableRows + 1
+	}
+
+	if timeRange.Live() {
+		return title + renderLiveTraffic(m, dimension, order, width, start, tableRows)
+	}
+
+	stats, _ := m.RangeStats.(*nginx.LogStats)
+	switch {
+	case m.RangeStatsPending:
+		return title + "  \033[90mReading rotated and compressed logs...\033[0m"
+	case m.RangeStatsErr != nil:
+		return title + fmt.Sprintf("  \033[33m⚠ Unable to read logs: %v\033[0m", m.RangeStatsErr)
+	case stats == nil || stats.TotalRequests == 0:
+		return title + "  \033[90mNo requests in this range\033[0m"
+	}
+
+	rows := stats.Top(dimension, order, 0)
+	keyWidth := max(min(width-68, 90), 20)
+	header := fmt.Sprintf("  \033[1;90m%4s  %-*s %9s %7s %10s %6s %6s  %s\033[0m\n", "#", keyWidth, strings.ToUpper(dimension.String()), "REQUESTS", "SHARE", "BYTES", "4XX", "5XX", "")
+	divider := "\033[90m" + strings.Repeat("─", min(width-2, keyWidth+70)) + "\033[0m\n"
+
+	var lines []string
+	for i := start; i < min(len(rows), start+tableRows); i++ {
+		row := rows[i]
+		share := percentOf(row.Requests, stats.TotalRequests)
+		lines = append(lines, fmt.Sprintf("%s%4d  %-*s \033[1;97m%9d\033[0m %6.1f%% %10s %s %s  \033[36m%s\033[0m",
+			trafficCursor(i == m.TrafficCursor), i+1,
+			keyWidth, trafficKey(dimension, row.Key, keyWidth),
+			row.Requests, share, formatByteCount(row.Bytes),
+			errorCount(row.ClientErrors, "\033[33m"), errorCount(row.ServerErrors, "\033[31m"),
+			strings.Repeat("█", int(share/5+0.5))))
+	}
+
+	footer := fmt.Sprintf("\n\n  \033[90m%d distinct %s over %d requests, %s sent · enter: show these requests in Logs\033[0m",
+		len(rows), strings.ToLower(dimension.String()), stats.TotalRequests, formatByteCount(stats.TotalBytes))
+	return title + header + divider + strings.Join(lines, "\n") + footer
+}
+
+// renderLiveTraffic renders request rates per key over the last minute of
+// tailed requests, with a sparkline of each key's requests per second
+func renderLiveTraffic(m *model.Model, dimension nginx.TrafficDimension, order nginx.TrafficSort, width, start, tableRows int) string {
+	live, _ := m.LiveTraffic.(*nginx.LiveTraffic)
+	if live == nil {
+		return "  \033[90mLoading access logs...\033[0m"
+	}
+	now := time.Now()
+	total := live.Total(now)
+	summary := fmt.Sprintf("  \033[1;97m%.1f\033[0m req/s   \033[1;97m%s\033[0m/s   \033[33m%d\033[0m 4xx   \033[31m%d\033[0m 5xx   %s\n\n",
+		total.Rate, formatByteCount(total.Bytes/int64(nginx.LiveTrafficWindow.Seconds())),
+		total.ClientErrors, total.ServerErrors, renderTrafficSpark(total.Spark, styles.AccentPrimary))
+	if total.Requests == 0 {
+		return summary + "  \033[90mNo requests in the last minute\033[0m"
+	}
+
+	rows := live.Top(dimension, order, 0, now)
+	keyWidth := max(min(width-74-trafficSparkWidth, 80), 20)
+	header := fmt.Sprintf("  \033[1;90m%4s  %-*s %8s %7s %10s %6s %6s  %-*s\033[0m\n", "#", keyWidth, strings.ToUpper(dimension.String()), "REQ/S", "SHARE", "BYTES/S", "4XX", "5XX", trafficSparkWidth, "LAST 30S")
+	divider := "\033[90m" + strings.Repeat("─", min(width-2, keyWidth+74+trafficSparkWidth)) + "\033[0m\n"
+
+	var lines []string
+	for i := start; i < min(len(rows), start+tableRows); i++ {
+		row := rows[i]
+		lines = append(lines, fmt.Sprintf("%s%4d  %-*s \033[1;97m%8.2f\033[0m %6.1f%% %10s %s %s  %s",
+			trafficCursor(i == m.TrafficCursor), i+1,
+			keyWidth, trafficKey(dimension, row.Key, keyWidth),
+			row.Rate, percentOf(row.Requests, total.Requests),
+			formatByteCount(row.Bytes/int64(nginx.LiveTrafficWindow.Seconds())),
+			errorCount(row.ClientErrors, "\033[33m"), errorCount(row.ServerErrors, "\033[31m"),
+			renderTrafficSpark(row.Spark, styles.AccentInfo)))
+	}
+
+	footer := fmt.Sprintf("\n\n  \033[90m%d distinct %s in the last minute · enter: show these requests in Logs\033[0m",
+		len(rows), strings.ToLower(dimension.String()))
+	return summary + header + divider + strings.Join(lines, "\n") + footer
+}
+
+// trafficKey returns a row's key for display, with the country and
+// autonomous system of client IPs when a GeoIP database is loaded
+func trafficKey(dimension nginx.TrafficDimension, key string, width int) string {
+	if dimension == nginx.ClientIPs && nginx.GeoIPEnabled() {
+		geo := nginx.LookupGeo(key)
+		key = fmt.Sprintf("%-15s %-2s %s", key, valueOr(geo.Country, "--"), geo.ASLabel())
+	}
+	return truncate(valueOr(strings.TrimSpace(key), "-"), width)
+}
+
+// renderTrafficSpark renders the last seconds of a per-second series on one line
+func renderTrafficSpark(data []float64, color lipgloss.Color) string {
+	sl := sparkline.New(trafficSparkWidth, 1, sparkline.WithStyle(lipgloss.NewStyle().Foreground(color)))
+	sl.PushAll(data)
+	sl.Draw()
+	return sl.View()
+}
+
+// renderTrafficDimensions renders the dimensions, the selected one
+// highlighted, and the sort order
+func renderTrafficDimensions(selected nginx.TrafficDimension, order nginx.TrafficSort) string {
+	var parts []string
+	for _, d := range nginx.TrafficDimensions {
+		if d == selected {
+			parts = append(parts, "\033[1;36m"+d.String()+"\033[0m")
+		} else {
+			parts = append(parts, "\033[90m"+d.String()+"\033[0m")
+		}
+	}
+	return "  " + strings.Join(parts, " \033[90m│\033[0m ") +
+		fmt.Sprintf("   \033[90m(d to switch) · sorted by\033[0m \033[97m%s\033[0m \033[90m(o)\033[0m", order)
+}
+
+// trafficCursor marks the selected row
+func trafficCursor(selected bool) string {
+	if selected {
+		return "\033[1;36m▸\033[0m "
+	}
+	return "  "
+}
+
+// errorCount right-aligns an error count, coloured when there are any
+func errorCount(count int, color string) string {
+	if count == 0 {
+		return fmt.Sprintf("\033[90m%6d\033[0m", 0)
+	}
+	return fmt.Sprintf("%s%6d\033[0m", color, count)
+}
+
+// percentOf returns part as a percentage of total
+func percentOf(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -46,8 +47,13 @@ func (r *Renderer) RenderTabs(m *model.Model, width int) string {
 	}{
 		{"🌐", "Sites"},
 		{"📋", "Logs"},
+		{"🚨", "Errors"},
 		{"📊", "Stats"},
+		{"🔝", "Traffic"},
+		{"⛔", "Bans"},
 		{"📈", "Metrics"},
+		{"🔒", "Certificates"},
+		{"🔏", "TLS"},
 	}
 
 	var renderedTabs []string
@@ -71,6 +77,9 @@ func (r *Renderer) RenderTabs(m *model.Model, width int) string {
 	}
 
 	tabBar := strings.Join(renderedTabs, "")
+	if m.NotificationsUnread > 0 {
+		tabBar += fmt.Sprintf("   \033[1;31m🔔 %d\033[0m", m.NotificationsUnread)
+	}
 
 	// Add a divider below tabs that spans the full terminal width
 	divider := "\033[90m" + strings.Repeat("─", width) + "\033[0m"
@@ -84,6 +93,25 @@ func (r *Renderer) RenderSitesTable(m *model.Model, width, height int) string {
 	return r.RenderSitesTableStickers(m, width, height)
 }
 
+// siteActions are the entries of the site action menu, in the order
+// executeAction handles them
+var siteActions = [...]struct {
+	icon  string
+	text  string
+	color lipgloss.Color
+}{
+	{"✓", "Enable Site", styles.AccentSuccess},
+	{"✗", "Disable Site", styles.AccentDanger},
+	{"🔍", "Test Configuration", styles.AccentInfo},
+	{"🔄", "Reload NGINX", styles.AccentWarning},
+	{"📋", "View Logs", styles.AccentPrimary},
+	{"🔐", "Issue Certificate (ACME)", styles.AccentSecondary},
+	{"←", "Back", styles.TextMuted},
+}
+
+// SiteActionCount is the number of entries in the site action menu
+const SiteActionCount = len(siteActions)
+
 // RenderActionMenu renders the action menu for a selected site
 func (r *Renderer) RenderActionMenu(m *model.Model) string {
 	if m.Selected < 0 || m.Selected >= len(m.Sites) {
@@ -102,22 +130,8 @@ func (r *Renderer) RenderActionMenu(m *model.Model) string {
 		statusBadge,
 	)
 
-	// Actions with icons
-	actions := []struct {
-		icon  string
-		text  string
-		color lipgloss.Color
-	}{
-		{"✓", "Enable Site", styles.AccentSuccess},
-		{"✗", "Disable Site", styles.AccentDanger},
-		{"🔍", "Test Configuration", styles.AccentInfo},
-		{"🔄", "Reload NGINX", styles.AccentWarning},
-		{"📋", "View Logs", styles.AccentPrimary},
-		{"←", "Back", styles.TextMuted},
-	}
-
 	var items []string
-	for i, action := range actions {
+	for i, action := range siteActions {
 		actionText := fmt.Sprintf("%s  %s", action.icon, action.text)
 		if i == m.Cursor {
 			items = append(items, styles.SelectedAction.Render("▸ "+actionText))
@@ -148,52 +162,150 @@ func (r *Renderer) RenderStatusBadge(enabled bool) string {
 }
 
 // RenderLogsView renders the logs view with REAL NGINX access logs
-func (r *Renderer) RenderLogsView(m *model.Model) string {
-	title := fmt.Sprintf("\033[1;36m📋 REAL-TIME ACCESS LOGS\033[0m\n")
+func (r *Renderer) RenderLogsView(m *model.Model, width, height int) string {
+	sources, _ := m.LogSources.([]nginx.LogSource)
+	source := nginx.SelectedLogSource(sources, m.LogSource)
+	title := renderLogSourceTitle(source)
+
+	if m.LogSourcePicking {
+		return title + renderLogSourcePicker(sources, m.LogSourceCursor)
+	}
+	if m.LogRangePicking {
+		return title + renderLogRangePicker(m.LogRangeCursor)
+	}
+	if m.ExportPicking {
+		return title + renderExportPicker(m.ExportCursor, "the entries matching the filter and time range")
+	}
+	if source.Kind == nginx.ErrorLogKind {
+		return title + renderErrorLogLines(m, source)
+	}
+	if entry, ok := m.LogDetail.(*nginx.LogEntry); ok {
+		return renderLogDetail(m, entry, width)
+	}
 
 	// Legend for status codes with icons
-	legend := fmt.Sprintf("  \033[32m✓\033[0m 2xx Success   \033[36m↻\033[0m 3xx Redirect   \033[33m⚠\033[0m 4xx Client Error   \033[31m✗\033[0m 5xx Server Error\n\n")
+	legend := fmt.Sprintf("  \033[32m✓\033[0m 2xx Success   \033[36m↻\033[0m 3xx Redirect   \033[33m⚠\033[0m 4xx Client Error   \033[31m✗\033[0m 5xx Server Error\n")
 
 	// Column headers
-	headers := fmt.Sprintf("  \033[1;90m%-8s %-15s %-6s %-35s %-4s %-4s %-12s %-10s\033[0m\n",
-		"TIME", "IP", "METHOD", "PATH", "CODE", "SIZE", "CLIENT", "REFERER")
+	ipHeader := fmt.Sprintf("%-15s", "IP")
+	if nginx.GeoIPEnabled() {
+		ipHeader += " CC"
+	}
+	headers := fmt.Sprintf("    \033[1;90m%-8s %s %-6s %-35s %-4s %-4s %-12s %-10s\033[0m\n",
+		"TIME", ipHeader, "METHOD", "PATH", "CODE", "SIZE", "CLIENT", "REFERER")
 
 	divider := "\033[90m" + strings.Repeat("─", 130) + "\033[0m\n"
 
+	// Entries are delivered by the background tailer
+	logEntries, filtered := ShownLogEntries(m)
+	query, _ := m.LogQuery.(*nginx.LogQuery)
+
+	// Filter bar, shown while typing or while a query is applied
+	filterBar := renderLogFilterBar(m, len(logEntries))
+
+	// Timeline of the shown requests, while it is open
+	timeline := ""
+	if m.LogTimeline {
+		timeline = renderLogTimeline(m, width) + "\n"
+	}
+
 	// Calculate how many log entries can fit on screen
-	// Account for: title (1), legend (2), headers (1), divider (1), menu bar (3), padding (2)
-	headerLines := 10
-	availableLines := m.Height - headerLines
-	if availableLines < 10 {
-		availableLines = 10 // Minimum 10 lines
+	// Account for: title (1), legend (1), scroll state (1), headers (1), divider (1)
+	headerLines := 5
+	if filterBar != "" {
+		headerLines++
+	}
+	headerLines += strings.Count(timeline, "\n")
+	availableLines := height - headerLines
+	if availableLines < 5 {
+		availableLines = 5 // Minimum 5 lines
 	}
 	if availableLines > 100 {
 		availableLines = 100 // Maximum 100 lines to avoid performance issues
 	}
 
-	// Get real access logs from NGINX
-	nginxService := nginx.New()
-	logEntries, err := nginxService.GetAccessLogs(availableLines)
+	// The window ends at the newest entry unless the cursor is above it
+	cursor := min(m.LogCursor, max(len(logEntries)-1, 0))
+	selected := len(logEntries) - 1 - cursor
+	end := len(logEntries)
+	if cursor >= availableLines {
+		end = selected + 1
+	}
+	start := max(end-availableLines, 0)
 
 	var logs []string
-	if err != nil {
-		logs = []string{fmt.Sprintf("\033[33m⚠ Unable to read access logs: %v\033[0m", err)}
+	if len(logEntries) == 0 && m.LogErr != nil {
+		logs = []string{fmt.Sprintf("\033[33m⚠ Unable to read access logs: %v\033[0m", m.LogErr)}
+	} else if len(logEntries) == 0 && m.LogTailer == nil {
+		logs = []string{"\033[90mLoading access logs...\033[0m"}
+	} else if len(logEntries) == 0 && m.LogSearchPending {
+		logs = []string{"\033[90mSearching log files...\033[0m"}
+	} else if len(logEntries) == 0 && filtered {
+		logs = []string{"\033[90mNo entries match the filter and time range\033[0m"}
 	} else if len(logEntries) == 0 {
 		logs = []string{"\033[90mNo access logs available\033[0m"}
 	} else {
 		// Format each log entry
-		for _, entry := range logEntries {
-			logs = append(logs, nginx.FormatLogEntry(entry))
+		for i := start; i < end; i++ {
+			logs = append(logs, trafficCursor(i == selected)+nginx.FormatLogEntryMatch(logEntries[i], query))
 		}
 	}
 
 	content := strings.Join(logs, "\n")
 
-	return title + legend + headers + divider + content
+	scrollState := renderLogScrollState(m, selected, len(logEntries)) + "\n"
+
+	return title + filterBar + timeline + legend + scrollState + headers + divider + content
+}
+
+// renderLogFilterBar renders the filter input or the applied query and time
+// range with the match count
+func renderLogFilterBar(m *model.Model, matchCount int) string {
+	query, _ := m.LogQuery.(*nginx.LogQuery)
+	timeRange := nginx.SelectedTimeRange(m.LogRange, time.Now())
+	if !m.LogFiltering && query.Empty() && timeRange.Live() {
+		return ""
+	}
+
+	var bar string
+	switch {
+	case m.LogFiltering:
+		bar = "  " + m.LogFilterInput.View()
+	case !query.Empty():
+		bar = fmt.Sprintf("  \033[36m/\033[0m \033[97m%s\033[0m", query.Expr)
+	}
+	if !timeRange.Live() {
+		bar += fmt.Sprintf("  \033[36m⏱\033[0m \033[97m%s\033[0m", timeRange.Label)
+	}
+
+	if !query.Empty() || !timeRange.Live() {
+		count := fmt.Sprintf("%d matches", matchCount)
+		if matchCount == 1 {
+			count = "1 match"
+		}
+		bar += fmt.Sprintf("   \033[1;33m%s\033[0m", count)
+		switch {
+		case m.LogSearchPending:
+			bar += " \033[90m(searching log files...)\033[0m"
+		case m.LogSearchErr != nil:
+			bar += fmt.Sprintf(" \033[33m(live window only: %v)\033[0m", m.LogSearchErr)
+		}
+	}
+	if m.LogQueryErr != nil {
+		bar += fmt.Sprintf("   \033[31m✗ %v\033[0m", m.LogQueryErr)
+	}
+	return bar + "\n"
 }
 
 // RenderStatsView renders the statistics view with stunning modern design
 func (r *Renderer) RenderStatsView(m *model.Model, width int) string {
+	if m.LogRangePicking {
+		return "\033[1;36m📊 TRAFFIC TIME RANGE\033[0m\n" + renderLogRangePicker(m.LogRangeCursor)
+	}
+	if m.ExportPicking {
+		return "\033[1;36m📊 EXPORT STATISTICS\033[0m\n" + renderExportPicker(m.ExportCursor, "the statistics over the selected time range")
+	}
+
 	totalSites := len(m.Sites)
 	enabledSites := 0
 	disabledSites := 0
@@ -224,10 +336,10 @@ func (r *Renderer) RenderStatsView(m *model.Model, width int) string {
 	distBar := r.RenderDistributionBar(enabledSites, disabledSites, totalSites, width-10)
 
 	// Performance metrics section
-	perfSection := r.RenderPerformanceMetrics()
+	perfSection := r.RenderPerformanceMetrics(m)
 
 	// System health indicators
-	healthSection := r.RenderHealthIndicators()
+	healthSection := r.RenderHealthIndicators(m)
 
 	return lipgloss.JoinVertical(lipgloss.Left,
 		cardsRow,
@@ -236,9 +348,15 @@ func (r *Renderer) RenderStatsView(m *model.Model, width int) string {
 		distBar,
 		"",
 		"",
-		perfSection,
+		lipgloss.JoinHorizontal(lipgloss.Top, perfSection, "        ", r.RenderTrafficSummary(m)),
 		"",
-		healthSection,
+		r.RenderAnomalySummary(m),
+		"",
+		lipgloss.JoinHorizontal(lipgloss.Top, healthSection, "        ", r.RenderLatencySummary(m)),
+		"",
+		r.RenderLatencyBreakdown(m),
+		"",
+		r.RenderCountryBreakdown(m),
 	)
 }
 
@@ -308,28 +426,39 @@ func (r *Renderer) RenderDistributionBar(enabled, disabled, total int, width int
 }
 
 // RenderPerformanceMetrics renders REAL performance indicators
-func (r *Renderer) RenderPerformanceMetrics() string {
+func (r *Renderer) RenderPerformanceMetrics(m *model.Model) string {
 	title := fmt.Sprintf("\033[1;36m▸ REAL-TIME PERFORMANCE\033[0m\n")
 
-	// Get real stats from NGINX
-	nginxService := nginx.New()
-	stats, err := nginxService.GetStats()
+	// Stats are collected in the background while the tab is visible
+	stats, ok := m.Stats.(*nginx.Stats)
 
 	var metrics []string
-	if err != nil {
-		metrics = []string{fmt.Sprintf("  \033[33m⚠ Unable to fetch stats: %v\033[0m", err)}
+	if m.StatsErr != nil {
+		metrics = []string{fmt.Sprintf("  \033[33m⚠ Unable to fetch stats: %v\033[0m", m.StatsErr)}
+	} else if !ok {
+		metrics = []string{"  \033[90mCollecting stats...\033[0m"}
 	} else {
-		// Get log stats for success rate
-		logStats, _ := nginxService.GetLogStats()
+		// Success rate from the tailed log entries
+		logStats, _ := m.LogStats.(*nginx.LogStats)
 		successRate := 0.0
 		if logStats != nil && logStats.TotalRequests > 0 {
 			successCount := logStats.StatusCounts["2xx"] + logStats.StatusCounts["3xx"]
 			successRate = float64(successCount) / float64(logStats.TotalRequests) * 100
 		}
 
+		// Request rate over the last minute of tailed requests
+		live, _ := m.LiveTraffic.(*nginx.LiveTraffic)
+		requestRate := live.Rate(time.Now())
+
+		// Connection states when a status page can be polled
+		connections := fmt.Sprintf("\033[1;97m%d\033[0m connections", stats.ActiveConnections)
+		if status := stats.Connections; status != nil {
+			connections += fmt.Sprintf(" \033[90m(%s)\033[0m", connectionStates(status, stats.Source, ", "))
+		}
+
 		metrics = []string{
-			fmt.Sprintf("  \033[32m●\033[0m Request Rate    : \033[1;97m%.1f\033[0m req/s", stats.RequestRate),
-			fmt.Sprintf("  \033[32m●\033[0m Active Conn.    : \033[1;97m%d\033[0m connections", stats.ActiveConnections),
+			fmt.Sprintf("  \033[32m●\033[0m Request Rate    : \033[1;97m%.1f\033[0m req/s", requestRate),
+			fmt.Sprintf("  \033[32m●\033[0m Active Conn.    : %s", connections),
 			fmt.Sprintf("  \033[32m●\033[0m Worker Processes: \033[1;97m%d\033[0m workers", stats.WorkerProcesses),
 			fmt.Sprintf("  \033[32m●\033[0m Success Rate    : \033[1;97m%.1f%%\033[0m", successRate),
 			fmt.Sprintf("  \033[32m●\033[0m Uptime          : \033[1;97m%s\033[0m", formatDuration(stats.Uptime)),
@@ -339,6 +468,124 @@ func (r *Renderer) RenderPerformanceMetrics() string {
 	return title + strings.Join(metrics, "\n")
 }
 
+// RenderTrafficSummary renders access log statistics over the selected time
+// range, or over the live window
+func (r *Renderer) RenderTrafficSummary(m *model.Model) string {
+	timeRange := nginx.SelectedTimeRange(m.LogRange, time.Now())
+	title := fmt.Sprintf("\033[1;36m▸ TRAFFIC\033[0m \033[90m· %s (t to change)\033[0m\n", timeRange.Label)
+
+	stats, _ := m.LogStats.(*nginx.LogStats)
+	if !timeRange.Live() {
+		stats, _ = m.RangeStats.(*nginx.LogStats)
+		switch {
+		case m.RangeStatsPending:
+			return title + "  \033[90mReading rotated and compressed logs...\033[0m"
+		case m.RangeStatsErr != nil:
+			return title + fmt.Sprintf("  \033[33m⚠ Unable to read logs: %v\033[0m", m.RangeStatsErr)
+		}
+	}
+	if stats == nil || stats.TotalRequests == 0 {
+		return title + "  \033[90mNo requests in this range\033[0m"
+	}
+
+	// Most requested path
+	topPath, topCount := "", 0
+	if top := stats.Top(nginx.RequestPaths, nginx.ByRequests, 1); len(top) > 0 {
+		topPath, topCount = top[0].Key, top[0].Requests
+	}
+
+	lines := []string{
+		fmt.Sprintf("  \033[36m●\033[0m Requests        : \033[1;97m%d\033[0m", stats.TotalRequests),
+		fmt.Sprintf("  \033[36m●\033[0m Unique IPs      : \033[1;97m%d\033[0m", stats.UniqueIPs),
+		fmt.Sprintf("  \033[36m●\033[0m Status          : \033[32m%d\033[0m 2xx  \033[36m%d\033[0m 3xx  \033[33m%d\033[0m 4xx  \033[31m%d\033[0m 5xx",
+			stats.StatusCounts["2xx"], stats.StatusCounts["3xx"], stats.StatusCounts["4xx"], stats.StatusCounts["5xx"]),
+		fmt.Sprintf("  \033[36m●\033[0m Transferred     : \033[1;97m%s\033[0m", formatByteCount(stats.TotalBytes)),
+		fmt.Sprintf("  \033[36m●\033[0m Top Path        : \033[1;97m%s\033[0m (%d)", truncate(topPath, 30), topCount),
+		fmt.Sprintf("  \033[36m●\033[0m Humans / Bots   : \033[1;97m%.0f%%\033[0m / \033[1;97m%.0f%%\033[0m",
+			percentOf(stats.ClientKinds[nginx.HumanClient], stats.TotalRequests), percentOf(stats.BotRequests(), stats.TotalRequests)),
+	}
+	if bots := renderBotKinds(stats); bots != "" {
+		lines = append(lines, "  \033[90m  "+bots+"\033[0m")
+	}
+	if stats.UnparsedLines > 0 {
+		lines = append(lines, fmt.Sprintf("  \033[90m● Unparsed lines  : %d\033[0m", stats.UnparsedLines))
+	}
+	return title + strings.Join(lines, "\n")
+}
+
+// countryBreakdownRows is how many countries the Stats tab lists
+const countryBreakdownRows = 8
+
+// RenderCountryBreakdown renders the countries sending the most requests,
+// when a GeoIP database is loaded
+func (r *Renderer) RenderCountryBreakdown(m *model.Model) string {
+	timeRange := nginx.SelectedTimeRange(m.LogRange, time.Now())
+	stats, _ := m.LogStats.(*nginx.LogStats)
+	if !timeRange.Live() {
+		stats, _ = m.RangeStats.(*nginx.LogStats)
+		if m.RangeStatsPending {
+			stats = nil
+		}
+	}
+	if stats == nil || len(stats.Countries) == 0 {
+		return ""
+	}
+
+	type countryCount struct {
+		code     string
+		requests int
+	}
+	var countries []countryCount
+	for code, requests := range stats.Countries {
+		countries = append(countries, countryCount{code, requests})
+	}
+	sort.Slice(countries, func(i, j int) bool {
+		if countries[i].requests != countries[j].requests {
+			return countries[i].requests > countries[j].requests
+		}
+		return countries[i].code < countries[j].code
+	})
+
+	title := fmt.Sprintf("\033[1;36m▸ COUNTRIES\033[0m \033[90m· %d seen, %s\033[0m\n", len(countries), timeRange.Label)
+	var lines []string
+	for _, c := range countries[:min(len(countries), countryBreakdownRows)] {
+		share := percentOf(c.requests, stats.TotalRequests)
+		lines = append(lines, fmt.Sprintf("  \033[1;97m%-2s\033[0m %8d %6.1f%%  \033[36m%s\033[0m",
+			valueOr(c.code, "--"), c.requests, share, strings.Repeat("█", int(share/4+0.5))))
+	}
+	return title + strings.Join(lines, "\n")
+}
+
+// renderBotKinds lists the requests of each kind of bot, busiest first
+func renderBotKinds(stats *nginx.LogStats) string {
+	var kinds []nginx.ClientKind
+	for _, kind := range nginx.ClientKinds {
+		if kind.IsBot() && stats.ClientKinds[kind] > 0 {
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.SliceStable(kinds, func(i, j int) bool { return stats.ClientKinds[kinds[i]] > stats.ClientKinds[kinds[j]] })
+
+	var parts []string
+	for _, kind := range kinds {
+		parts = append(parts, fmt.Sprintf("%s %d", kind, stats.ClientKinds[kind]))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// formatByteCount formats a byte total into human-readable format
+func formatByteCount(bytes int64) string {
+	switch {
+	case bytes >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(bytes)/(1<<30))
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", bytes)
+}
+
 // formatDuration formats a duration into human-readable format
 func formatDuration(d time.Duration) string {
 	days := int(d.Hours() / 24)
@@ -354,15 +601,18 @@ func formatDuration(d time.Duration) string {
 }
 
 // RenderHealthIndicators renders REAL system health status
-func (r *Renderer) RenderHealthIndicators() string {
+func (r *Renderer) RenderHealthIndicators(m *model.Model) string {
 	title := fmt.Sprintf("\033[1;36m▸ SYSTEM HEALTH\033[0m\n")
 
-	nginxService := nginx.New()
+	health, ok := m.Health.(*nginx.Health)
+	if !ok {
+		return title + "  \033[90mCollecting health checks...\033[0m"
+	}
 
 	// Check NGINX service
 	nginxStatus := "\033[32m✓\033[0m"
 	nginxMsg := "\033[1;32mRunning\033[0m"
-	if err := nginxService.TestConfig(); err != nil {
+	if health.ConfigErr != nil {
 		nginxStatus = "\033[31m✗\033[0m"
 		nginxMsg = "\033[1;31mConfig Error\033[0m"
 	}
@@ -370,14 +620,13 @@ func (r *Renderer) RenderHealthIndicators() string {
 	// Check configuration
 	configStatus := "\033[32m✓\033[0m"
 	configMsg := "\033[1;32mValid\033[0m"
-	errors, err := nginxService.GetConfigErrors()
-	if err != nil || len(errors) > 0 {
+	if health.ConfigErr != nil || len(health.ConfigErrors) > 0 {
 		configStatus = "\033[31m✗\033[0m"
-		configMsg = fmt.Sprintf("\033[1;31m%d Errors\033[0m", len(errors))
+		configMsg = fmt.Sprintf("\033[1;31m%d Errors\033[0m", len(health.ConfigErrors))
 	}
 
-	// Get system metrics
-	sysMetrics, _ := nginxService.GetSystemMetrics()
+	// System metrics
+	sysMetrics := health.System
 	diskMsg := "\033[1;32mOK\033[0m"
 	if sysMetrics != nil && sysMetrics.DiskUsage != "" {
 		diskMsg = fmt.Sprintf("\033[1;97m%s%% Used\033[0m", sysMetrics.DiskUsage)
@@ -433,42 +682,8 @@ func (r *Renderer) RenderSiteDistributionChart(m *model.Model) string {
 	return styles.Panel.Render(lipgloss.JoinVertical(lipgloss.Left, title, bc.View()))
 }
 
+// RenderMetricsView renders the metric charts from the collected histories
 func (r *Renderer) RenderMetricsView(m *model.Model, width, height int) string {
-	// Collect real metrics
-	nginxService := nginx.New()
-	metrics, err := nginxService.GetMetrics()
-
-	// Update history with real data
-	if err == nil {
-		// Calculate network rate (MB/s) from change in total bytes
-		var networkRate float64
-		if m.LastNetworkIn > 0 && m.LastNetworkOut > 0 {
-			// Calculate change since last measurement
-			deltaIn := metrics.NetworkIn - m.LastNetworkIn
-			deltaOut := metrics.NetworkOut - m.LastNetworkOut
-			networkRate = (deltaIn + deltaOut) / 1024 // Convert to MB/s
-		}
-
-		// Store current values for next calculation
-		m.LastNetworkIn = metrics.NetworkIn
-		m.LastNetworkOut = metrics.NetworkOut
-
-		// Add new data point (will grow from 0 to 50 points)
-		if len(m.CPUHistory) < 50 {
-			// Still filling up - just append
-			m.CPUHistory = append(m.CPUHistory, metrics.CPU)
-			m.MemHistory = append(m.MemHistory, metrics.Memory)
-			m.NetHistory = append(m.NetHistory, networkRate)
-			m.RequestHistory = append(m.RequestHistory, metrics.RequestRate)
-		} else {
-			// Full - shift and add new data
-			m.CPUHistory = append(m.CPUHistory[1:], metrics.CPU)
-			m.MemHistory = append(m.MemHistory[1:], metrics.Memory)
-			m.NetHistory = append(m.NetHistory[1:], networkRate)
-			m.RequestHistory = append(m.RequestHistory[1:], metrics.RequestRate)
-		}
-	}
-
 	// Add section header
 	headerStyle := lipgloss.NewStyle().
 		Bold(true).
@@ -477,12 +692,17 @@ func (r *Renderer) RenderMetricsView(m *model.Model, width, height int) string {
 		MarginTop(1)
 	sectionHeader := headerStyle.Render("📈 Real-Time System Metrics")
 
-	// Calculate chart dimensions to use full width with better spacing
-	chartWidth := (width / 2) - 8
+	if m.MetricsBreakdown {
+		return lipgloss.JoinVertical(lipgloss.Left, sectionHeader, renderConnections(m), "", r.RenderMetricsBreakdown(m, width, height-4))
+	}
+
+	// Calculate chart dimensions to use full width with better spacing:
+	// three charts per row, latency spanning two columns of the second
+	chartWidth := (width-8)/3 - 2
 	chartHeight := (height / 2) - 5
 
-	if chartWidth < 40 {
-		chartWidth = 40
+	if chartWidth < 30 {
+		chartWidth = 30
 	}
 	if chartHeight < 10 {
 		chartHeight = 10
@@ -493,12 +713,13 @@ func (r *Renderer) RenderMetricsView(m *model.Model, width, height int) string {
 	memChart := r.RenderLineChart("Memory Usage", m.MemHistory, styles.AccentSecondary, chartWidth, chartHeight)
 	netChart := r.RenderLineChart("Network Traffic", m.NetHistory, styles.AccentSuccess, chartWidth, chartHeight)
 	reqChart := r.RenderLineChart("Request Rate", m.RequestHistory, styles.AccentWarning, chartWidth, chartHeight)
+	latencyChart := r.RenderLatencyChart(m, 2*chartWidth+6, chartHeight)
 
 	// Create rows with proper spacing
-	row1 := lipgloss.JoinHorizontal(lipgloss.Top, cpuChart, "    ", memChart)
-	row2 := lipgloss.JoinHorizontal(lipgloss.Top, netChart, "    ", reqChart)
+	row1 := lipgloss.JoinHorizontal(lipgloss.Top, cpuChart, "    ", memChart, "    ", netChart)
+	row2 := lipgloss.JoinHorizontal(lipgloss.Top, reqChart, "    ", latencyChart)
 
-	return lipgloss.JoinVertical(lipgloss.Left, sectionHeader, "", row1, "", row2)
+	return lipgloss.JoinVertical(lipgloss.Left, sectionHeader, renderConnections(m), row1, "", row2)
 }
 
 // RenderLineChart renders a line chart with specified dimensions
@@ -679,8 +900,162 @@ func (r *Renderer) RenderHelp(m *model.Model, width int) string {
 			styles.HelpSeparator.Render("  │  "),
 		)
 	}
+
+	// Add "filter" and "select logs" options only on Logs tab
+	if m.ActiveTab == model.LogsTab {
+		actionParts = append(actionParts,
+			styles.HelpKey.Render("/"),
+			styles.HelpSeparator.Render(" "),
+			styles.HelpDesc.Render("filter"),
+			styles.HelpSeparator.Render("  │  "),
+			styles.HelpKey.Render("s"),
+			styles.HelpSeparator.Render(" "),
+			styles.HelpDesc.Render("select logs"),
+			styles.HelpSeparator.Render("  │  "),
+		)
+	}
+
+	// Add breakdown and drill-down options only on Traffic tab
+	if m.ActiveTab == model.TrafficTab {
+		actionParts = append(actionParts,
+			styles.HelpKey.Render("d"),
+			styles.HelpSeparator.Render(" "),
+			styles.HelpDesc.Render("breakdown"),
+			styles.HelpSeparator.Render("  │  "),
+			styles.HelpKey.Render("o"),
+			styles.HelpSeparator.Render(" "),
+			styles.HelpDesc.Render("sort"),
+			styles.HelpSeparator.Render("  │  "),
+			styles.HelpKey.Render("enter"),
+			styles.HelpSeparator.Render(" "),
+			styles.HelpDesc.Render("show requests"),
+			styles.HelpSeparator.Render("  │  "),
+		)
+	}
+
+	// Add "unban" option only on Bans tab
+	if m.ActiveTab == model.BansTab {
+		actionParts = append(actionParts,
+			styles.HelpKey.Render("u"),
+			styles.HelpSeparator.Render(" "),
+			styles.HelpDesc.Render("unban"),
+			styles.HelpSeparator.Render("  │  "),
+		)
+	}
+
+	// Add detail pane options only on Logs tab
+	if m.ActiveTab == model.LogsTab && m.LogDetail != nil {
+		actionParts = append(actionParts,
+			styles.HelpKey.Render("y"),
+			styles.HelpSeparator.Render(" "),
+			styles.HelpDesc.Render("copy curl"),
+			styles.HelpSeparator.Render("  │  "),
+			styles.HelpKey.Render("esc"),
+			styles.HelpSeparator.Render(" "),
+			styles.HelpDesc.Render("close"),
+			styles.HelpSeparator.Render("  │  "),
+		)
+	} else if m.ActiveTab == model.LogsTab {
+		actionParts = append(actionParts,
+			styles.HelpKey.Render("enter"),
+			styles.HelpSeparator.Render(" "),
+			styles.HelpDesc.Render("details"),
+			styles.HelpSeparator.Render("  │  "),
+			styles.HelpKey.Render("p"),
+			styles.HelpSeparator.Render(" "),
+			styles.HelpDesc.Render("pause"),
+			styles.HelpSeparator.Render("  │  "),
+			styles.HelpKey.Render("F"),
+			styles.HelpSeparator.Render(" "),
+			styles.HelpDesc.Render("follow"),
+			styles.HelpSeparator.Render("  │  "),
+			styles.HelpKey.Render(":"),
+			styles.HelpSeparator.Render(" "),
+			styles.HelpDesc.Render("jump"),
+			styles.HelpSeparator.Render("  │  "),
+			styles.HelpKey.Render("b"),
+			styles.HelpSeparator.Render(" "),
+			styles.HelpDesc.Render("timeline"),
+			styles.HelpSeparator.Render("  │  "),
+		)
+	}
+
+	// Add "export" option on the Logs and Stats tabs
+	if m.ActiveTab == model.LogsTab || m.ActiveTab == model.StatsTab {
+		actionParts = append(actionParts,
+			styles.HelpKey.Render("e"),
+			styles.HelpSeparator.Render(" "),
+			styles.HelpDesc.Render("export"),
+			styles.HelpSeparator.Render("  │  "),
+		)
+	}
+
+	// Add "time range" option on the Logs, Stats and Traffic tabs
+	if m.ActiveTab == model.LogsTab || m.ActiveTab == model.StatsTab || m.ActiveTab == model.TrafficTab {
+		actionParts = append(actionParts,
+			styles.HelpKey.Render("t"),
+			styles.HelpSeparator.Render(" "),
+			styles.HelpDesc.Render("time range"),
+			styles.HelpSeparator.Render("  │  "),
+		)
+	}
+
+	// Add level, grouping and jump options only on Error Log tab
+	if m.ActiveTab == model.ErrorLogTab {
+		actionParts = append(actionParts,
+			styles.HelpKey.Render("f"),
+			styles.HelpSeparator.Render(" "),
+			styles.HelpDesc.Render("level"),
+			styles.HelpSeparator.Render("  │  "),
+			styles.HelpKey.Render("g"),
+			styles.HelpSeparator.Render(" "),
+			styles.HelpDesc.Render("group"),
+			styles.HelpSeparator.Render("  │  "),
+			styles.HelpKey.Render("enter"),
+			styles.HelpSeparator.Render(" "),
+			styles.HelpDesc.Render("access log"),
+			styles.HelpSeparator.Render("  │  "),
+		)
+	}
+
+	// Add "breakdown" option on the Metrics tab, and "stub_status" until a
+	// status page can be polled
+	if m.ActiveTab == model.MetricsTab {
+		breakdown := "breakdown"
+		if m.MetricsBreakdown {
+			breakdown = "charts"
+		}
+		actionParts = append(actionParts,
+			styles.HelpKey.Render("d"),
+			styles.HelpSeparator.Render(" "),
+			styles.HelpDesc.Render(breakdown),
+			styles.HelpSeparator.Render("  │  "),
+		)
+		if metrics, _ := m.LastMetrics.(*nginx.Metrics); metrics == nil || metrics.Connections == nil {
+			actionParts = append(actionParts,
+				styles.HelpKey.Render("c"),
+				styles.HelpSeparator.Render(" "),
+				styles.HelpDesc.Render("set up stub_status"),
+				styles.HelpSeparator.Render("  │  "),
+			)
+		}
+	}
+
+	// Add "renew" option only on Certificates tab
+	if m.ActiveTab == model.CertificatesTab {
+		actionParts = append(actionParts,
+			styles.HelpKey.Render("n"),
+			styles.HelpSeparator.Render(" "),
+			styles.HelpDesc.Render("renew certificates"),
+			styles.HelpSeparator.Render("  │  "),
+		)
+	}
 	
 	actionParts = append(actionParts,
+		styles.HelpKey.Render("!"),
+		styles.HelpSeparator.Render(" "),
+		styles.HelpDesc.Render("alerts"),
+		styles.HelpSeparator.Render("  │  "),
 		styles.HelpKey.Render("esc"),
 		styles.HelpSeparator.Render(" "),
 		styles.HelpDesc.Render("back"),